@@ -4,18 +4,27 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"os"
 
 	"github.com/joho/godotenv"
 
+	"unichance-backend-go/internal/account"
 	"unichance-backend-go/internal/auth"
+	"unichance-backend-go/internal/auth/oidc"
 	"unichance-backend-go/internal/config"
+	"unichance-backend-go/internal/counselor"
 	"unichance-backend-go/internal/db"
+	"unichance-backend-go/internal/documents"
+	"unichance-backend-go/internal/fx"
 	httpRouter "unichance-backend-go/internal/http"
+	"unichance-backend-go/internal/jwtkeys"
+	"unichance-backend-go/internal/mail"
 
 	// "unichance-backend-go/internal/llm"
 	"unichance-backend-go/internal/profile"
 	"unichance-backend-go/internal/programs"
+	"unichance-backend-go/internal/scoring"
 	"unichance-backend-go/internal/universities"
 )
 
@@ -46,13 +55,155 @@ func main() {
 	profRepo := profile.Repo{DB: pool}
 	profH := profile.Handler{Repo: profRepo, DB: pool}
 
+	// mail: SMTP if configured, otherwise local outbox dir
+	var mailer mail.Mailer = mail.FileMailer{Dir: cfg.MailOutboxDir, From: cfg.MailFrom}
+	if cfg.SMTPHost != "" {
+		mailer = mail.SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUser,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		}
+	}
+
+	// login throttle
+	throttlePolicy := auth.DefaultThrottlePolicy()
+	throttlePolicy.LockoutAfter = cfg.LoginLockoutAfter
+	throttlePolicy.LockoutFor = cfg.LoginLockoutDuration
+	var throttle auth.LoginThrottle
+	switch cfg.LoginThrottle {
+	case "memory":
+		throttle = auth.NewMemoryThrottle(throttlePolicy)
+	case "off":
+	default:
+		throttle = auth.PgThrottle{DB: pool, Policy: throttlePolicy}
+	}
+
+	// reverse proxies allowed to set X-Forwarded-For
+	var trustedProxies []*net.IPNet
+	for _, cidr := range cfg.TrustedProxies {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Fatalf("TRUSTED_PROXIES: %v", err)
+		}
+		trustedProxies = append(trustedProxies, ipNet)
+	}
+
+	// OIDC social login providers
+	oidcProviders := map[string]*oidc.Provider{}
+	for _, p := range cfg.OIDCProviders {
+		oidcProviders[p.Name] = oidc.NewProvider(oidc.Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}, nil)
+	}
+
+	// JWT signing keys: Postgres-backed ring when rotating or using asymmetric
+	// keys, otherwise JWT_SECRET alone (kid "legacy")
+	keyOpts := jwtkeys.Options{
+		Alg:          cfg.JwtAlg,
+		Grace:        cfg.JwtKeyGrace,
+		LegacySecret: cfg.JwtSecret,
+		LegacyGrace:  cfg.JwtLegacyGrace,
+	}
+	if cfg.JwtKeyRotation > 0 || cfg.JwtAlg != jwtkeys.AlgHS256 {
+		keyOpts.Store = jwtkeys.PgStore{DB: pool}
+	}
+	keys, err := jwtkeys.New(context.Background(), keyOpts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.JwtKeyRotation > 0 {
+		keys.StartRotation(context.Background(), cfg.JwtKeyRotation, log.Printf)
+	}
+
+	// password policy (+ optional offline breached-password list)
+	passwords := auth.DefaultPasswordPolicy()
+	passwords.MinLength = cfg.PasswordMinLength
+	passwords.RequireLower, passwords.RequireDigit = false, false
+	for _, class := range cfg.PasswordRequire {
+		switch class {
+		case "upper":
+			passwords.RequireUpper = true
+		case "lower":
+			passwords.RequireLower = true
+		case "digit":
+			passwords.RequireDigit = true
+		case "symbol":
+			passwords.RequireSymbol = true
+		}
+	}
+	if cfg.PasswordBreachedPath != "" {
+		passwords.Breached, err = auth.LoadBreachedList(cfg.PasswordBreachedPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	// auth
-	authSvc := auth.Service{DB: pool, JwtSecret: cfg.JwtSecret}
+	authSvc := auth.Service{
+		DB:         pool,
+		JwtSecret:  cfg.JwtSecret,
+		Keys:       keys,
+		AccessTTL:  cfg.AccessTokenTTL,
+		RefreshTTL: cfg.RefreshTokenTTL,
+		Mailer:     mailer,
+		AppURL:     cfg.AppURL,
+		Throttle:   throttle,
+		OIDC:       oidcProviders,
+		Passwords:  passwords,
+	}
 	authH := auth.Handler{Svc: authSvc}
 
+	// document vault
+	docStorage, err := documents.NewStorage(cfg.DocumentsStorage, cfg.DocumentsDir, documents.S3Storage{
+		Endpoint:  cfg.S3Endpoint,
+		Region:    cfg.S3Region,
+		Bucket:    cfg.S3Bucket,
+		AccessKey: cfg.S3AccessKey,
+		SecretKey: cfg.S3SecretKey,
+		PathStyle: cfg.S3PathStyle,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	docSvc := documents.Service{DB: pool, Storage: docStorage, MaxBytes: cfg.DocumentsMaxBytes}
+	docH := documents.Handler{Svc: docSvc}
+
+	accountH := account.Handler{Svc: account.Service{DB: pool, Auth: authSvc, Grace: cfg.AccountDeleteGrace, Blobs: docSvc}}
+
+	// counselor <-> student links
+	counselorSvc := counselor.Service{DB: pool, Mailer: mailer, AppURL: cfg.AppURL}
+	counselorH := counselor.Handler{Svc: counselorSvc}
+
 	// programs
 	progRepo := programs.Repo{DB: pool}
-	progH := programs.Handler{Repo: progRepo, DB: pool, ProfileRepo: profRepo}
+	scorer, err := scoring.LookupScorer(cfg.Scorer)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("scorer: %s", scorer.Version())
+	if cfg.ScoringWeights != "" {
+		if err := scoring.ActiveWeights.LoadFile(cfg.ScoringWeights); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("scoring weights %q from %s", scoring.ActiveWeights.Get().Version, cfg.ScoringWeights)
+		scoring.ActiveWeights.Watch(context.Background(), cfg.ScoringWeights, cfg.ScoringWeightsPoll, log.Printf)
+	}
+	progH := programs.Handler{
+		Repo:            progRepo,
+		DB:              pool,
+		ProfileRepo:     profRepo,
+		FX:              fx.Service{DB: pool},
+		Scorer:          scorer,
+		DisplayCurrency: cfg.DisplayCurrency,
+	}
+	profH.Matcher = progH
 	uniRepo := universities.Repo{DB: pool}
 	uniH := universities.Handler{Repo: uniRepo}
 
@@ -61,12 +212,20 @@ func main() {
 	// llmH := llm.Handler{DB: pool, ProfileRepo: profRepo, LLMURL: llmURL}
 
 	e := httpRouter.NewRouter(httpRouter.Deps{
-		AuthHandler:     authH,
-		ProgramsHandler: progH,
-		ProfileHandler:  profH,
+		AuthHandler:      authH,
+		AccountHandler:   accountH,
+		CounselorHandler: counselorH,
+		DocumentsHandler: docH,
+		ProgramsHandler:  progH,
+		ProfileHandler:   profH,
 		// LLMHandler:          llmH,
-		JwtSecret:           cfg.JwtSecret,
-		UniversitiesHandler: uniH,
+		TokenKeys:            keys,
+		Revocation:           authSvc,
+		CounselorAccess:      counselorSvc,
+		EmailVerifier:        authSvc,
+		RequireVerifiedEmail: cfg.RequireVerifiedEmail,
+		TrustedProxies:       trustedProxies,
+		UniversitiesHandler:  uniH,
 	})
 
 	log.Println("api listening on :" + cfg.Port)
@@ -0,0 +1,44 @@
+// fit_admission refits the per-program admission probability models from the
+// latest admission_stats and the outcomes students reported. Run it after
+// importing admission statistics, or periodically as outcomes come in.
+//
+//	go run ./cmd/fit_admission
+//	go run ./cmd/fit_admission -dry-run
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/joho/godotenv"
+
+	"unichance-backend-go/internal/admission"
+	"unichance-backend-go/internal/config"
+	"unichance-backend-go/internal/db"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "fit and report, don't write")
+	flag.Parse()
+
+	_ = godotenv.Load()
+	cfg := config.Load()
+	if cfg.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL is required")
+	}
+	ctx := context.Background()
+	pool, err := db.Connect(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer pool.Close()
+
+	res, err := admission.Repo{DB: pool}.FitAll(ctx, *dryRun)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("fitted %d program(s) on %d outcome(s), %d on the acceptance-rate prior, %d without admission data\n",
+		res.Fitted, res.Outcomes, res.Prior, res.Skipped)
+}
@@ -0,0 +1,86 @@
+// import_fx loads exchange rates into fx_rates from a local file: a CSV of
+// date,currency,per_eur rows or an ECB eurofxref XML download
+// (eurofxref-daily.xml, eurofxref-hist-90d.xml, ...).
+//
+//	go run ./cmd/import_fx -file rates.csv
+//	go run ./cmd/import_fx -file eurofxref-daily.xml
+//	go run ./cmd/import_fx -file rates.csv -dry-run
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"unichance-backend-go/internal/config"
+	"unichance-backend-go/internal/db"
+	"unichance-backend-go/internal/fx"
+)
+
+func main() {
+	file := flag.String("file", "", "CSV or ECB XML file with rates")
+	format := flag.String("format", "", "csv or ecb (default: by file extension)")
+	dryRun := flag.Bool("dry-run", false, "parse and report, don't write")
+	flag.Parse()
+	if *file == "" {
+		log.Fatal("-file is required")
+	}
+	if *format == "" {
+		*format = "csv"
+		if strings.EqualFold(filepath.Ext(*file), ".xml") {
+			*format = "ecb"
+		}
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	var rates []fx.Rate
+	switch *format {
+	case "csv":
+		rates, err = fx.ParseCSV(f)
+	case "ecb":
+		rates, err = fx.ParseECB(f)
+	default:
+		log.Fatalf("unknown format %q", *format)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	currencies := map[string]bool{}
+	for _, r := range rates {
+		currencies[r.Currency] = true
+	}
+	fmt.Printf("%d rate(s) for %d currencies\n", len(rates), len(currencies))
+	if *dryRun {
+		return
+	}
+
+	_ = godotenv.Load()
+	cfg := config.Load()
+	if cfg.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL is required")
+	}
+	ctx := context.Background()
+	pool, err := db.Connect(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer pool.Close()
+
+	n, err := fx.Service{DB: pool}.Store(ctx, rates, *format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("imported %d rate(s)\n", n)
+}
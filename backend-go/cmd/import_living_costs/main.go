@@ -0,0 +1,64 @@
+// import_living_costs loads the cost-of-living dataset into living_costs from
+// a local CSV (see livingcost.ParseCSV for the columns). Rows with an empty
+// city are the country default.
+//
+//	go run ./cmd/import_living_costs -file living_costs.csv
+//	go run ./cmd/import_living_costs -file living_costs.csv -dry-run
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/joho/godotenv"
+
+	"unichance-backend-go/internal/config"
+	"unichance-backend-go/internal/db"
+	"unichance-backend-go/internal/livingcost"
+)
+
+func main() {
+	file := flag.String("file", "", "CSV file with living costs")
+	dryRun := flag.Bool("dry-run", false, "parse and report, don't write")
+	flag.Parse()
+	if *file == "" {
+		log.Fatal("-file is required")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	entries, err := livingcost.ParseCSV(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d location(s)\n", len(entries))
+	if *dryRun {
+		return
+	}
+
+	_ = godotenv.Load()
+	cfg := config.Load()
+	if cfg.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL is required")
+	}
+	ctx := context.Background()
+	pool, err := db.Connect(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer pool.Close()
+
+	n, err := livingcost.Repo{DB: pool}.Store(ctx, entries, filepath.Base(*file))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("imported %d location(s)\n", n)
+}
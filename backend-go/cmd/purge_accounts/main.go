@@ -0,0 +1,82 @@
+// purge_accounts hard-deletes accounts whose DELETE /me grace period is over.
+// Run it from cron (e.g. hourly):
+//
+//	go run ./cmd/purge_accounts
+//	go run ./cmd/purge_accounts -dry-run
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"unichance-backend-go/internal/account"
+	"unichance-backend-go/internal/config"
+	"unichance-backend-go/internal/db"
+	"unichance-backend-go/internal/documents"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only check the dataset registry and count due accounts")
+	flag.Parse()
+
+	_ = godotenv.Load()
+	cfg := config.Load()
+	if cfg.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL is required")
+	}
+
+	ctx := context.Background()
+	pool, err := db.Connect(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer pool.Close()
+
+	// stored documents are deleted along with the account
+	storage, err := documents.NewStorage(cfg.DocumentsStorage, cfg.DocumentsDir, documents.S3Storage{
+		Endpoint:  cfg.S3Endpoint,
+		Region:    cfg.S3Region,
+		Bucket:    cfg.S3Bucket,
+		AccessKey: cfg.S3AccessKey,
+		SecretKey: cfg.S3SecretKey,
+		PathStyle: cfg.S3PathStyle,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	svc := account.Service{DB: pool, Blobs: documents.Service{DB: pool, Storage: storage}}
+
+	// a user-linked table missing from account.Datasets would keep personal
+	// data after the purge (and be missing from exports) — refuse to run
+	missing, err := svc.Unregistered(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(missing) > 0 {
+		log.Fatalf("tables not covered by account.Datasets: %s", strings.Join(missing, ", "))
+	}
+
+	if *dryRun {
+		var due int
+		err := pool.QueryRow(ctx,
+			`SELECT count(*) FROM users WHERE deleted_at IS NOT NULL AND purge_after <= now()`,
+		).Scan(&due)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%d account(s) due for purge\n", due)
+		return
+	}
+
+	n, err := svc.Purge(ctx, time.Now())
+	fmt.Printf("purged %d account(s)\n", n)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,125 @@
+// Package account implements the personal-data endpoints: GET /me/export and
+// DELETE /me with a grace period, plus the purge job behind cmd/purge_accounts.
+package account
+
+// Dataset is one slice of a user's personal data. Every table holding user data
+// must be covered by a dataset: export reads it, and the purge job refuses to
+// run while a table with a user_id column is not listed here (see Unregistered).
+//
+// Export is a SELECT with $1 = user id (text); rows are written as a JSON array.
+// Purge is only needed when rows are not removed by ON DELETE CASCADE from
+// users; it gets $1 = user id (text) and $2 = email.
+type Dataset struct {
+	Name   string
+	Tables []string
+	Export string
+	Purge  string
+}
+
+// Datasets is the registry. Add new user-linked tables here.
+var Datasets = []Dataset{
+	{
+		Name:   "account",
+		Tables: []string{"users"},
+		Export: `SELECT id, email, role, created_at, email_verified_at, deleted_at, purge_after
+      FROM users WHERE id::text = $1`,
+	},
+	{
+		Name:   "profile",
+		Tables: []string{"profiles"},
+		Export: `SELECT * FROM profiles WHERE user_id::text = $1`,
+	},
+	{
+		Name:   "profile_history",
+		Tables: []string{"profile_versions"},
+		Export: `SELECT version, created_at, data FROM profile_versions
+      WHERE user_id::text = $1 ORDER BY version`,
+	},
+	{
+		Name:   "achievements",
+		Tables: []string{"profile_achievements"},
+		Export: `SELECT id, category, level, year, description, created_at, updated_at
+      FROM profile_achievements WHERE user_id::text = $1 ORDER BY created_at`,
+	},
+	{
+		Name:   "scenarios",
+		Tables: []string{"profile_scenarios"},
+		Export: `SELECT id, name, overrides, created_at, updated_at
+      FROM profile_scenarios WHERE user_id::text = $1 ORDER BY created_at`,
+	},
+	{
+		Name:   "scores",
+		Tables: []string{"scores"},
+		Export: `SELECT s.id, s.program_id, s.score, s.reasons, s.reason_codes, s.profile_version, s.scenario_id, s.scorer_version, s.created_at
+      FROM scores s JOIN profiles p ON p.id = s.profile_id
+      WHERE p.user_id::text = $1 ORDER BY s.created_at`,
+	},
+	{
+		Name:   "admission_outcomes",
+		Tables: []string{"admission_outcomes"},
+		Export: `SELECT id, program_id, year, decision, gpa_us4, sat, gre, gmat, ielts, testdaf, created_at
+      FROM admission_outcomes WHERE user_id::text = $1 ORDER BY created_at`,
+	},
+	{
+		Name:   "match_history",
+		Tables: []string{"match_history"},
+		Export: `SELECT * FROM match_history WHERE user_id::text = $1 ORDER BY created_at`,
+	},
+	{
+		Name:   "sessions",
+		Tables: []string{"user_sessions", "refresh_tokens"},
+		Export: `SELECT id, device, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at
+      FROM user_sessions WHERE user_id::text = $1 ORDER BY created_at`,
+	},
+	{
+		Name:   "linked_accounts",
+		Tables: []string{"external_identities"},
+		Export: `SELECT provider, subject, email, created_at, last_login_at
+      FROM external_identities WHERE user_id::text = $1`,
+	},
+	{
+		Name:   "security_log",
+		Tables: []string{"auth_audit_log", "login_attempts"},
+		Export: `SELECT event, ip, detail, created_at FROM auth_audit_log
+      WHERE user_id::text = $1 ORDER BY created_at`,
+		// audit rows are SET NULL on delete and keep the email; drop them outright
+		Purge: `WITH a AS (
+        DELETE FROM auth_audit_log WHERE user_id::text = $1 OR lower(email) = lower($2)
+      )
+      DELETE FROM login_attempts WHERE key = 'email:' || lower($2)`,
+	},
+	{
+		// both sides: links the user made as a counselor or accepted as a student,
+		// and counselor requests on the user's data
+		Name:   "counselors",
+		Tables: []string{"counselor_links", "counselor_access_log"},
+		Export: `SELECT 'link' AS kind, to_jsonb(l) AS row FROM counselor_links l
+      WHERE l.counselor_id::text = $1 OR l.student_id::text = $1
+      UNION ALL
+      SELECT 'access', to_jsonb(a) FROM counselor_access_log a
+      WHERE a.student_id::text = $1 OR a.counselor_id::text = $1`,
+		// pending invitations to the email aren't tied to the user row yet
+		Purge: `DELETE FROM counselor_links WHERE student_id IS NULL AND lower(invite_email) = lower($2)`,
+	},
+	{
+		// metadata only; the files are removed by Service.Blobs on purge
+		Name:   "documents",
+		Tables: []string{"documents", "document_field_links"},
+		Export: `SELECT d.id, d.kind, d.filename, d.content_type, d.size_bytes, d.sha256, d.created_at,
+        coalesce((SELECT array_agg(f.field) FROM document_field_links f WHERE f.document_id = d.id), '{}') AS fields
+      FROM documents d WHERE d.user_id::text = $1 ORDER BY d.created_at`,
+	},
+	{
+		// legacy tables from 001_initial_schema.sql, present on older databases
+		Name:   "applications",
+		Tables: []string{"applications", "reviews"},
+		Export: `SELECT 'application' AS kind, to_jsonb(a) AS row FROM applications a WHERE a.user_id::text = $1
+      UNION ALL
+      SELECT 'review', to_jsonb(r) FROM reviews r WHERE r.user_id::text = $1`,
+	},
+	{
+		// tokens only, nothing worth exporting
+		Name:   "auth_tokens",
+		Tables: []string{"password_reset_tokens", "revoked_tokens"},
+	},
+}
@@ -0,0 +1,55 @@
+package account
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"unichance-backend-go/internal/middleware"
+)
+
+type Handler struct{ Svc Service }
+
+// Export streams a zip with every dataset linked to the caller.
+func (h Handler) Export(c echo.Context) error {
+	u := c.Get("user").(middleware.CtxUser)
+	files, err := h.Svc.Export(c.Request().Context(), u.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	name := fmt.Sprintf("unichance-export-%s.zip", time.Now().UTC().Format("20060102"))
+	c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+name+`"`)
+	c.Response().WriteHeader(http.StatusOK)
+	return WriteZip(c.Response(), files)
+}
+
+type deleteReq struct {
+	Password string `json:"password"`
+}
+
+// Delete schedules the account for purge. Logging in again during the grace
+// period cancels it.
+func (h Handler) Delete(c echo.Context) error {
+	u := c.Get("user").(middleware.CtxUser)
+	var req deleteReq
+	_ = c.Bind(&req)
+
+	purgeAfter, err := h.Svc.Delete(c.Request().Context(), u.ID, req.Password)
+	switch err {
+	case nil:
+	case ErrInvalidPassword:
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	case ErrNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusAccepted, map[string]any{
+		"deleted":     true,
+		"purge_after": purgeAfter,
+	})
+}
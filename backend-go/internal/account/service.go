@@ -0,0 +1,234 @@
+package account
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/crypto/bcrypt"
+
+	"unichance-backend-go/internal/auth"
+)
+
+var (
+	ErrNotFound        = errors.New("account not found")
+	ErrInvalidPassword = errors.New("invalid password")
+)
+
+type Service struct {
+	DB    *pgxpool.Pool
+	Auth  auth.Service
+	Grace time.Duration // soft delete -> purge; default 30 days
+
+	// Blobs removes files kept outside Postgres (documents.Service); nil
+	// when the caller only needs exports.
+	Blobs BlobPurger
+}
+
+// BlobPurger deletes a user's stored files. Rows are removed by the purge
+// transaction; this only covers the bytes behind them.
+type BlobPurger interface {
+	PurgeUser(ctx context.Context, userID string) error
+}
+
+func (s Service) grace() time.Duration {
+	if s.Grace <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return s.Grace
+}
+
+// File is one entry of the export archive.
+type File struct {
+	Name string
+	Data json.RawMessage
+}
+
+// Export collects every registered dataset for the user as JSON documents,
+// plus a manifest.json describing the archive.
+func (s Service) Export(ctx context.Context, userID string) ([]File, error) {
+	files := make([]File, 0, len(Datasets)+1)
+	names := []string{}
+	for _, d := range Datasets {
+		if d.Export == "" {
+			continue
+		}
+		var data []byte
+		err := s.DB.QueryRow(ctx,
+			`SELECT coalesce(json_agg(t), '[]'::json) FROM (`+d.Export+`) t`, userID,
+		).Scan(&data)
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", d.Name, err)
+		}
+		files = append(files, File{Name: d.Name + ".json", Data: data})
+		names = append(names, d.Name)
+	}
+
+	manifest, err := json.MarshalIndent(map[string]any{
+		"user_id":     userID,
+		"exported_at": time.Now().UTC(),
+		"format":      "one JSON array of rows per dataset",
+		"datasets":    names,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]File{{Name: "manifest.json", Data: manifest}}, files...), nil
+}
+
+// WriteZip writes files as a zip archive.
+func WriteZip(w io.Writer, files []File) error {
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.Create(f.Name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.Data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Delete soft-deletes the account: it is hidden from login until restored and
+// purged after the grace period. Accounts with a password must confirm it;
+// OIDC-only accounts (empty hash) are already proven by their session.
+func (s Service) Delete(ctx context.Context, userID, password string) (time.Time, error) {
+	var hash string
+	err := s.DB.QueryRow(ctx, `SELECT password_hash FROM users WHERE id=$1`, userID).Scan(&hash)
+	if err == pgx.ErrNoRows {
+		return time.Time{}, ErrNotFound
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	if hash != "" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return time.Time{}, ErrInvalidPassword
+	}
+
+	purgeAfter := time.Now().Add(s.grace())
+	_, err = s.DB.Exec(ctx,
+		`UPDATE users SET deleted_at=now(), purge_after=$2 WHERE id=$1`,
+		userID, purgeAfter,
+	)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := s.Auth.RevokeAllForUser(ctx, userID); err != nil {
+		return time.Time{}, err
+	}
+	s.Auth.Audit(ctx, userID, "", "", "account_deleted", purgeAfter.UTC().Format(time.RFC3339))
+	return purgeAfter, nil
+}
+
+// Purge hard-deletes accounts whose grace period is over and returns how many
+// were removed. Each account goes in its own transaction so one failure doesn't
+// block the rest.
+func (s Service) Purge(ctx context.Context, now time.Time) (int, error) {
+	rows, err := s.DB.Query(ctx, `
+    SELECT id::text, email FROM users
+    WHERE deleted_at IS NOT NULL AND purge_after <= $1
+  `, now)
+	if err != nil {
+		return 0, err
+	}
+	type victim struct{ id, email string }
+	var victims []victim
+	for rows.Next() {
+		var v victim
+		if err := rows.Scan(&v.id, &v.email); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		victims = append(victims, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	var errs []error
+	for _, v := range victims {
+		if err := s.purgeOne(ctx, v.id, v.email); err != nil {
+			errs = append(errs, fmt.Errorf("purge %s: %w", v.id, err))
+			continue
+		}
+		purged++
+	}
+	return purged, errors.Join(errs...)
+}
+
+func (s Service) purgeOne(ctx context.Context, userID, email string) error {
+	// files first: if that fails the account stays and the next run retries
+	if s.Blobs != nil {
+		if err := s.Blobs.PurgeUser(ctx, userID); err != nil {
+			return fmt.Errorf("blobs: %w", err)
+		}
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, d := range Datasets {
+		if d.Purge == "" {
+			continue
+		}
+		if _, err := tx.Exec(ctx, d.Purge, userID, email); err != nil {
+			return fmt.Errorf("%s: %w", d.Name, err)
+		}
+	}
+	// everything else goes through ON DELETE CASCADE
+	if _, err := tx.Exec(ctx, `DELETE FROM users WHERE id::text=$1 AND deleted_at IS NOT NULL`, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Unregistered lists tables that look user-linked (a user_id column or a
+// foreign key to users) but are not covered by any Dataset. The purge command
+// refuses to run while this is non-empty, so new tables can't silently keep
+// data of deleted users.
+func (s Service) Unregistered(ctx context.Context) ([]string, error) {
+	rows, err := s.DB.Query(ctx, `
+    SELECT DISTINCT table_name::text FROM information_schema.columns
+    WHERE table_schema = current_schema() AND column_name = 'user_id'
+    UNION
+    SELECT DISTINCT conrelid::regclass::text FROM pg_constraint
+    WHERE contype = 'f' AND confrelid = 'users'::regclass
+  `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	known := map[string]bool{}
+	for _, d := range Datasets {
+		for _, t := range d.Tables {
+			known[t] = true
+		}
+	}
+	var out []string
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		if !known[t] {
+			out = append(out, t)
+		}
+	}
+	sort.Strings(out)
+	return out, rows.Err()
+}
@@ -0,0 +1,65 @@
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteZip(t *testing.T) {
+	files := []File{
+		{Name: "manifest.json", Data: json.RawMessage(`{"user_id":"u1"}`)},
+		{Name: "profile.json", Data: json.RawMessage(`[{"gpa":3.7}]`)},
+	}
+	var buf bytes.Buffer
+	if err := WriteZip(&buf, files); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != len(files) {
+		t.Fatalf("got %d entries, want %d", len(zr.File), len(files))
+	}
+	for i, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := io.ReadAll(rc)
+		rc.Close()
+		if f.Name != files[i].Name || string(got) != string(files[i].Data) {
+			t.Errorf("entry %d = %s %s", i, f.Name, got)
+		}
+	}
+}
+
+func TestDatasetsRegistry(t *testing.T) {
+	names := map[string]bool{}
+	tables := map[string]string{}
+	for _, d := range Datasets {
+		if names[d.Name] {
+			t.Errorf("duplicate dataset %q", d.Name)
+		}
+		names[d.Name] = true
+		if d.Export != "" && !strings.Contains(d.Export, "$1") {
+			t.Errorf("%s: export query must filter by $1 (user id)", d.Name)
+		}
+		for _, tbl := range d.Tables {
+			if other, ok := tables[tbl]; ok {
+				t.Errorf("table %s registered by both %s and %s", tbl, other, d.Name)
+			}
+			tables[tbl] = d.Name
+		}
+	}
+	for _, tbl := range []string{"users", "profiles", "scores", "match_history"} {
+		if _, ok := tables[tbl]; !ok {
+			t.Errorf("table %s not covered by any dataset", tbl)
+		}
+	}
+}
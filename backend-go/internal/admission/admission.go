@@ -0,0 +1,181 @@
+// Package admission stores the per-program admission probability models
+// (admission_model_params) and fits them from admission_stats and the
+// outcomes students report (admission_outcomes). The model itself is
+// scoring.AdmissionModel; cmd/fit_admission runs Repo.FitAll.
+package admission
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"unichance-backend-go/internal/scoring"
+)
+
+type Repo struct {
+	DB *pgxpool.Pool
+}
+
+// FitResult summarizes a FitAll run.
+type FitResult struct {
+	Fitted   int // programs with reported outcomes, params saved
+	Prior    int // programs on the acceptance-rate prior, stale params dropped
+	Skipped  int // no acceptance rate: no model at all
+	Outcomes int // outcomes used
+}
+
+// FitAll refits every program: the prior from its latest acceptance rate,
+// refined with the admitted/rejected outcomes reported for it. Programs
+// without outcomes lose their saved params so the prior follows new
+// admission_stats. Waitlisted outcomes are not used.
+func (r Repo) FitAll(ctx context.Context, dryRun bool) (FitResult, error) {
+	var res FitResult
+	programs, err := r.programs(ctx)
+	if err != nil {
+		return res, err
+	}
+	outcomes, err := r.outcomes(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	batch := &pgx.Batch{}
+	for _, p := range programs {
+		if p.AcceptanceRate == nil {
+			res.Skipped++
+			continue
+		}
+		list := outcomes[p.ID]
+		if len(list) == 0 {
+			res.Prior++
+			batch.Queue(`DELETE FROM admission_model_params WHERE program_id::text = $1`, p.ID)
+			continue
+		}
+		m := scoring.FitAdmissionModel(scoring.PriorAdmissionModel(*p.AcceptanceRate), p, list)
+		res.Fitted++
+		res.Outcomes += m.Outcomes
+		coef, cov := flatten(m)
+		batch.Queue(`
+      INSERT INTO admission_model_params (program_id, coef, cov, n_outcomes)
+      VALUES ($1::uuid, $2, $3, $4)
+      ON CONFLICT (program_id) DO UPDATE SET
+        coef = EXCLUDED.coef, cov = EXCLUDED.cov,
+        n_outcomes = EXCLUDED.n_outcomes, fitted_at = now()
+    `, p.ID, coef, cov, m.Outcomes)
+	}
+	if dryRun {
+		return res, nil
+	}
+
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback(ctx)
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return res, err
+	}
+	return res, tx.Commit(ctx)
+}
+
+// programs loads what the model needs of every program: level, language
+// and the latest admission_stats row.
+func (r Repo) programs(ctx context.Context) ([]scoring.ProgramContext, error) {
+	rows, err := r.DB.Query(ctx, `
+    SELECT p.id::text, p.degree_level::text, p.language,
+      ads.acceptance_rate::float8, ads.avg_gpa::float8, ads.avg_ielts::float8, ads.avg_toefl,
+      ads.avg_sat, ads.avg_act, ads.avg_gre, ads.avg_gmat,
+      ads.avg_duolingo, ads.avg_pte, ads.avg_testdaf::float8
+    FROM programs p
+    LEFT JOIN LATERAL (
+      SELECT * FROM admission_stats s
+      WHERE s.program_id = p.id
+      ORDER BY year DESC
+      LIMIT 1
+    ) ads ON true
+  `)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (scoring.ProgramContext, error) {
+		var p scoring.ProgramContext
+		var language *string
+		err := row.Scan(&p.ID, &p.DegreeLevel, &language,
+			&p.AcceptanceRate, &p.AvgGPA, &p.AvgIELTS, &p.AvgTOEFL,
+			&p.AvgSAT, &p.AvgACT, &p.AvgGRE, &p.AvgGMAT,
+			&p.AvgDuolingo, &p.AvgPTE, &p.AvgTestDaF)
+		if language != nil {
+			p.Language = *language
+		}
+		return p, err
+	})
+}
+
+// outcomes loads the admitted/rejected reports by program id.
+func (r Repo) outcomes(ctx context.Context) (map[string][]scoring.Outcome, error) {
+	rows, err := r.DB.Query(ctx, `
+    SELECT program_id::text, decision = 'admitted',
+      gpa_us4::float8, sat::float8, gre::float8, gmat::float8, ielts::float8, testdaf::float8
+    FROM admission_outcomes
+    WHERE decision IN ('admitted','rejected')
+  `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string][]scoring.Outcome{}
+	for rows.Next() {
+		var id string
+		var o scoring.Outcome
+		f := &o.Features
+		if err := rows.Scan(&id, &o.Admitted, &f.GPA, &f.SAT, &f.GRE, &f.GMAT, &f.IELTS, &f.TestDaF); err != nil {
+			return nil, err
+		}
+		out[id] = append(out[id], o)
+	}
+	return out, rows.Err()
+}
+
+// JoinSQL joins the saved model of a program. Alias the programs table "p".
+const JoinSQL = `LEFT JOIN admission_model_params amp ON amp.program_id = p.id`
+
+// Columns selects the JoinSQL row for Scan.
+const Columns = `amp.coef, amp.cov, amp.n_outcomes`
+
+// Scanned receives Columns; all nil when the program has no saved model.
+type Scanned struct {
+	Coef, Cov []float64
+	Outcomes  *int
+}
+
+// Dest returns Scan destinations matching Columns.
+func (s *Scanned) Dest() []any {
+	return []any{&s.Coef, &s.Cov, &s.Outcomes}
+}
+
+// Model returns the saved model, nil without one (or a malformed row).
+func (s Scanned) Model() *scoring.AdmissionModel {
+	var m scoring.AdmissionModel
+	if len(s.Coef) != len(m.Coef) || len(s.Cov) != len(m.Cov)*len(m.Cov) {
+		return nil
+	}
+	copy(m.Coef[:], s.Coef)
+	for i := range m.Cov {
+		copy(m.Cov[i][:], s.Cov[i*len(m.Cov):])
+	}
+	if s.Outcomes != nil {
+		m.Outcomes = *s.Outcomes
+	}
+	return &m
+}
+
+// flatten is the inverse of Scanned.Model: coef and row-major cov.
+func flatten(m scoring.AdmissionModel) (coef, cov []float64) {
+	coef = append(coef, m.Coef[:]...)
+	for i := range m.Cov {
+		cov = append(cov, m.Cov[i][:]...)
+	}
+	return coef, cov
+}
@@ -0,0 +1,31 @@
+package admission
+
+import (
+	"testing"
+
+	"unichance-backend-go/internal/scoring"
+)
+
+func TestScannedModelRoundTrip(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	m := scoring.FitAdmissionModel(scoring.PriorAdmissionModel(20), scoring.ProgramContext{AvgGPA: f(3.5)}, []scoring.Outcome{
+		{Features: scoring.ApplicantFeatures{GPA: f(3.9)}, Admitted: true},
+		{Features: scoring.ApplicantFeatures{GPA: f(3.1)}, Admitted: false},
+	})
+
+	coef, cov := flatten(m)
+	s := Scanned{Coef: coef, Cov: cov, Outcomes: &m.Outcomes}
+	got := s.Model()
+	if got == nil || *got != m {
+		t.Fatalf("round trip: got %+v, want %+v", got, m)
+	}
+}
+
+func TestScannedModelMissing(t *testing.T) {
+	if m := (Scanned{}).Model(); m != nil {
+		t.Errorf("no row: got %+v", m)
+	}
+	if m := (Scanned{Coef: []float64{1, 2}, Cov: make([]float64, 16)}).Model(); m != nil {
+		t.Errorf("malformed row: got %+v", m)
+	}
+}
@@ -0,0 +1,23 @@
+package auth
+
+import (
+  "context"
+  "log"
+)
+
+// audit appends to auth_audit_log. Failures are logged, never returned: a
+// broken audit insert must not lock people out.
+func (s Service) audit(ctx context.Context, userID, email, ip, event, detail string) {
+  _, err := s.DB.Exec(ctx, `
+    INSERT INTO auth_audit_log(user_id, email, ip, event, detail)
+    VALUES (NULLIF($1,'')::uuid, NULLIF($2,''), NULLIF($3,''), $4, NULLIF($5,''))
+  `, userID, email, ip, event, detail)
+  if err != nil {
+    log.Printf("auth: audit %s for %s failed: %v", event, email, err)
+  }
+}
+
+// Audit is audit for other packages (account deletion) that log to the same table.
+func (s Service) Audit(ctx context.Context, userID, email, ip, event, detail string) {
+  s.audit(ctx, userID, email, ip, event, detail)
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+  "bufio"
+  "crypto/sha1"
+  "encoding/hex"
+  "errors"
+  "os"
+  "path/filepath"
+  "strings"
+)
+
+// BreachedList answers "has this password leaked?" offline, using SHA-1 hashes
+// split k-anonymity style into a 5-hex-char prefix and the remaining suffix
+// (the Have I Been Pwned range format). Two layouts are supported:
+//
+//   - a directory of range files named by prefix ("5BAA6"), each line
+//     "SUFFIX:COUNT" — read lazily, one small file per lookup;
+//   - a single file of full hashes, one "HASH" or "HASH:COUNT" per line —
+//     loaded into memory, fine for a top-N list.
+type BreachedList struct {
+  dir      string
+  byPrefix map[string]map[string]struct{} // file mode only
+}
+
+// LoadBreachedList opens path (file or directory, see BreachedList).
+func LoadBreachedList(path string) (*BreachedList, error) {
+  st, err := os.Stat(path)
+  if err != nil { return nil, err }
+  if st.IsDir() { return &BreachedList{dir: path}, nil }
+
+  f, err := os.Open(path)
+  if err != nil { return nil, err }
+  defer f.Close()
+
+  b := &BreachedList{byPrefix: map[string]map[string]struct{}{}}
+  sc := bufio.NewScanner(f)
+  for sc.Scan() {
+    hash, _, _ := strings.Cut(strings.TrimSpace(sc.Text()), ":")
+    if len(hash) != 40 { continue }
+    hash = strings.ToUpper(hash)
+    b.add(hash[:5], hash[5:])
+  }
+  return b, sc.Err()
+}
+
+func (b *BreachedList) add(prefix, suffix string) {
+  set := b.byPrefix[prefix]
+  if set == nil {
+    set = map[string]struct{}{}
+    b.byPrefix[prefix] = set
+  }
+  set[suffix] = struct{}{}
+}
+
+// Contains reports whether password's SHA-1 is on the list.
+func (b *BreachedList) Contains(password string) (bool, error) {
+  sum := sha1.Sum([]byte(password))
+  hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+  prefix, suffix := hash[:5], hash[5:]
+
+  if b.dir == "" {
+    _, ok := b.byPrefix[prefix][suffix]
+    return ok, nil
+  }
+
+  set, err := b.rangeFile(prefix)
+  if err != nil { return false, err }
+  _, ok := set[suffix]
+  return ok, nil
+}
+
+// rangeFile reads one prefix file in directory mode. Not cached: it is only
+// hit on register/reset and a range file is a few KB.
+func (b *BreachedList) rangeFile(prefix string) (map[string]struct{}, error) {
+  set := map[string]struct{}{}
+  f, err := os.Open(filepath.Join(b.dir, prefix))
+  if errors.Is(err, os.ErrNotExist) { return set, nil } // no known breached hash with this prefix
+  if err != nil { return nil, err }
+  defer f.Close()
+
+  sc := bufio.NewScanner(f)
+  for sc.Scan() {
+    suffix, _, _ := strings.Cut(strings.TrimSpace(sc.Text()), ":")
+    if len(suffix) == 35 { set[strings.ToUpper(suffix)] = struct{}{} }
+  }
+  return set, sc.Err()
+}
@@ -1,9 +1,16 @@
 package auth
 
 import (
+  "crypto/subtle"
+  "errors"
+  "math"
   "net/http"
+  "strconv"
 
   "github.com/labstack/echo/v4"
+
+  "unichance-backend-go/internal/middleware"
+  "unichance-backend-go/internal/validation"
 )
 
 type Handler struct { Svc Service }
@@ -13,23 +20,231 @@ type authReq struct {
   Password string `json:"password"`
 }
 
+type refreshReq struct {
+  RefreshToken string `json:"refresh_token"`
+}
+
+// internalError logs err and answers 500 without it: database and driver
+// errors describe our setup, not the request.
+func internalError(c echo.Context, op string, err error) error {
+  c.Logger().Errorf("%s: %v", op, err)
+  return c.JSON(http.StatusInternalServerError, map[string]string{"error": "internal error"})
+}
+
+func clientInfo(c echo.Context) ClientInfo {
+  return ClientInfo{IP: c.RealIP(), UserAgent: c.Request().UserAgent()}
+}
+
+func tokenResponse(t Tokens, user User) map[string]any {
+  return map[string]any{
+    "token": t.AccessToken,
+    "refresh_token": t.RefreshToken,
+    "expires_in": t.ExpiresIn,
+    "user": user,
+  }
+}
+
 func (h Handler) Register(c echo.Context) error {
   var req authReq
   if err := c.Bind(&req); err != nil { return c.JSON(http.StatusBadRequest, map[string]string{"error":"bad body"}) }
-  token, user, err := h.Svc.Register(c.Request().Context(), req.Email, req.Password)
-  if err != nil { return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()}) }
-  return c.JSON(http.StatusCreated, map[string]any{"token": token, "user": user})
+  tokens, user, err := h.Svc.Register(c.Request().Context(), req.Email, req.Password, clientInfo(c))
+  if verrs, ok := validation.As(err); ok { return c.JSON(http.StatusUnprocessableEntity, verrs.Body()) }
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": "registration failed"}) }
+  return c.JSON(http.StatusCreated, tokenResponse(tokens, user))
 }
 
 func (h Handler) Login(c echo.Context) error {
   var req authReq
   if err := c.Bind(&req); err != nil { return c.JSON(http.StatusBadRequest, map[string]string{"error":"bad body"}) }
-  token, user, err := h.Svc.Login(c.Request().Context(), req.Email, req.Password)
-  if err != nil { return c.JSON(http.StatusUnauthorized, map[string]string{"error":"invalid credentials"}) }
-  return c.JSON(http.StatusOK, map[string]any{"token": token, "user": user})
+  tokens, user, err := h.Svc.Login(c.Request().Context(), req.Email, req.Password, clientInfo(c))
+  var throttled *ThrottledError
+  if errors.As(err, &throttled) {
+    c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+    return c.JSON(http.StatusTooManyRequests, map[string]string{"error": throttled.Error()})
+  }
+  if err == ErrInvalidCredentials { return c.JSON(http.StatusUnauthorized, map[string]string{"error":"invalid credentials"}) }
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": "login failed"}) }
+  return c.JSON(http.StatusOK, tokenResponse(tokens, user))
+}
+
+func (h Handler) Refresh(c echo.Context) error {
+  var req refreshReq
+  if err := c.Bind(&req); err != nil { return c.JSON(http.StatusBadRequest, map[string]string{"error":"bad body"}) }
+  tokens, user, err := h.Svc.Refresh(c.Request().Context(), req.RefreshToken, clientInfo(c))
+  if err == ErrInvalidRefreshToken { return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()}) }
+  if err != nil { return internalError(c, "refresh", err) }
+  return c.JSON(http.StatusOK, tokenResponse(tokens, user))
+}
+
+// Logout revokes the current access token and its session.
+func (h Handler) Logout(c echo.Context) error {
+  u := c.Get("user").(middleware.CtxUser)
+  var req refreshReq
+  _ = c.Bind(&req)
+  if err := h.Svc.Logout(c.Request().Context(), u.ID, u.SessionID, u.JTI, u.ExpiresAt, req.RefreshToken); err != nil {
+    return internalError(c, "logout", err)
+  }
+  return c.NoContent(http.StatusNoContent)
+}
+
+type forgotReq struct {
+  Email string `json:"email"`
+}
+
+type resetReq struct {
+  Token string `json:"token"`
+  Password string `json:"password"`
+}
+
+// ForgotPassword always answers 202 so callers can't tell whether the email exists.
+func (h Handler) ForgotPassword(c echo.Context) error {
+  var req forgotReq
+  if err := c.Bind(&req); err != nil || req.Email == "" { return c.JSON(http.StatusBadRequest, map[string]string{"error":"email required"}) }
+  if err := h.Svc.ForgotPassword(c.Request().Context(), req.Email); err != nil {
+    c.Logger().Errorf("forgot password: %v", err)
+  }
+  return c.NoContent(http.StatusAccepted)
+}
+
+func (h Handler) ResetPassword(c echo.Context) error {
+  var req resetReq
+  if err := c.Bind(&req); err != nil { return c.JSON(http.StatusBadRequest, map[string]string{"error":"bad body"}) }
+  err := h.Svc.ResetPassword(c.Request().Context(), req.Token, req.Password)
+  if err == ErrInvalidResetToken { return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()}) }
+  if verrs, ok := validation.As(err); ok { return c.JSON(http.StatusUnprocessableEntity, verrs.Body()) }
+  if err != nil { return internalError(c, "reset password", err) }
+  return c.NoContent(http.StatusNoContent)
+}
+
+type verifyReq struct {
+  Token string `json:"token"`
+}
+
+func (h Handler) VerifyEmail(c echo.Context) error {
+  var req verifyReq
+  if err := c.Bind(&req); err != nil || req.Token == "" { return c.JSON(http.StatusBadRequest, map[string]string{"error":"token required"}) }
+  err := h.Svc.VerifyEmail(c.Request().Context(), req.Token)
+  if err == ErrInvalidVerifyToken { return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()}) }
+  if err != nil { return internalError(c, "verify email", err) }
+  return c.JSON(http.StatusOK, map[string]any{"email_verified": true})
+}
+
+func (h Handler) ResendVerification(c echo.Context) error {
+  u := c.Get("user").(middleware.CtxUser)
+  err := h.Svc.ResendVerification(c.Request().Context(), u.ID)
+  if err == ErrAlreadyVerified { return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()}) }
+  if err != nil { return internalError(c, "resend verification", err) }
+  return c.NoContent(http.StatusAccepted)
+}
+
+type roleReq struct {
+  Role string `json:"role"`
+}
+
+// SetRole is admin-only (see router): PUT /admin/users/:id/role
+func (h Handler) SetRole(c echo.Context) error {
+  var req roleReq
+  if err := c.Bind(&req); err != nil { return c.JSON(http.StatusBadRequest, map[string]string{"error":"bad body"}) }
+  err := h.Svc.SetRole(c.Request().Context(), c.Param("id"), req.Role)
+  if err == ErrInvalidRole { return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()}) }
+  if err == ErrUserNotFound { return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()}) }
+  if err != nil { return internalError(c, "set role", err) }
+  return c.JSON(http.StatusOK, map[string]any{"id": c.Param("id"), "role": req.Role})
+}
+
+// oidcStateCookie binds a login to the browser that started it: the callback
+// only accepts the state this browser got, so an attacker can't log a victim
+// into the attacker's account with a code/state pair of their own.
+const oidcStateCookie = "oidc_state"
+
+// OIDCStart returns the IdP authorization URL; ?redirect=1 answers with a 302 instead.
+func (h Handler) OIDCStart(c echo.Context) error {
+  authURL, state, err := h.Svc.StartOIDC(c.Request().Context(), c.Param("provider"))
+  if err == ErrUnknownProvider { return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()}) }
+  if err != nil {
+    c.Logger().Errorf("oidc start %s: %v", c.Param("provider"), err)
+    return c.JSON(http.StatusBadGateway, map[string]string{"error": "identity provider unavailable"})
+  }
+  c.SetCookie(&http.Cookie{
+    Name:     oidcStateCookie,
+    Value:    state,
+    Path:     "/auth/oidc",
+    MaxAge:   int(oidcStateTTL.Seconds()),
+    HttpOnly: true,
+    Secure:   c.Scheme() == "https",
+    SameSite: http.SameSiteLaxMode,
+  })
+  if c.QueryParam("redirect") == "1" { return c.Redirect(http.StatusFound, authURL) }
+  return c.JSON(http.StatusOK, map[string]string{"authorization_url": authURL})
+}
+
+type oidcCallbackReq struct {
+  Code  string `json:"code"`
+  State string `json:"state"`
+}
+
+// OIDCCallback is called by the frontend with the code/state it received on
+// its redirect URL; the request has to carry the state cookie set by OIDCStart.
+func (h Handler) OIDCCallback(c echo.Context) error {
+  var req oidcCallbackReq
+  if err := c.Bind(&req); err != nil || req.Code == "" || req.State == "" {
+    return c.JSON(http.StatusBadRequest, map[string]string{"error":"code and state required"})
+  }
+  cookie, err := c.Cookie(oidcStateCookie)
+  if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(req.State)) != 1 {
+    return c.JSON(http.StatusBadRequest, map[string]string{"error": ErrInvalidOIDCState.Error()})
+  }
+  c.SetCookie(&http.Cookie{Name: oidcStateCookie, Path: "/auth/oidc", MaxAge: -1, HttpOnly: true})
+
+  tokens, user, err := h.Svc.FinishOIDC(c.Request().Context(), c.Param("provider"), req.State, req.Code, clientInfo(c))
+  switch err {
+  case nil:
+    return c.JSON(http.StatusOK, tokenResponse(tokens, user))
+  case ErrUnknownProvider:
+    return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+  case ErrInvalidOIDCState, ErrOIDCEmailNotVerified:
+    return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+  }
+  // IdP and token errors stay in the log, they describe our setup
+  c.Logger().Errorf("oidc callback %s: %v", c.Param("provider"), err)
+  return c.JSON(http.StatusUnauthorized, map[string]string{"error": "login with identity provider failed"})
+}
+
+func (h Handler) Sessions(c echo.Context) error {
+  u := c.Get("user").(middleware.CtxUser)
+  sessions, err := h.Svc.Sessions(c.Request().Context(), u.ID, u.SessionID)
+  if err != nil { return internalError(c, "sessions", err) }
+  return c.JSON(http.StatusOK, map[string]any{"sessions": sessions})
+}
+
+// RevokeSession logs out one device: DELETE /auth/sessions/:id
+func (h Handler) RevokeSession(c echo.Context) error {
+  u := c.Get("user").(middleware.CtxUser)
+  err := h.Svc.RevokeSession(c.Request().Context(), u.ID, c.Param("id"))
+  if err == ErrSessionNotFound { return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()}) }
+  if err != nil { return internalError(c, "revoke session", err) }
+  return c.NoContent(http.StatusNoContent)
+}
+
+// RevokeAllSessions is "log out everywhere", including the calling device.
+func (h Handler) RevokeAllSessions(c echo.Context) error {
+  u := c.Get("user").(middleware.CtxUser)
+  ctx := c.Request().Context()
+  if err := h.Svc.RevokeAllForUser(ctx, u.ID); err != nil {
+    return internalError(c, "revoke all sessions", err)
+  }
+  h.Svc.audit(ctx, u.ID, u.Email, c.RealIP(), "sessions_revoked_all", "")
+  return c.NoContent(http.StatusNoContent)
 }
 
 func (h Handler) Me(c echo.Context) error {
   u := c.Get("user")
   return c.JSON(http.StatusOK, map[string]any{"user": u})
 }
+
+// JWKS publishes the public signing keys so other services (llm_service,
+// partners) can verify access tokens without the shared secret.
+func (h Handler) JWKS(c echo.Context) error {
+  c.Response().Header().Set("Cache-Control", "public, max-age=300")
+  return c.JSON(http.StatusOK, h.Svc.Keys.JWKS())
+}
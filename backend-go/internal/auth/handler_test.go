@@ -0,0 +1,24 @@
+package auth
+
+import (
+  "errors"
+  "net/http"
+  "net/http/httptest"
+  "strings"
+  "testing"
+
+  "github.com/labstack/echo/v4"
+)
+
+func TestInternalErrorHidesCause(t *testing.T) {
+  e := echo.New()
+  rec := httptest.NewRecorder()
+  c := e.NewContext(httptest.NewRequest(http.MethodPost, "/auth/refresh", nil), rec)
+
+  if err := internalError(c, "refresh", errors.New(`relation "refresh_tokens" does not exist`)); err != nil {
+    t.Fatal(err)
+  }
+  if rec.Code != http.StatusInternalServerError || strings.TrimSpace(rec.Body.String()) != `{"error":"internal error"}` {
+    t.Errorf("got %d %s", rec.Code, rec.Body.String())
+  }
+}
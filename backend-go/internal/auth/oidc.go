@@ -0,0 +1,137 @@
+package auth
+
+import (
+  "context"
+  "errors"
+  "time"
+
+  "github.com/google/uuid"
+  "github.com/jackc/pgx/v5"
+
+  "unichance-backend-go/internal/auth/oidc"
+)
+
+var (
+  ErrUnknownProvider      = errors.New("unknown identity provider")
+  ErrInvalidOIDCState     = errors.New("invalid or expired login state")
+  ErrOIDCEmailNotVerified = errors.New("identity provider did not verify the email")
+)
+
+const oidcStateTTL = 10*time.Minute
+
+// StartOIDC creates state, nonce and PKCE verifier for provider and returns the
+// URL the browser has to be sent to, with the state to bind to that browser.
+func (s Service) StartOIDC(ctx context.Context, provider string) (authURL, state string, err error) {
+  p, ok := s.OIDC[provider]
+  if !ok { return "", "", ErrUnknownProvider }
+
+  state, err = oidc.RandomString(32)
+  if err != nil { return "", "", err }
+  nonce, err := oidc.RandomString(32)
+  if err != nil { return "", "", err }
+  verifier, err := oidc.RandomString(32)
+  if err != nil { return "", "", err }
+
+  authURL, err = p.AuthCodeURL(ctx, state, nonce, verifier)
+  if err != nil { return "", "", err }
+
+  _, _ = s.DB.Exec(ctx, `DELETE FROM oidc_login_states WHERE expires_at < now()`)
+  _, err = s.DB.Exec(ctx, `
+    INSERT INTO oidc_login_states(state, provider, nonce, code_verifier, expires_at)
+    VALUES ($1,$2,$3,$4,$5)
+  `, state, provider, nonce, verifier, time.Now().Add(oidcStateTTL))
+  if err != nil { return "", "", err }
+
+  return authURL, state, nil
+}
+
+// FinishOIDC consumes the login state, exchanges the code, verifies the ID
+// token and issues the usual UniChance tokens. Identities are linked to an
+// existing user only through an email the IdP marked as verified. The handler
+// checks that state came back to the browser that started the login.
+func (s Service) FinishOIDC(ctx context.Context, provider, state, code string, client ClientInfo) (Tokens, User, error) {
+  p, ok := s.OIDC[provider]
+  if !ok { return Tokens{}, User{}, ErrUnknownProvider }
+
+  // single use: the row is deleted whether or not the rest succeeds
+  var nonce, verifier string
+  var expiresAt time.Time
+  err := s.DB.QueryRow(ctx, `
+    DELETE FROM oidc_login_states WHERE state=$1 AND provider=$2
+    RETURNING nonce, code_verifier, expires_at
+  `, state, provider).Scan(&nonce, &verifier, &expiresAt)
+  if err == pgx.ErrNoRows { return Tokens{}, User{}, ErrInvalidOIDCState }
+  if err != nil { return Tokens{}, User{}, err }
+  if time.Now().After(expiresAt) { return Tokens{}, User{}, ErrInvalidOIDCState }
+
+  rawID, err := p.Exchange(ctx, code, verifier)
+  if err != nil { return Tokens{}, User{}, err }
+  claims, err := p.VerifyIDToken(ctx, rawID, nonce)
+  if err != nil { return Tokens{}, User{}, err }
+
+  user, err := s.linkIdentity(ctx, provider, claims)
+  if err != nil { return Tokens{}, User{}, err }
+
+  s.audit(ctx, user.ID, user.Email, client.IP, "login_oidc", provider)
+  if err := s.restoreIfDeleted(ctx, user.ID, user.Email, client.IP); err != nil { return Tokens{}, User{}, err }
+  tokens, err := s.issueTokens(ctx, s.DB, user, uuid.NewString(), client)
+  if err != nil { return Tokens{}, User{}, err }
+  return tokens, user, nil
+}
+
+// linkIdentity finds the user for (provider, sub); on first login it attaches
+// the identity to the user with the same email, or creates one. An existing
+// account whose email was never verified may have been registered by someone
+// else to squat on the address: the IdP proved who owns it, so that account
+// loses its password and every session before the identity is linked.
+func (s Service) linkIdentity(ctx context.Context, provider string, c oidc.Claims) (User, error) {
+  var u User
+  err := s.DB.QueryRow(ctx, `
+    UPDATE external_identities ei SET last_login_at=now()
+    FROM users u
+    WHERE u.id = ei.user_id AND ei.provider=$1 AND ei.subject=$2
+    RETURNING u.id, u.email, u.email_verified_at IS NOT NULL, u.role
+  `, provider, c.Subject).Scan(&u.ID, &u.Email, &u.EmailVerified, &u.Role)
+  if err == nil { return u, nil }
+  if err != pgx.ErrNoRows { return User{}, err }
+
+  if c.Email == "" || !c.EmailVerified { return User{}, ErrOIDCEmailNotVerified }
+
+  tx, err := s.DB.Begin(ctx)
+  if err != nil { return User{}, err }
+  defer tx.Rollback(ctx)
+
+  // a new account without a password, unless one with this email exists
+  _, err = tx.Exec(ctx, `
+    INSERT INTO users(email, password_hash, email_verified_at) VALUES ($1, '', now())
+    ON CONFLICT (email) DO NOTHING
+  `, c.Email)
+  if err != nil { return User{}, err }
+
+  var verified bool
+  err = tx.QueryRow(ctx, `
+    SELECT id, email, role, email_verified_at IS NOT NULL FROM users WHERE email=$1 FOR UPDATE
+  `, c.Email).Scan(&u.ID, &u.Email, &u.Role, &verified)
+  if err != nil { return User{}, err }
+  if !verified {
+    _, err = tx.Exec(ctx,
+      `UPDATE users SET password_hash='', email_verified_at=now() WHERE id=$1`,
+      u.ID,
+    )
+    if err != nil { return User{}, err }
+  }
+  u.EmailVerified = true
+
+  _, err = tx.Exec(ctx, `
+    INSERT INTO external_identities(user_id, provider, subject, email, last_login_at)
+    VALUES ($1,$2,$3,$4,now())
+  `, u.ID, provider, c.Subject, c.Email)
+  if err != nil { return User{}, err }
+  if err := tx.Commit(ctx); err != nil { return User{}, err }
+
+  if !verified {
+    s.audit(ctx, u.ID, u.Email, "", "oidc_link_unverified", provider)
+    if err := s.RevokeAllForUser(ctx, u.ID); err != nil { return User{}, err }
+  }
+  return u, nil
+}
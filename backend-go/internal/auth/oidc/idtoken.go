@@ -0,0 +1,67 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims are the identity claims we use from a verified ID token.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified any    `json:"email_verified"` // bool, but some IdPs send "true"
+	Name          string `json:"name"`
+}
+
+// VerifyIDToken checks signature (issuer JWKS), iss, aud, exp/iat and nonce.
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (Claims, error) {
+	m, err := p.Metadata(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	var c idTokenClaims
+	_, err = jwt.ParseWithClaims(raw, &c,
+		func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			return p.keys.get(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384"}),
+		jwt.WithIssuer(m.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return Claims{}, fmt.Errorf("oidc id token: %w", err)
+	}
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(c.Nonce), []byte(nonce)) != 1 {
+		return Claims{}, errors.New("oidc id token: nonce mismatch")
+	}
+	if c.Subject == "" {
+		return Claims{}, errors.New("oidc id token: missing sub")
+	}
+
+	verified := false
+	switch v := c.EmailVerified.(type) {
+	case bool:
+		verified = v
+	case string:
+		verified = v == "true"
+	}
+	return Claims{Subject: c.Subject, Email: c.Email, EmailVerified: verified, Name: c.Name}, nil
+}
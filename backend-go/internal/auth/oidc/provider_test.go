@@ -0,0 +1,157 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIdP is a minimal OIDC issuer: discovery, JWKS and a token endpoint that
+// enforces PKCE and returns an ID token carrying the nonce it was given.
+type mockIdP struct {
+	srv       *httptest.Server
+	key       *rsa.PrivateKey
+	clientID  string
+	challenge string // code_challenge seen on /authorize
+	nonce     string
+	aud       string // override audience to test rejection
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIdP{key: key, clientID: "unichance-test"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.srv.URL,
+			"authorization_endpoint": m.srv.URL + "/authorize",
+			"token_endpoint":         m.srv.URL + "/token",
+			"jwks_uri":               m.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kid": "k1", "kty": "RSA", "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("code") != "good-code" || CodeChallenge(r.Form.Get("code_verifier")) != m.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		aud := m.clientID
+		if m.aud != "" {
+			aud = m.aud
+		}
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            m.srv.URL,
+			"sub":            "google-123",
+			"aud":            aud,
+			"exp":            time.Now().Add(time.Hour).Unix(),
+			"iat":            time.Now().Unix(),
+			"nonce":          m.nonce,
+			"email":          "student@example.com",
+			"email_verified": true,
+		})
+		tok.Header["kid"] = "k1"
+		signed, _ := tok.SignedString(key)
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "access_token": "x"})
+	})
+	m.srv = httptest.NewServer(mux)
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+func (m *mockIdP) authorize(t *testing.T, authURL string) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != m.clientID {
+		t.Fatalf("bad authorization request: %s", authURL)
+	}
+	m.challenge = q.Get("code_challenge")
+	m.nonce = q.Get("nonce")
+}
+
+func TestCodeFlowWithMockIdP(t *testing.T) {
+	idp := newMockIdP(t)
+	p := NewProvider(Config{
+		Name: "mock", Issuer: idp.srv.URL, ClientID: idp.clientID,
+		RedirectURL: "http://localhost:5173/oidc/callback",
+	}, idp.srv.Client())
+	ctx := context.Background()
+
+	verifier, _ := RandomString(32)
+	authURL, err := p.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatalf("auth url: %v", err)
+	}
+	idp.authorize(t, authURL)
+
+	if _, err := p.Exchange(ctx, "good-code", "wrong-verifier"); err == nil {
+		t.Fatal("exchange must fail with a wrong PKCE verifier")
+	}
+
+	raw, err := p.Exchange(ctx, "good-code", verifier)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+
+	claims, err := p.VerifyIDToken(ctx, raw, "nonce-1")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if claims.Subject != "google-123" || claims.Email != "student@example.com" || !claims.EmailVerified {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	if _, err := p.VerifyIDToken(ctx, raw, "other-nonce"); err == nil {
+		t.Error("nonce mismatch must be rejected")
+	}
+}
+
+func TestVerifyRejectsForeignAudience(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.aud = "some-other-client"
+	p := NewProvider(Config{Name: "mock", Issuer: idp.srv.URL, ClientID: idp.clientID}, idp.srv.Client())
+	ctx := context.Background()
+
+	verifier, _ := RandomString(32)
+	authURL, _ := p.AuthCodeURL(ctx, "s", "n", verifier)
+	idp.authorize(t, authURL)
+	raw, err := p.Exchange(ctx, "good-code", verifier)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	if _, err := p.VerifyIDToken(ctx, raw, "n"); err == nil {
+		t.Error("token for another client must be rejected")
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	idp := newMockIdP(t)
+	p := NewProvider(Config{Name: "mock", Issuer: idp.srv.URL + "/", ClientID: "x"}, idp.srv.Client())
+	if _, err := p.Metadata(context.Background()); err == nil {
+		t.Error("issuer mismatch must fail discovery")
+	}
+}
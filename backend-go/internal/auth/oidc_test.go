@@ -0,0 +1,71 @@
+package auth
+
+import (
+  "context"
+  "net/http"
+  "net/http/httptest"
+  "strings"
+  "testing"
+
+  "github.com/google/uuid"
+  "github.com/labstack/echo/v4"
+
+  "unichance-backend-go/internal/auth/oidc"
+)
+
+func TestOIDCCallbackRequiresStateCookie(t *testing.T) {
+  e := echo.New()
+  h := Handler{}
+  cases := map[string]*http.Cookie{
+    "no cookie":   nil,
+    "other state":  {Name: oidcStateCookie, Value: "attacker-state"},
+  }
+  for name, cookie := range cases {
+    req := httptest.NewRequest(http.MethodPost, "/auth/oidc/google/callback",
+      strings.NewReader(`{"code":"c","state":"victim-state"}`))
+    req.Header.Set("Content-Type", "application/json")
+    if cookie != nil { req.AddCookie(cookie) }
+    rec := httptest.NewRecorder()
+    c := e.NewContext(req, rec)
+    c.SetParamNames("provider")
+    c.SetParamValues("google")
+    if err := h.OIDCCallback(c); err != nil { t.Fatal(err) }
+    if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), ErrInvalidOIDCState.Error()) {
+      t.Errorf("%s: %d %s", name, rec.Code, rec.Body.String())
+    }
+  }
+}
+
+func TestLinkIdentityTakesOverUnverifiedAccount(t *testing.T) {
+  s := testService(t)
+  ctx := context.Background()
+  tokens, user, password := testUser(t, s) // registered, email never verified
+  before := parseAccess(t, s, tokens.AccessToken)
+
+  linked, err := s.linkIdentity(ctx, "google", oidc.Claims{Subject: uuid.NewString(), Email: user.Email, EmailVerified: true})
+  if err != nil { t.Fatal(err) }
+  if linked.ID != user.ID || !linked.EmailVerified { t.Fatalf("linked %+v", linked) }
+
+  if _, _, err := s.Login(ctx, user.Email, password, ClientInfo{}); err != ErrInvalidCredentials {
+    t.Errorf("squatter's password still works: %v", err)
+  }
+  if revoked, err := s.IsRevoked(ctx, before.jti, before.sid, user.ID, before.iat); err != nil || !revoked {
+    t.Errorf("squatter's session survived: revoked %v, %v", revoked, err)
+  }
+}
+
+func TestLinkIdentityKeepsVerifiedAccount(t *testing.T) {
+  s := testService(t)
+  ctx := context.Background()
+  _, user, password := testUser(t, s)
+  if _, err := s.DB.Exec(ctx, `UPDATE users SET email_verified_at=now() WHERE id=$1`, user.ID); err != nil {
+    t.Fatal(err)
+  }
+
+  if _, err := s.linkIdentity(ctx, "google", oidc.Claims{Subject: uuid.NewString(), Email: user.Email, EmailVerified: true}); err != nil {
+    t.Fatal(err)
+  }
+  if _, _, err := s.Login(ctx, user.Email, password, ClientInfo{}); err != nil {
+    t.Errorf("verified owner lost their password: %v", err)
+  }
+}
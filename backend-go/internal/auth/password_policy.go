@@ -0,0 +1,87 @@
+package auth
+
+import (
+  "strconv"
+  "strings"
+  "unicode"
+  "unicode/utf8"
+
+  "unichance-backend-go/internal/validation"
+)
+
+// PasswordPolicy is checked on register and password reset.
+type PasswordPolicy struct {
+  MinLength int // runes
+  MaxLength int // bytes; bcrypt ignores everything after 72
+
+  RequireUpper  bool
+  RequireLower  bool
+  RequireDigit  bool
+  RequireSymbol bool
+
+  ForbidEmail bool // no email / local part inside the password
+
+  Breached *BreachedList // nil = no breached-password check
+}
+
+func DefaultPasswordPolicy() PasswordPolicy {
+  return PasswordPolicy{
+    MinLength:    8,
+    MaxLength:    72,
+    RequireLower: true,
+    RequireDigit: true,
+    ForbidEmail:  true,
+  }
+}
+
+// Check returns every violation for password (field "password").
+func (p PasswordPolicy) Check(password, email string) validation.Errors {
+  var errs validation.Errors
+  if password == "" {
+    errs.Add("password", "required", "password is required")
+    return errs
+  }
+  if n := utf8.RuneCountInString(password); n < p.MinLength {
+    errs.Add("password", "too_short", "password must be at least "+strconv.Itoa(p.MinLength)+" characters")
+  }
+  if p.MaxLength > 0 && len(password) > p.MaxLength {
+    errs.Add("password", "too_long", "password must be at most "+strconv.Itoa(p.MaxLength)+" bytes")
+  }
+
+  var upper, lower, digit, symbol bool
+  for _, r := range password {
+    switch {
+    case unicode.IsUpper(r): upper = true
+    case unicode.IsLower(r): lower = true
+    case unicode.IsDigit(r): digit = true
+    case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r): symbol = true
+    }
+  }
+  if p.RequireUpper && !upper { errs.Add("password", "missing_upper", "password needs an uppercase letter") }
+  if p.RequireLower && !lower { errs.Add("password", "missing_lower", "password needs a lowercase letter") }
+  if p.RequireDigit && !digit { errs.Add("password", "missing_digit", "password needs a digit") }
+  if p.RequireSymbol && !symbol { errs.Add("password", "missing_symbol", "password needs a symbol") }
+
+  if p.ForbidEmail && containsEmail(password, email) {
+    errs.Add("password", "contains_email", "password must not contain your email")
+  }
+
+  // only worth a lookup when everything else passed
+  if len(errs) == 0 && p.Breached != nil {
+    breached, err := p.Breached.Contains(password)
+    if err == nil && breached {
+      errs.Add("password", "breached", "this password appeared in a data breach, choose another one")
+    }
+  }
+  return errs
+}
+
+func containsEmail(password, email string) bool {
+  email = strings.ToLower(strings.TrimSpace(email))
+  if email == "" { return false }
+  pw := strings.ToLower(password)
+  if strings.Contains(pw, email) { return true }
+  local, _, _ := strings.Cut(email, "@")
+  // very short local parts ("a@x.kz") would reject half the dictionary
+  return len(local) >= 4 && strings.Contains(pw, local)
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+  "crypto/sha1"
+  "encoding/hex"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+func codes(p PasswordPolicy, password, email string) string {
+  var out []string
+  for _, e := range p.Check(password, email) { out = append(out, e.Code) }
+  return strings.Join(out, ",")
+}
+
+func TestPasswordPolicy(t *testing.T) {
+  p := DefaultPasswordPolicy()
+  p.RequireUpper = true
+
+  tests := []struct {
+    password, email, want string
+  }{
+    {"", "a@b.kz", "required"},
+    {"Ab1", "", "too_short"},
+    {"abcdefgh1", "", "missing_upper"},
+    {"ABCDEFGH1", "", "missing_lower"},
+    {"Abcdefghi", "", "missing_digit"},
+    {"Aidana2024x", "aidana@mail.kz", "contains_email"},
+    {"Xa1@mail.kz-ok", "a@mail.kz", ""}, // one-letter local part isn't matched
+    {"Str0ngPassw0rd", "student@mail.kz", ""},
+    {strings.Repeat("Aa1", 30), "", "too_long"},
+  }
+  for _, tt := range tests {
+    if got := codes(p, tt.password, tt.email); got != tt.want {
+      t.Errorf("Check(%q, %q) = %q, want %q", tt.password, tt.email, got, tt.want)
+    }
+  }
+}
+
+func sha1Hex(s string) string {
+  sum := sha1.Sum([]byte(s))
+  return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func TestBreachedList(t *testing.T) {
+  leaked := sha1Hex("Passw0rd123")
+  dir := t.TempDir()
+
+  // single file of full hashes
+  file := filepath.Join(dir, "top.txt")
+  if err := os.WriteFile(file, []byte(strings.ToLower(leaked)+":42\n"), 0o644); err != nil { t.Fatal(err) }
+
+  // range directory: file named by prefix, lines SUFFIX:COUNT
+  rangeDir := filepath.Join(dir, "ranges")
+  if err := os.Mkdir(rangeDir, 0o755); err != nil { t.Fatal(err) }
+  if err := os.WriteFile(filepath.Join(rangeDir, leaked[:5]), []byte(leaked[5:]+":42\r\n"), 0o644); err != nil { t.Fatal(err) }
+
+  for _, path := range []string{file, rangeDir} {
+    b, err := LoadBreachedList(path)
+    if err != nil { t.Fatal(err) }
+    if ok, err := b.Contains("Passw0rd123"); err != nil || !ok {
+      t.Errorf("%s: leaked password not found (err %v)", path, err)
+    }
+    if ok, err := b.Contains("correct horse battery 7"); err != nil || ok {
+      t.Errorf("%s: unrelated password reported as breached (err %v)", path, err)
+    }
+
+    p := DefaultPasswordPolicy()
+    p.Breached = b
+    if got := codes(p, "Passw0rd123", ""); got != "breached" {
+      t.Errorf("%s: policy code = %q, want breached", path, got)
+    }
+  }
+}
@@ -0,0 +1,121 @@
+package auth
+
+import (
+  "context"
+  "errors"
+  "log"
+  "strings"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+  "golang.org/x/crypto/bcrypt"
+
+  "unichance-backend-go/internal/mail"
+)
+
+var (
+  ErrInvalidResetToken = errors.New("invalid or expired reset token")
+)
+
+// ForgotPassword emails a one-time reset link. Unknown emails are silently
+// ignored and the mail goes out asynchronously, so the endpoint can't be used
+// to enumerate accounts.
+func (s Service) ForgotPassword(ctx context.Context, email string) error {
+  var userID string
+  err := s.DB.QueryRow(ctx, `SELECT id FROM users WHERE email=$1`, email).Scan(&userID)
+  if err == pgx.ErrNoRows { return nil }
+  if err != nil { return err }
+
+  raw, hash, err := newOpaqueToken()
+  if err != nil { return err }
+
+  // only the latest link works
+  _, err = s.DB.Exec(ctx,
+    `UPDATE password_reset_tokens SET used_at=now() WHERE user_id=$1 AND used_at IS NULL`,
+    userID,
+  )
+  if err != nil { return err }
+
+  _, err = s.DB.Exec(ctx, `
+    INSERT INTO password_reset_tokens(user_id, token_hash, expires_at)
+    VALUES ($1,$2,$3)
+  `, userID, hash, time.Now().Add(s.resetTTL()))
+  if err != nil { return err }
+
+  if s.Mailer == nil {
+    log.Printf("auth: no mailer configured, password reset for %s not sent", email)
+    return nil
+  }
+  msg := mail.Message{
+    To:      email,
+    Subject: "UniChance: сброс пароля",
+    Body: "Чтобы задать новый пароль, перейдите по ссылке:\n\n" +
+      s.link("/reset-password", raw) + "\n\n" +
+      "Ссылка действует " + s.resetTTL().String() + " и может быть использована один раз.\n" +
+      "Если вы не запрашивали сброс, просто проигнорируйте это письмо.\n",
+  }
+  // sent in the background: waiting for SMTP would make known emails
+  // answer measurably slower than unknown ones
+  go func() {
+    ctx, cancel := context.WithTimeout(context.Background(), resetMailTimeout)
+    defer cancel()
+    if err := s.Mailer.Send(ctx, msg); err != nil {
+      log.Printf("auth: password reset mail for %s: %v", email, err)
+    }
+  }()
+  return nil
+}
+
+const resetMailTimeout = 30 * time.Second
+
+// ResetPassword consumes a reset token, sets the new password and revokes all
+// existing sessions of the user. A password rejected by the policy leaves the
+// token usable.
+func (s Service) ResetPassword(ctx context.Context, rawToken, newPassword string) error {
+  if rawToken == "" { return ErrInvalidResetToken }
+
+  tx, err := s.DB.Begin(ctx)
+  if err != nil { return err }
+  defer tx.Rollback(ctx)
+
+  var id, userID, email string
+  var expiresAt time.Time
+  var usedAt *time.Time
+  err = tx.QueryRow(ctx, `
+    SELECT t.id, t.user_id, u.email, t.expires_at, t.used_at
+    FROM password_reset_tokens t
+    JOIN users u ON u.id = t.user_id
+    WHERE t.token_hash=$1
+    FOR UPDATE OF t
+  `, hashToken(rawToken)).Scan(&id, &userID, &email, &expiresAt, &usedAt)
+  if err == pgx.ErrNoRows { return ErrInvalidResetToken }
+  if err != nil { return err }
+  if usedAt != nil || time.Now().After(expiresAt) { return ErrInvalidResetToken }
+
+  if err := s.passwordPolicy().Check(newPassword, email).Err(); err != nil { return err }
+  hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+  if err != nil { return err }
+
+  if _, err := tx.Exec(ctx, `UPDATE password_reset_tokens SET used_at=now() WHERE id=$1`, id); err != nil {
+    return err
+  }
+  if _, err := tx.Exec(ctx, `UPDATE users SET password_hash=$2 WHERE id=$1`, userID, string(hash)); err != nil {
+    return err
+  }
+  if err := tx.Commit(ctx); err != nil { return err }
+
+  s.audit(ctx, userID, "", "", "password_reset", "")
+  return s.RevokeAllForUser(ctx, userID)
+}
+
+func (s Service) resetTTL() time.Duration {
+  if s.ResetTTL <= 0 { return time.Hour }
+  return s.ResetTTL
+}
+
+// link builds a frontend URL carrying a token, e.g. {AppURL}/reset-password?token=...
+func (s Service) link(path, token string) string {
+  base := strings.TrimRight(s.AppURL, "/")
+  if base == "" { base = "http://localhost:5173" }
+  return base + path + "?token=" + token
+}
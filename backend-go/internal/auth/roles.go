@@ -0,0 +1,26 @@
+package auth
+
+import (
+  "context"
+  "errors"
+
+  "unichance-backend-go/internal/middleware"
+)
+
+var (
+  ErrInvalidRole  = errors.New("invalid role")
+  ErrUserNotFound = errors.New("user not found")
+)
+
+// SetRole changes a user's role. Existing tokens still carry the old role in
+// their claims, so they are revoked and the user has to log in again.
+func (s Service) SetRole(ctx context.Context, userID, role string) error {
+  if !middleware.ValidRole(role) { return ErrInvalidRole }
+
+  tag, err := s.DB.Exec(ctx, `UPDATE users SET role=$2 WHERE id=$1`, userID, role)
+  if err != nil { return err }
+  if tag.RowsAffected() == 0 { return ErrUserNotFound }
+
+  s.audit(ctx, userID, "", "", "role_changed", role)
+  return s.RevokeAllForUser(ctx, userID)
+}
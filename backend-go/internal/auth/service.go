@@ -2,65 +2,183 @@ package auth
 
 import (
   "context"
+  "errors"
+  "log"
+  netmail "net/mail"
+  "strings"
   "time"
 
   "github.com/golang-jwt/jwt/v5"
+  "github.com/google/uuid"
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
   "github.com/jackc/pgx/v5/pgxpool"
   "golang.org/x/crypto/bcrypt"
+
+  "unichance-backend-go/internal/auth/oidc"
+  "unichance-backend-go/internal/jwtkeys"
+  "unichance-backend-go/internal/mail"
+  "unichance-backend-go/internal/validation"
 )
 
 type Service struct {
   DB *pgxpool.Pool
-  JwtSecret string
+  JwtSecret string        // HMAC key for email-verification links
+  Keys      *jwtkeys.Ring // signs access tokens
+
+  AccessTTL  time.Duration // default 15m
+  RefreshTTL time.Duration // default 30d
+
+  Mailer   mail.Mailer
+  AppURL   string        // frontend base URL for links in emails
+  ResetTTL time.Duration // default 1h
+  VerifyTTL time.Duration // default 48h
+
+  Throttle LoginThrottle // nil disables brute-force protection
+
+  OIDC map[string]*oidc.Provider // keyed by provider name ("google", "microsoft")
+
+  Passwords PasswordPolicy // zero value = DefaultPasswordPolicy()
 }
 
 type User struct {
   ID string `json:"id"`
   Email string `json:"email"`
+  EmailVerified bool `json:"email_verified"`
+  Role string `json:"role"`
 }
 
-func (s Service) Register(ctx context.Context, email, password string) (string, User, error) {
+var (
+  ErrInvalidEmail       = errors.New("invalid email")
+  ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+func (s Service) Register(ctx context.Context, email, password string, client ClientInfo) (Tokens, User, error) {
+  email = strings.TrimSpace(email)
+  var errs validation.Errors
+  if email == "" {
+    errs.Add("email", "required", "email is required")
+  } else if addr, err := netmail.ParseAddress(email); err != nil || addr.Address != email {
+    errs.Add("email", "invalid", ErrInvalidEmail.Error())
+  }
+  errs = append(errs, s.passwordPolicy().Check(password, email)...)
+  if err := errs.Err(); err != nil { return Tokens{}, User{}, err }
+
   hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-  if err != nil { return "", User{}, err }
+  if err != nil { return Tokens{}, User{}, err }
 
-  var id string
+  user := User{Email: email}
   err = s.DB.QueryRow(ctx,
-    `INSERT INTO users(email,password_hash) VALUES ($1,$2) RETURNING id`,
+    `INSERT INTO users(email,password_hash) VALUES ($1,$2) RETURNING id, role`,
     email, string(hash),
-  ).Scan(&id)
-  if err != nil { return "", User{}, err }
+  ).Scan(&user.ID, &user.Role)
+  var pgErr *pgconn.PgError
+  if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+    return Tokens{}, User{}, validation.Errors{{Field: "email", Code: "taken", Message: "email is already registered"}}
+  }
+  if err != nil { return Tokens{}, User{}, err }
+
+  tokens, err := s.issueTokens(ctx, s.DB, user, uuid.NewString(), client)
+  if err != nil { return Tokens{}, User{}, err }
 
-  token, err := s.issueToken(id, email)
-  if err != nil { return "", User{}, err }
+  // a mail outage shouldn't fail registration, the user can ask for a resend
+  if err := s.SendVerification(ctx, user.ID, email); err != nil {
+    log.Printf("auth: verification email to %s failed: %v", email, err)
+  }
 
-  return token, User{ID:id, Email:email}, nil
+  return tokens, user, nil
 }
 
-func (s Service) Login(ctx context.Context, email, password string) (string, User, error) {
-  var id, hash string
+// dummyHash is what Login compares against when there is no real hash.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("unichance-no-such-account"), bcrypt.DefaultCost)
+
+func (s Service) Login(ctx context.Context, email, password string, client ClientInfo) (Tokens, User, error) {
+  ip := client.IP
+  keys := loginKeys(email, ip)
+  if s.Throttle != nil {
+    wait, err := s.Throttle.Check(ctx, keys)
+    if err != nil { return Tokens{}, User{}, err }
+    if wait > 0 {
+      // rejected before bcrypt, so a locked key costs no CPU
+      s.audit(ctx, "", email, ip, "login_locked", "")
+      return Tokens{}, User{}, &ThrottledError{RetryAfter: wait}
+    }
+  }
+
+  user := User{Email: email}
+  var hash string
   err := s.DB.QueryRow(ctx,
-    `SELECT id, password_hash FROM users WHERE email=$1`,
+    `SELECT id, password_hash, email_verified_at IS NOT NULL, role FROM users WHERE email=$1`,
     email,
-  ).Scan(&id, &hash)
-  if err != nil { return "", User{}, err }
+  ).Scan(&user.ID, &hash, &user.EmailVerified, &user.Role)
+  if err != nil && err != pgx.ErrNoRows { return Tokens{}, User{}, err }
+  if err == nil && hash != "" {
+    err = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+  } else {
+    // unknown emails and OIDC-only accounts (empty hash) pay for a bcrypt
+    // compare too, so the response time doesn't reveal which emails exist
+    bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+    err = ErrInvalidCredentials
+  }
+  if err != nil {
+    s.audit(ctx, user.ID, email, ip, "login_failed", "")
+    if s.Throttle != nil {
+      if err := s.Throttle.Fail(ctx, keys); err != nil { return Tokens{}, User{}, err }
+    }
+    return Tokens{}, User{}, ErrInvalidCredentials
+  }
 
-  if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
-    return "", User{}, err
+  // only the email key: one good password from a shared IP must not wipe
+  // the failures an attacker racked up there against other accounts
+  if s.Throttle != nil {
+    if err := s.Throttle.Reset(ctx, keys[:1]); err != nil { return Tokens{}, User{}, err }
   }
+  s.audit(ctx, user.ID, email, ip, "login_success", "")
+  if err := s.restoreIfDeleted(ctx, user.ID, email, ip); err != nil { return Tokens{}, User{}, err }
+
+  tokens, err := s.issueTokens(ctx, s.DB, user, uuid.NewString(), client)
+  if err != nil { return Tokens{}, User{}, err }
 
-  token, err := s.issueToken(id, email)
-  if err != nil { return "", User{}, err }
+  return tokens, user, nil
+}
 
-  return token, User{ID:id, Email:email}, nil
+// restoreIfDeleted cancels a pending account deletion (DELETE /me): logging in
+// during the grace period means the user changed their mind.
+func (s Service) restoreIfDeleted(ctx context.Context, userID, email, ip string) error {
+  tag, err := s.DB.Exec(ctx,
+    `UPDATE users SET deleted_at=NULL, purge_after=NULL WHERE id=$1 AND deleted_at IS NOT NULL`,
+    userID,
+  )
+  if err != nil { return err }
+  if tag.RowsAffected() > 0 { s.audit(ctx, userID, email, ip, "account_restored", "") }
+  return nil
 }
 
-func (s Service) issueToken(userID, email string) (string, error) {
+func (s Service) issueToken(u User, sessionID string) (string, error) {
+  now := time.Now()
   claims := jwt.MapClaims{
-    "sub": userID,
-    "email": email,
-    "iat": time.Now().Unix(),
-    "exp": time.Now().Add(7*24*time.Hour).Unix(),
+    "sub": u.ID,
+    "email": u.Email,
+    "role": u.Role,
+    "jti": uuid.NewString(),
+    "sid": sessionID,
+    "iat": float64(now.UnixMicro()) / 1e6, // sub-second, compared with tokens_valid_after
+    "exp": now.Add(s.accessTTL()).Unix(),
   }
-  t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-  return t.SignedString([]byte(s.JwtSecret))
+  return s.Keys.Sign(claims)
+}
+
+func (s Service) passwordPolicy() PasswordPolicy {
+  if s.Passwords.MinLength <= 0 { return DefaultPasswordPolicy() }
+  return s.Passwords
+}
+
+func (s Service) accessTTL() time.Duration {
+  if s.AccessTTL <= 0 { return 15*time.Minute }
+  return s.AccessTTL
+}
+
+func (s Service) refreshTTL() time.Duration {
+  if s.RefreshTTL <= 0 { return 30*24*time.Hour }
+  return s.RefreshTTL
 }
@@ -0,0 +1,112 @@
+package auth
+
+import (
+  "context"
+  "errors"
+  "strings"
+  "time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// ClientInfo describes where a login/refresh came from.
+type ClientInfo struct {
+  IP        string
+  UserAgent string
+}
+
+// Session is one refresh token family, i.e. one logged-in browser/device.
+type Session struct {
+  ID         string    `json:"id"`
+  Device     string    `json:"device"`
+  IP         string    `json:"ip"`
+  UserAgent  string    `json:"user_agent"`
+  CreatedAt  time.Time `json:"created_at"`
+  LastSeenAt time.Time `json:"last_seen_at"`
+  Current    bool      `json:"current"`
+}
+
+// touchSession creates the session row on first issue and refreshes ip/user
+// agent/last-seen on every rotation.
+func (s Service) touchSession(ctx context.Context, db execer, userID, sessionID string, client ClientInfo) error {
+  _, err := db.Exec(ctx, `
+    INSERT INTO user_sessions(id, user_id, device, ip, user_agent, expires_at)
+    VALUES ($1,$2,$3,$4,$5,$6)
+    ON CONFLICT (id) DO UPDATE SET
+      ip=EXCLUDED.ip, user_agent=EXCLUDED.user_agent, device=EXCLUDED.device,
+      last_seen_at=now(), expires_at=EXCLUDED.expires_at
+  `, sessionID, userID, describeDevice(client.UserAgent), client.IP, client.UserAgent,
+    time.Now().Add(s.refreshTTL()))
+  return err
+}
+
+// Sessions lists the user's live sessions, newest activity first. currentID
+// (the caller's sid) is flagged so the UI can label "this device".
+func (s Service) Sessions(ctx context.Context, userID, currentID string) ([]Session, error) {
+  rows, err := s.DB.Query(ctx, `
+    SELECT id::text, device, ip, user_agent, created_at, last_seen_at
+    FROM user_sessions
+    WHERE user_id=$1 AND revoked_at IS NULL AND expires_at > now()
+    ORDER BY last_seen_at DESC
+  `, userID)
+  if err != nil { return nil, err }
+  defer rows.Close()
+
+  out := []Session{}
+  for rows.Next() {
+    var ss Session
+    if err := rows.Scan(&ss.ID, &ss.Device, &ss.IP, &ss.UserAgent, &ss.CreatedAt, &ss.LastSeenAt); err != nil {
+      return nil, err
+    }
+    ss.Current = ss.ID == currentID
+    out = append(out, ss)
+  }
+  return out, rows.Err()
+}
+
+// RevokeSession logs one device out: its refresh tokens stop working and its
+// access tokens are rejected by RequireAuth.
+func (s Service) RevokeSession(ctx context.Context, userID, sessionID string) error {
+  tag, err := s.DB.Exec(ctx,
+    `UPDATE user_sessions SET revoked_at=now() WHERE id::text=$1 AND user_id=$2 AND revoked_at IS NULL`,
+    sessionID, userID,
+  )
+  if err != nil { return err }
+  if tag.RowsAffected() == 0 { return ErrSessionNotFound }
+  s.audit(ctx, userID, "", "", "session_revoked", sessionID)
+  return s.revokeFamily(ctx, sessionID)
+}
+
+// describeDevice turns a user agent into "Chrome on Windows". Good enough for a
+// session list; unknown agents fall back to "Unknown device".
+func describeDevice(ua string) string {
+  if ua == "" { return "Unknown device" }
+  l := strings.ToLower(ua)
+
+  browser := ""
+  switch {
+  case strings.Contains(l, "edg/"): browser = "Edge"
+  case strings.Contains(l, "opr/") || strings.Contains(l, "opera"): browser = "Opera"
+  case strings.Contains(l, "yabrowser"): browser = "Yandex Browser"
+  case strings.Contains(l, "firefox/"): browser = "Firefox"
+  case strings.Contains(l, "chrome/") || strings.Contains(l, "crios/"): browser = "Chrome"
+  case strings.Contains(l, "safari/"): browser = "Safari"
+  }
+
+  os := ""
+  switch {
+  case strings.Contains(l, "android"): os = "Android"
+  case strings.Contains(l, "iphone") || strings.Contains(l, "ipad"): os = "iOS"
+  case strings.Contains(l, "windows"): os = "Windows"
+  case strings.Contains(l, "mac os"): os = "macOS"
+  case strings.Contains(l, "cros"): os = "ChromeOS"
+  case strings.Contains(l, "linux"): os = "Linux"
+  }
+
+  switch {
+  case browser != "" && os != "": return browser + " on " + os
+  case browser != "": return browser
+  case os != "": return os
+  }
+  return "Unknown device"
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+  "context"
+  "fmt"
+  "strings"
+  "sync"
+  "time"
+
+  "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ThrottledError is returned by Login while a key is backing off or locked out.
+type ThrottledError struct {
+  RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+  return fmt.Sprintf("too many login attempts, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// ThrottlePolicy: the first FreeAttempts failures cost nothing, then every
+// failure doubles the wait starting at BaseDelay (capped at MaxDelay); after
+// LockoutAfter failures the key is locked for LockoutFor. Counters reset after
+// Window without failures.
+type ThrottlePolicy struct {
+  FreeAttempts int
+  BaseDelay    time.Duration
+  MaxDelay     time.Duration
+  LockoutAfter int
+  LockoutFor   time.Duration
+  Window       time.Duration
+  // IP keys are shared by whole schools behind one NAT, so they tolerate
+  // IPFactor times more failures than email keys.
+  IPFactor int
+}
+
+func DefaultThrottlePolicy() ThrottlePolicy {
+  return ThrottlePolicy{
+    FreeAttempts: 3,
+    BaseDelay:    time.Second,
+    MaxDelay:     5*time.Minute,
+    LockoutAfter: 10,
+    LockoutFor:   15*time.Minute,
+    Window:       time.Hour,
+    IPFactor:     5,
+  }
+}
+
+// delay is how long key must wait after its n-th consecutive failure.
+func (p ThrottlePolicy) delay(key string, failures int) time.Duration {
+  if strings.HasPrefix(key, "ip:") && p.IPFactor > 1 {
+    failures /= p.IPFactor
+  }
+  if p.LockoutAfter > 0 && failures >= p.LockoutAfter {
+    return p.LockoutFor
+  }
+  over := failures - p.FreeAttempts
+  if over <= 0 { return 0 }
+  d := p.BaseDelay
+  for i := 1; i < over && d < p.MaxDelay; i++ { d *= 2 }
+  if p.MaxDelay > 0 && d > p.MaxDelay { d = p.MaxDelay }
+  return d
+}
+
+// LoginThrottle tracks failed logins per key ("email:...", "ip:...").
+type LoginThrottle interface {
+  // Check returns how long the caller has to wait before trying any of keys.
+  Check(ctx context.Context, keys []string) (time.Duration, error)
+  // Fail records a failure for every key.
+  Fail(ctx context.Context, keys []string) error
+  Reset(ctx context.Context, keys []string) error
+}
+
+func loginKeys(email, ip string) []string {
+  keys := []string{"email:" + strings.ToLower(strings.TrimSpace(email))}
+  if ip != "" { keys = append(keys, "ip:"+ip) }
+  return keys
+}
+
+// ===== in-memory (single instance, dev) =====
+
+type MemoryThrottle struct {
+  Policy ThrottlePolicy
+  Now    func() time.Time // for tests; defaults to time.Now
+
+  mu      sync.Mutex
+  entries map[string]*attempts
+}
+
+type attempts struct {
+  failures     int
+  lastFailure  time.Time
+  blockedUntil time.Time
+}
+
+func NewMemoryThrottle(p ThrottlePolicy) *MemoryThrottle {
+  return &MemoryThrottle{Policy: p, entries: map[string]*attempts{}}
+}
+
+func (m *MemoryThrottle) now() time.Time {
+  if m.Now != nil { return m.Now() }
+  return time.Now()
+}
+
+func (m *MemoryThrottle) Check(ctx context.Context, keys []string) (time.Duration, error) {
+  m.mu.Lock()
+  defer m.mu.Unlock()
+  now := m.now()
+  var wait time.Duration
+  for _, k := range keys {
+    if a, ok := m.entries[k]; ok && a.blockedUntil.After(now) {
+      if d := a.blockedUntil.Sub(now); d > wait { wait = d }
+    }
+  }
+  return wait, nil
+}
+
+func (m *MemoryThrottle) Fail(ctx context.Context, keys []string) error {
+  m.mu.Lock()
+  defer m.mu.Unlock()
+  now := m.now()
+  for _, k := range keys {
+    a, ok := m.entries[k]
+    if !ok || now.Sub(a.lastFailure) > m.Policy.Window {
+      a = &attempts{}
+      m.entries[k] = a
+    }
+    a.failures++
+    a.lastFailure = now
+    a.blockedUntil = now.Add(m.Policy.delay(k, a.failures))
+  }
+  // drop stale keys so the map doesn't grow forever
+  for k, a := range m.entries {
+    if now.Sub(a.lastFailure) > m.Policy.Window && !a.blockedUntil.After(now) { delete(m.entries, k) }
+  }
+  return nil
+}
+
+func (m *MemoryThrottle) Reset(ctx context.Context, keys []string) error {
+  m.mu.Lock()
+  defer m.mu.Unlock()
+  for _, k := range keys { delete(m.entries, k) }
+  return nil
+}
+
+// ===== Postgres (shared between instances) =====
+
+type PgThrottle struct {
+  DB     *pgxpool.Pool
+  Policy ThrottlePolicy
+}
+
+func (p PgThrottle) Check(ctx context.Context, keys []string) (time.Duration, error) {
+  var until *time.Time
+  err := p.DB.QueryRow(ctx,
+    `SELECT max(blocked_until) FROM login_attempts WHERE key = ANY($1)`,
+    keys,
+  ).Scan(&until)
+  if err != nil || until == nil { return 0, err }
+  if d := time.Until(*until); d > 0 { return d, nil }
+  return 0, nil
+}
+
+func (p PgThrottle) Fail(ctx context.Context, keys []string) error {
+  now := time.Now()
+  for _, k := range keys {
+    var failures int
+    err := p.DB.QueryRow(ctx, `
+      INSERT INTO login_attempts(key, failures, last_failure) VALUES ($1, 1, $2)
+      ON CONFLICT (key) DO UPDATE SET
+        failures = CASE WHEN login_attempts.last_failure < $3 THEN 1 ELSE login_attempts.failures + 1 END,
+        last_failure = EXCLUDED.last_failure
+      RETURNING failures
+    `, k, now, now.Add(-p.Policy.Window)).Scan(&failures)
+    if err != nil { return err }
+
+    _, err = p.DB.Exec(ctx,
+      `UPDATE login_attempts SET blocked_until=$2 WHERE key=$1`,
+      k, now.Add(p.Policy.delay(k, failures)),
+    )
+    if err != nil { return err }
+  }
+  return nil
+}
+
+func (p PgThrottle) Reset(ctx context.Context, keys []string) error {
+  _, err := p.DB.Exec(ctx, `DELETE FROM login_attempts WHERE key = ANY($1)`, keys)
+  return err
+}
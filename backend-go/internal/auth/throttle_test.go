@@ -0,0 +1,70 @@
+package auth
+
+import (
+  "context"
+  "testing"
+  "time"
+)
+
+func TestThrottlePolicyDelay(t *testing.T) {
+  p := DefaultThrottlePolicy()
+
+  tests := []struct {
+    key      string
+    failures int
+    want     time.Duration
+  }{
+    {"email:a@b.c", 1, 0},
+    {"email:a@b.c", 3, 0},
+    {"email:a@b.c", 4, time.Second},
+    {"email:a@b.c", 5, 2 * time.Second},
+    {"email:a@b.c", 7, 8 * time.Second},
+    {"email:a@b.c", 10, 15 * time.Minute}, // lockout
+    {"ip:10.0.0.1", 10, 0},                // 10/5 = 2, still free
+    {"ip:10.0.0.1", 50, 15 * time.Minute},
+  }
+
+  for _, tt := range tests {
+    if got := p.delay(tt.key, tt.failures); got != tt.want {
+      t.Errorf("delay(%s, %d) = %s, want %s", tt.key, tt.failures, got, tt.want)
+    }
+  }
+}
+
+func TestMemoryThrottleBackoffAndReset(t *testing.T) {
+  ctx := context.Background()
+  now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+  m := NewMemoryThrottle(DefaultThrottlePolicy())
+  m.Now = func() time.Time { return now }
+
+  keys := loginKeys("Student@Example.com", "10.0.0.1")
+  for i := 0; i < 4; i++ {
+    _ = m.Fail(ctx, keys)
+  }
+
+  wait, _ := m.Check(ctx, keys)
+  if wait != time.Second {
+    t.Fatalf("expected 1s backoff after 4 failures, got %s", wait)
+  }
+
+  now = now.Add(2 * time.Second)
+  if wait, _ := m.Check(ctx, keys); wait != 0 {
+    t.Fatalf("backoff should be over, got %s", wait)
+  }
+
+  _ = m.Reset(ctx, keys)
+  _ = m.Fail(ctx, keys)
+  if wait, _ := m.Check(ctx, keys); wait != 0 {
+    t.Fatalf("counter should restart after reset, got %s", wait)
+  }
+
+  // window expiry restarts counting too
+  for i := 0; i < 5; i++ {
+    _ = m.Fail(ctx, keys)
+  }
+  now = now.Add(2 * time.Hour)
+  _ = m.Fail(ctx, keys)
+  if wait, _ := m.Check(ctx, keys); wait != 0 {
+    t.Fatalf("failures older than the window should be forgotten, got %s", wait)
+  }
+}
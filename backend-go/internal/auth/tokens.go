@@ -0,0 +1,202 @@
+package auth
+
+import (
+  "context"
+  "crypto/rand"
+  "crypto/sha256"
+  "encoding/base64"
+  "encoding/hex"
+  "errors"
+  "time"
+
+  "github.com/jackc/pgx/v5"
+  "github.com/jackc/pgx/v5/pgconn"
+)
+
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// Tokens is what a successful login/register/refresh hands back to the client.
+type Tokens struct {
+  AccessToken  string `json:"token"`
+  RefreshToken string `json:"refresh_token"`
+  ExpiresIn    int    `json:"expires_in"` // access token lifetime, seconds
+}
+
+// execer is satisfied by both *pgxpool.Pool and pgx.Tx.
+type execer interface {
+  Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// issueTokens mints an access token and stores a new refresh token in the
+// session's family (session id == refresh family id).
+func (s Service) issueTokens(ctx context.Context, db execer, u User, sessionID string, client ClientInfo) (Tokens, error) {
+  if err := s.touchSession(ctx, db, u.ID, sessionID, client); err != nil { return Tokens{}, err }
+
+  access, err := s.issueToken(u, sessionID)
+  if err != nil { return Tokens{}, err }
+
+  raw, hash, err := newOpaqueToken()
+  if err != nil { return Tokens{}, err }
+
+  _, err = db.Exec(ctx, `
+    INSERT INTO refresh_tokens(user_id, family_id, token_hash, expires_at)
+    VALUES ($1,$2,$3,$4)
+  `, u.ID, sessionID, hash, time.Now().Add(s.refreshTTL()))
+  if err != nil { return Tokens{}, err }
+
+  return Tokens{
+    AccessToken:  access,
+    RefreshToken: raw,
+    ExpiresIn:    int(s.accessTTL().Seconds()),
+  }, nil
+}
+
+// Refresh rotates a refresh token: the presented one is marked used and a new
+// pair is issued in the same family. Presenting an already used token means it
+// leaked, so the whole family is revoked.
+func (s Service) Refresh(ctx context.Context, rawToken string, client ClientInfo) (Tokens, User, error) {
+  if rawToken == "" { return Tokens{}, User{}, ErrInvalidRefreshToken }
+
+  tx, err := s.DB.Begin(ctx)
+  if err != nil { return Tokens{}, User{}, err }
+  defer tx.Rollback(ctx)
+
+  var id, familyID string
+  var user User
+  var expiresAt time.Time
+  var usedAt, revokedAt *time.Time
+  err = tx.QueryRow(ctx, `
+    SELECT rt.id, rt.family_id, rt.expires_at, rt.used_at, rt.revoked_at,
+      u.id, u.email, u.email_verified_at IS NOT NULL, u.role
+    FROM refresh_tokens rt
+    JOIN users u ON u.id = rt.user_id
+    WHERE rt.token_hash = $1
+    FOR UPDATE OF rt
+  `, hashToken(rawToken)).Scan(
+    &id, &familyID, &expiresAt, &usedAt, &revokedAt,
+    &user.ID, &user.Email, &user.EmailVerified, &user.Role,
+  )
+  if err == pgx.ErrNoRows { return Tokens{}, User{}, ErrInvalidRefreshToken }
+  if err != nil { return Tokens{}, User{}, err }
+
+  if revokedAt != nil || time.Now().After(expiresAt) {
+    return Tokens{}, User{}, ErrInvalidRefreshToken
+  }
+  if usedAt != nil {
+    // reuse detected — kill the family outside the rolled back tx
+    _ = tx.Rollback(ctx)
+    if err := s.revokeFamily(ctx, familyID); err != nil { return Tokens{}, User{}, err }
+    return Tokens{}, User{}, ErrInvalidRefreshToken
+  }
+
+  if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET used_at=now() WHERE id=$1`, id); err != nil {
+    return Tokens{}, User{}, err
+  }
+  tokens, err := s.issueTokens(ctx, tx, user, familyID, client)
+  if err != nil { return Tokens{}, User{}, err }
+  if err := tx.Commit(ctx); err != nil { return Tokens{}, User{}, err }
+
+  return tokens, user, nil
+}
+
+// Logout denylists the current access token and ends its session. The refresh
+// token (optional) covers access tokens issued before sessions existed.
+func (s Service) Logout(ctx context.Context, userID, sessionID, jti string, accessExp time.Time, rawRefresh string) error {
+  if sessionID != "" {
+    if err := s.revokeFamily(ctx, sessionID); err != nil { return err }
+  }
+  if rawRefresh != "" {
+    var familyID string
+    err := s.DB.QueryRow(ctx,
+      `SELECT family_id FROM refresh_tokens WHERE token_hash=$1 AND user_id=$2`,
+      hashToken(rawRefresh), userID,
+    ).Scan(&familyID)
+    if err != nil && err != pgx.ErrNoRows { return err }
+    if err == nil {
+      if err := s.revokeFamily(ctx, familyID); err != nil { return err }
+    }
+  }
+  return s.RevokeAccessToken(ctx, userID, jti, accessExp)
+}
+
+// RevokeAccessToken puts a single jti on the denylist until it would expire anyway.
+func (s Service) RevokeAccessToken(ctx context.Context, userID, jti string, exp time.Time) error {
+  if jti == "" { return nil }
+  _, err := s.DB.Exec(ctx, `
+    INSERT INTO revoked_tokens(jti, user_id, expires_at) VALUES ($1,$2,$3)
+    ON CONFLICT (jti) DO NOTHING
+  `, jti, userID, exp)
+  return err
+}
+
+// RevokeAllForUser invalidates every session, refresh token and access token
+// issued so far for the user (password change / reset, "log out everywhere").
+func (s Service) RevokeAllForUser(ctx context.Context, userID string) error {
+  _, err := s.DB.Exec(ctx,
+    `UPDATE refresh_tokens SET revoked_at=now() WHERE user_id=$1 AND revoked_at IS NULL`,
+    userID,
+  )
+  if err != nil { return err }
+  _, err = s.DB.Exec(ctx,
+    `UPDATE user_sessions SET revoked_at=now() WHERE user_id=$1 AND revoked_at IS NULL`,
+    userID,
+  )
+  if err != nil { return err }
+  // app clock, the one access token iat comes from
+  _, err = s.DB.Exec(ctx, `UPDATE users SET tokens_valid_after=$2 WHERE id=$1`, userID, time.Now())
+  return err
+}
+
+// revokeFamily kills a refresh token family and the session it backs.
+func (s Service) revokeFamily(ctx context.Context, familyID string) error {
+  _, err := s.DB.Exec(ctx,
+    `UPDATE refresh_tokens SET revoked_at=now() WHERE family_id=$1 AND revoked_at IS NULL`,
+    familyID,
+  )
+  if err != nil { return err }
+  _, err = s.DB.Exec(ctx,
+    `UPDATE user_sessions SET revoked_at=now() WHERE id=$1 AND revoked_at IS NULL`,
+    familyID,
+  )
+  return err
+}
+
+// IsRevoked implements middleware.RevocationChecker. A live session also gets
+// its last_seen_at bumped (at most once a minute). issuedAt has microsecond
+// precision, so a token minted in the same second as a "revoke all" is only
+// valid when it was minted after it.
+func (s Service) IsRevoked(ctx context.Context, jti, sessionID, userID string, issuedAt time.Time) (bool, error) {
+  var revoked bool
+  err := s.DB.QueryRow(ctx, `
+    SELECT
+      EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)
+      OR EXISTS (
+        SELECT 1 FROM users
+        WHERE id = $2 AND tokens_valid_after IS NOT NULL
+          AND tokens_valid_after >= $3
+      )
+      OR EXISTS (
+        SELECT 1 FROM user_sessions
+        WHERE id = NULLIF($4, '')::uuid AND (revoked_at IS NOT NULL OR expires_at <= now())
+      )
+  `, jti, userID, issuedAt, sessionID).Scan(&revoked)
+  if err != nil || revoked || sessionID == "" { return revoked, err }
+
+  _, err = s.DB.Exec(ctx, `
+    UPDATE user_sessions SET last_seen_at=now()
+    WHERE id=$1 AND last_seen_at < now() - interval '1 minute'
+  `, sessionID)
+  return false, err
+}
+
+func newOpaqueToken() (raw, hash string, err error) {
+  b := make([]byte, 32)
+  if _, err := rand.Read(b); err != nil { return "", "", err }
+  raw = base64.RawURLEncoding.EncodeToString(b)
+  return raw, hashToken(raw), nil
+}
+
+func hashToken(raw string) string {
+  sum := sha256.Sum256([]byte(raw))
+  return hex.EncodeToString(sum[:])
+}
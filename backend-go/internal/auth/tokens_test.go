@@ -0,0 +1,145 @@
+package auth
+
+import (
+  "context"
+  "math"
+  "os"
+  "testing"
+  "time"
+
+  "github.com/golang-jwt/jwt/v5"
+  "github.com/google/uuid"
+  "github.com/jackc/pgx/v5/pgxpool"
+
+  "unichance-backend-go/internal/jwtkeys"
+)
+
+// testService needs a migrated database in DATABASE_URL.
+func testService(t *testing.T) Service {
+  t.Helper()
+  url := os.Getenv("DATABASE_URL")
+  if url == "" { t.Skip("DATABASE_URL not set, skipping database tests") }
+  ctx := context.Background()
+  pool, err := pgxpool.New(ctx, url)
+  if err != nil { t.Fatal(err) }
+  t.Cleanup(pool.Close)
+  keys, err := jwtkeys.New(ctx, jwtkeys.Options{LegacySecret: "test-secret"})
+  if err != nil { t.Fatal(err) }
+  return Service{DB: pool, Keys: keys}
+}
+
+func testUser(t *testing.T, s Service) (Tokens, User, string) {
+  t.Helper()
+  email := "tokens-" + uuid.NewString()[:8] + "@example.com"
+  password := "correct horse 42"
+  tokens, user, err := s.Register(context.Background(), email, password, ClientInfo{IP: "127.0.0.1"})
+  if err != nil { t.Fatal(err) }
+  t.Cleanup(func() { s.DB.Exec(context.Background(), `DELETE FROM users WHERE id=$1`, user.ID) })
+  return tokens, user, password
+}
+
+type accessClaims struct {
+  jti, sid string
+  iat, exp time.Time
+}
+
+func parseAccess(t *testing.T, s Service, token string) accessClaims {
+  t.Helper()
+  tok, err := jwt.Parse(token, s.Keys.Keyfunc, jwt.WithValidMethods(s.Keys.ValidMethods()))
+  if err != nil { t.Fatal(err) }
+  c := tok.Claims.(jwt.MapClaims)
+  exp, _ := c.GetExpirationTime()
+  return accessClaims{
+    jti: c["jti"].(string),
+    sid: c["sid"].(string),
+    iat: time.UnixMicro(int64(math.Round(c["iat"].(float64) * 1e6))),
+    exp: exp.Time,
+  }
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+  s := testService(t)
+  ctx := context.Background()
+  first, user, _ := testUser(t, s)
+
+  second, got, err := s.Refresh(ctx, first.RefreshToken, ClientInfo{})
+  if err != nil || got.ID != user.ID || second.RefreshToken == first.RefreshToken {
+    t.Fatalf("refresh: %v, %+v", err, got)
+  }
+  if parseAccess(t, s, second.AccessToken).sid != parseAccess(t, s, first.AccessToken).sid {
+    t.Error("refresh must stay in the same session")
+  }
+
+  // replaying the used token revokes the whole family, the new one included
+  if _, _, err := s.Refresh(ctx, first.RefreshToken, ClientInfo{}); err != ErrInvalidRefreshToken {
+    t.Errorf("reuse: %v", err)
+  }
+  if _, _, err := s.Refresh(ctx, second.RefreshToken, ClientInfo{}); err != ErrInvalidRefreshToken {
+    t.Errorf("after reuse the family must be dead: %v", err)
+  }
+  if _, _, err := s.Refresh(ctx, "", ClientInfo{}); err != ErrInvalidRefreshToken {
+    t.Errorf("empty token: %v", err)
+  }
+}
+
+func TestLogoutRevokesTokenAndSession(t *testing.T) {
+  s := testService(t)
+  ctx := context.Background()
+  tokens, user, _ := testUser(t, s)
+  c := parseAccess(t, s, tokens.AccessToken)
+
+  if revoked, err := s.IsRevoked(ctx, c.jti, c.sid, user.ID, c.iat); err != nil || revoked {
+    t.Fatalf("fresh token: revoked %v, %v", revoked, err)
+  }
+  if err := s.Logout(ctx, user.ID, c.sid, c.jti, c.exp, ""); err != nil {
+    t.Fatal(err)
+  }
+  if revoked, err := s.IsRevoked(ctx, c.jti, c.sid, user.ID, c.iat); err != nil || !revoked {
+    t.Errorf("after logout: revoked %v, %v", revoked, err)
+  }
+  if _, _, err := s.Refresh(ctx, tokens.RefreshToken, ClientInfo{}); err != ErrInvalidRefreshToken {
+    t.Errorf("refresh after logout: %v", err)
+  }
+}
+
+func TestRevokeAllSameSecond(t *testing.T) {
+  s := testService(t)
+  ctx := context.Background()
+  before, user, password := testUser(t, s)
+  old := parseAccess(t, s, before.AccessToken)
+
+  if err := s.RevokeAllForUser(ctx, user.ID); err != nil { t.Fatal(err) }
+  if revoked, err := s.IsRevoked(ctx, old.jti, old.sid, user.ID, old.iat); err != nil || !revoked {
+    t.Errorf("token issued before revoke-all: revoked %v, %v", revoked, err)
+  }
+
+  // a login right after, usually within the same second, stays valid
+  after, _, err := s.Login(ctx, user.Email, password, ClientInfo{})
+  if err != nil { t.Fatal(err) }
+  c := parseAccess(t, s, after.AccessToken)
+  if revoked, err := s.IsRevoked(ctx, c.jti, c.sid, user.ID, c.iat); err != nil || revoked {
+    t.Errorf("token issued after revoke-all: revoked %v, %v", revoked, err)
+  }
+
+  // a pre-revoke token in the same second with second-precision iat is out too
+  if revoked, _ := s.IsRevoked(ctx, "other", "", user.ID, old.iat.Truncate(time.Second)); !revoked {
+    t.Error("second-precision iat before revoke-all must be revoked")
+  }
+}
+
+func TestLoginResetsOnlyEmailKey(t *testing.T) {
+  s := testService(t)
+  ctx := context.Background()
+  throttle := NewMemoryThrottle(DefaultThrottlePolicy())
+  s.Throttle = throttle
+  _, user, password := testUser(t, s)
+  client := ClientInfo{IP: "198.51.100.9"}
+
+  if _, _, err := s.Login(ctx, "nobody-"+uuid.NewString()[:8]+"@example.com", password, client); err != ErrInvalidCredentials {
+    t.Fatalf("unknown email: %v", err)
+  }
+  if _, _, err := s.Login(ctx, user.Email, password, client); err != nil { t.Fatal(err) }
+  if a := throttle.entries["ip:"+client.IP]; a == nil || a.failures != 1 {
+    t.Errorf("successful login wiped the ip key: %+v", a)
+  }
+}
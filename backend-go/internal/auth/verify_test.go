@@ -0,0 +1,35 @@
+package auth
+
+import (
+  "strings"
+  "testing"
+  "time"
+)
+
+func TestVerificationRoundTrip(t *testing.T) {
+  s := Service{JwtSecret: "test-secret"}
+  exp := time.Now().Add(time.Hour)
+  for _, email := range []string{"student@example.com", `"a|b"@example.com`, "x|y|z@example.com"} {
+    userID, got, err := s.parseVerification(s.signVerification("u1", email, exp))
+    if err != nil || userID != "u1" || got != email {
+      t.Errorf("%s: parsed %q %q, %v", email, userID, got, err)
+    }
+  }
+}
+
+func TestVerificationRejects(t *testing.T) {
+  s := Service{JwtSecret: "test-secret"}
+  token := s.signVerification("u1", "student@example.com", time.Now().Add(time.Hour))
+  cases := map[string]string{
+    "empty":        "",
+    "no signature": strings.SplitN(token, ".", 2)[0],
+    "expired":      s.signVerification("u1", "student@example.com", time.Now().Add(-time.Minute)),
+    "other secret": Service{JwtSecret: "other"}.signVerification("u1", "student@example.com", time.Now().Add(time.Hour)),
+    "tampered":     "x" + token[1:],
+  }
+  for name, tok := range cases {
+    if _, _, err := s.parseVerification(tok); err != ErrInvalidVerifyToken {
+      t.Errorf("%s: %v", name, err)
+    }
+  }
+}
@@ -1,11 +1,97 @@
 package config
 
-import "os"
+import (
+  "os"
+  "strconv"
+  "strings"
+  "time"
+)
 
 type Config struct {
   DatabaseURL string
   JwtSecret   string
   Port        string
+
+  AccessTokenTTL  time.Duration
+  RefreshTokenTTL time.Duration
+
+  // JWT signing keys: JWT_ALG is HS256, RS256 or EdDSA. With rotation on (or a
+  // non-HS256 alg) keys live in Postgres so all instances share them.
+  // JWT_SECRET still verifies old kid-less tokens for JwtLegacyGrace after
+  // the first kid key took over.
+  JwtAlg         string
+  JwtKeyRotation time.Duration // 0 = never rotate
+  JwtKeyGrace    time.Duration
+  JwtLegacyGrace time.Duration
+
+  AppURL string // frontend base URL used in email links
+
+  // mail: SMTP when SMTP_HOST is set, otherwise .eml files in MailOutboxDir
+  SMTPHost      string
+  SMTPPort      string
+  SMTPUser      string
+  SMTPPassword  string
+  MailFrom      string
+  MailOutboxDir string
+
+  // TRUSTED_PROXIES: CIDRs of reverse proxies whose X-Forwarded-For is
+  // believed ("10.0.0.0/8,172.16.0.0/12"); empty means the client IP is the
+  // TCP peer and forwarded headers are ignored
+  TrustedProxies []string
+
+  // block score-saving endpoints until the user verified their email
+  RequireVerifiedEmail bool
+
+  // login brute-force protection: "postgres" (default), "memory" or "off"
+  LoginThrottle        string
+  LoginLockoutAfter    int
+  LoginLockoutDuration time.Duration
+
+  OIDCProviders []OIDCProvider
+
+  // password policy for register/reset; PASSWORD_REQUIRE is a comma list of
+  // upper,lower,digit,symbol. PASSWORD_BREACHED_PATH: SHA-1 range dir or hash file.
+  PasswordMinLength    int
+  PasswordRequire      []string
+  PasswordBreachedPath string
+
+  // DELETE /me: soft delete, hard purge (cmd/purge_accounts) after this long
+  AccountDeleteGrace time.Duration
+
+  // document vault: DOCUMENTS_STORAGE is "local" (files under DocumentsDir)
+  // or "s3" (any S3-compatible endpoint; S3_PATH_STYLE for MinIO)
+  DocumentsStorage  string
+  DocumentsDir      string
+  DocumentsMaxBytes int64
+  S3Endpoint        string
+  S3Region          string
+  S3Bucket          string
+  S3AccessKey       string
+  S3SecretKey       string
+  S3PathStyle       bool
+
+  // default currency for tuition/budget comparisons (fx_rates, cmd/import_fx)
+  DisplayCurrency string
+
+  // scoring engine for /score and smart search: a registered version
+  // ("match-v1") or a name for its newest version ("match")
+  Scorer string
+
+  // JSON weights of the match scorer (scoring_weights.example.json), reloaded
+  // when the file changes; unset keeps the built-in weights
+  ScoringWeights     string
+  ScoringWeightsPoll time.Duration
+}
+
+// OIDCProvider comes from OIDC_PROVIDERS=google,microsoft plus, per name,
+// OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL and optional _SCOPES.
+type OIDCProvider struct {
+  Name         string
+  Issuer       string
+  ClientID     string
+  ClientSecret string
+  RedirectURL  string
+  Scopes       []string
 }
 
 func Load() Config {
@@ -13,7 +99,119 @@ func Load() Config {
     DatabaseURL: os.Getenv("DATABASE_URL"),
     JwtSecret:   os.Getenv("JWT_SECRET"),
     Port:        os.Getenv("PORT"),
+
+    AccessTokenTTL:  durationEnv("ACCESS_TOKEN_TTL", 15*time.Minute),
+    RefreshTokenTTL: durationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+    JwtAlg:         os.Getenv("JWT_ALG"),
+    JwtKeyRotation: durationEnv("JWT_KEY_ROTATION", 0),
+    JwtKeyGrace:    durationEnv("JWT_KEY_GRACE", 24*time.Hour),
+    JwtLegacyGrace: durationEnv("JWT_LEGACY_GRACE", 24*time.Hour),
+
+    AppURL: os.Getenv("APP_URL"),
+
+    SMTPHost:      os.Getenv("SMTP_HOST"),
+    SMTPPort:      os.Getenv("SMTP_PORT"),
+    SMTPUser:      os.Getenv("SMTP_USER"),
+    SMTPPassword:  os.Getenv("SMTP_PASSWORD"),
+    MailFrom:      os.Getenv("MAIL_FROM"),
+    MailOutboxDir: os.Getenv("MAIL_OUTBOX_DIR"),
+
+    TrustedProxies: listEnv("TRUSTED_PROXIES", ""),
+
+    RequireVerifiedEmail: boolEnv("REQUIRE_VERIFIED_EMAIL"),
+
+    LoginThrottle:        os.Getenv("LOGIN_THROTTLE"),
+    LoginLockoutAfter:    intEnv("LOGIN_LOCKOUT_AFTER", 10),
+    LoginLockoutDuration: durationEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+
+    PasswordMinLength:    intEnv("PASSWORD_MIN_LENGTH", 8),
+    PasswordRequire:      listEnv("PASSWORD_REQUIRE", "lower,digit"),
+    PasswordBreachedPath: os.Getenv("PASSWORD_BREACHED_PATH"),
+
+    AccountDeleteGrace: durationEnv("ACCOUNT_DELETE_GRACE", 30*24*time.Hour),
+
+    DocumentsStorage:  os.Getenv("DOCUMENTS_STORAGE"),
+    DocumentsDir:      os.Getenv("DOCUMENTS_DIR"),
+    DocumentsMaxBytes: int64(intEnv("DOCUMENTS_MAX_MB", 10)) << 20,
+    S3Endpoint:        os.Getenv("S3_ENDPOINT"),
+    S3Region:          os.Getenv("S3_REGION"),
+    S3Bucket:          os.Getenv("S3_BUCKET"),
+    S3AccessKey:       os.Getenv("S3_ACCESS_KEY"),
+    S3SecretKey:       os.Getenv("S3_SECRET_KEY"),
+    S3PathStyle:       boolEnv("S3_PATH_STYLE"),
+
+    DisplayCurrency: strings.ToUpper(os.Getenv("DISPLAY_CURRENCY")),
+
+    Scorer: os.Getenv("SCORER"),
+
+    ScoringWeights:     os.Getenv("SCORING_WEIGHTS"),
+    ScoringWeightsPoll: durationEnv("SCORING_WEIGHTS_POLL", 10*time.Second),
   }
   if c.Port == "" { c.Port = "8080" }
+  if c.JwtAlg == "" { c.JwtAlg = "HS256" }
+  if c.AppURL == "" { c.AppURL = "http://localhost:5173" }
+  if c.SMTPPort == "" { c.SMTPPort = "587" }
+  if c.MailFrom == "" { c.MailFrom = "UniChance <no-reply@unichance.local>" }
+  if c.MailOutboxDir == "" { c.MailOutboxDir = "outbox" }
+  if c.LoginThrottle == "" { c.LoginThrottle = "postgres" }
+  if c.DocumentsStorage == "" { c.DocumentsStorage = "local" }
+  if c.DocumentsDir == "" { c.DocumentsDir = "documents" }
+  if c.S3Region == "" { c.S3Region = "us-east-1" }
+  if c.DisplayCurrency == "" { c.DisplayCurrency = "USD" }
+  if c.Scorer == "" { c.Scorer = "match" }
+  c.OIDCProviders = loadOIDCProviders()
   return c
 }
+
+// durationEnv parses a Go duration ("15m", "720h"); bad or empty values fall back to def.
+func durationEnv(key string, def time.Duration) time.Duration {
+  v := os.Getenv(key)
+  if v == "" { return def }
+  d, err := time.ParseDuration(v)
+  if err != nil || d <= 0 { return def }
+  return d
+}
+
+func boolEnv(key string) bool {
+  v := os.Getenv(key)
+  return v == "1" || v == "true" || v == "TRUE" || v == "yes"
+}
+
+func intEnv(key string, def int) int {
+  v, err := strconv.Atoi(os.Getenv(key))
+  if err != nil { return def }
+  return v
+}
+
+// listEnv splits a comma separated value; unset means def, "" or "none" means empty.
+func listEnv(key, def string) []string {
+  v, ok := os.LookupEnv(key)
+  if !ok { v = def }
+  var out []string
+  for _, p := range strings.Split(v, ",") {
+    p = strings.ToLower(strings.TrimSpace(p))
+    if p != "" && p != "none" { out = append(out, p) }
+  }
+  return out
+}
+
+func loadOIDCProviders() []OIDCProvider {
+  var out []OIDCProvider
+  for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+    name = strings.TrimSpace(name)
+    if name == "" { continue }
+    prefix := "OIDC_" + strings.ToUpper(name) + "_"
+    p := OIDCProvider{
+      Name:         strings.ToLower(name),
+      Issuer:       os.Getenv(prefix + "ISSUER"),
+      ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+      ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+      RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+      Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+    }
+    if p.Issuer == "" || p.ClientID == "" { continue }
+    out = append(out, p)
+  }
+  return out
+}
@@ -0,0 +1,118 @@
+package counselor
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"unichance-backend-go/internal/middleware"
+	"unichance-backend-go/internal/validation"
+)
+
+type Handler struct{ Svc Service }
+
+type inviteReq struct {
+	Email string `json:"email"`
+	Scope string `json:"scope"` // read (default) | write
+}
+
+// ===== counselor side: /counselor/... =====
+
+func (h Handler) Invite(c echo.Context) error {
+	u := c.Get("user").(middleware.CtxUser)
+	var req inviteReq
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "bad body"})
+	}
+	l, err := h.Svc.Invite(c.Request().Context(), u.ID, u.Email, req.Email, req.Scope)
+	if verrs, ok := validation.As(err); ok {
+		return c.JSON(http.StatusUnprocessableEntity, verrs.Body())
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusCreated, map[string]any{"link": l})
+}
+
+// Invitations lists the counselor's pending invitations.
+func (h Handler) Invitations(c echo.Context) error {
+	return h.listCounselorLinks(c, "pending")
+}
+
+// Students lists the students who accepted (active links).
+func (h Handler) Students(c echo.Context) error {
+	return h.listCounselorLinks(c, "active")
+}
+
+func (h Handler) listCounselorLinks(c echo.Context, status string) error {
+	u := c.Get("user").(middleware.CtxUser)
+	items, err := h.Svc.CounselorLinks(c.Request().Context(), u.ID, status)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"items": items})
+}
+
+// EndLink cancels an invitation or drops a linked student.
+func (h Handler) EndLink(c echo.Context) error {
+	u := c.Get("user").(middleware.CtxUser)
+	return h.noContent(c, h.Svc.EndLink(c.Request().Context(), u.ID, c.Param("id")))
+}
+
+// ===== student side: /me/counselors/... =====
+
+func (h Handler) MyCounselors(c echo.Context) error {
+	u := c.Get("user").(middleware.CtxUser)
+	items, err := h.Svc.StudentLinks(c.Request().Context(), u.ID, u.Email)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"items": items})
+}
+
+func (h Handler) Accept(c echo.Context) error {
+	u := c.Get("user").(middleware.CtxUser)
+	l, err := h.Svc.Accept(c.Request().Context(), u.ID, u.Email, c.Param("id"))
+	switch err {
+	case nil:
+		return c.JSON(http.StatusOK, map[string]any{"link": l})
+	case ErrLinkNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case ErrEmailNotVerified:
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
+func (h Handler) Decline(c echo.Context) error {
+	u := c.Get("user").(middleware.CtxUser)
+	return h.noContent(c, h.Svc.Decline(c.Request().Context(), u.Email, c.Param("id")))
+}
+
+// Revoke withdraws consent; the counselor loses access immediately.
+func (h Handler) Revoke(c echo.Context) error {
+	u := c.Get("user").(middleware.CtxUser)
+	return h.noContent(c, h.Svc.Revoke(c.Request().Context(), u.ID, c.Param("id")))
+}
+
+// AccessLog shows the student every counselor request on their data.
+func (h Handler) AccessLog(c echo.Context) error {
+	u := c.Get("user").(middleware.CtxUser)
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	items, err := h.Svc.AccessLog(c.Request().Context(), u.ID, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"items": items})
+}
+
+func (h Handler) noContent(c echo.Context, err error) error {
+	switch err {
+	case nil:
+		return c.NoContent(http.StatusNoContent)
+	case ErrLinkNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
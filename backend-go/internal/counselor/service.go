@@ -0,0 +1,353 @@
+// Package counselor links counselor accounts to the students they manage.
+// A counselor invites a student by email; the link only becomes active once
+// the student accepts it, and the student can revoke it at any time. Access
+// to a student's data goes through middleware.ActAsStudent, which uses
+// Service for the link check and the access log.
+package counselor
+
+import (
+	"context"
+	"errors"
+	"log"
+	netmail "net/mail"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"unichance-backend-go/internal/mail"
+	"unichance-backend-go/internal/middleware"
+	"unichance-backend-go/internal/validation"
+)
+
+var (
+	ErrLinkNotFound     = errors.New("invitation or link not found")
+	ErrEmailNotVerified = errors.New("verify your email before accepting an invitation")
+)
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+type Service struct {
+	DB        *pgxpool.Pool
+	Mailer    mail.Mailer
+	AppURL    string
+	InviteTTL time.Duration // default 14 days
+}
+
+// Link is a counselor -> student invitation or active link.
+type Link struct {
+	ID             string     `json:"id"`
+	CounselorID    string     `json:"counselor_id"`
+	CounselorEmail string     `json:"counselor_email"`
+	StudentID      *string    `json:"student_id"`
+	Email          string     `json:"email"` // invited student email
+	Scope          string     `json:"scope"`
+	Status         string     `json:"status"` // pending | active | declined | revoked | expired
+	CreatedAt      time.Time  `json:"created_at"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+	EndedAt        *time.Time `json:"ended_at"`
+}
+
+// AccessEntry is one counselor request on a student's data.
+type AccessEntry struct {
+	CounselorEmail *string   `json:"counselor_email"`
+	Method         string    `json:"method"`
+	Path           string    `json:"path"`
+	Status         int       `json:"status"`
+	IP             *string   `json:"ip"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (s Service) inviteTTL() time.Duration {
+	if s.InviteTTL <= 0 {
+		return 14 * 24 * time.Hour
+	}
+	return s.InviteTTL
+}
+
+const linkColumns = `l.id, l.counselor_id, cu.email, l.student_id, l.invite_email, l.scope, l.status,
+  l.created_at, l.expires_at, l.accepted_at, l.ended_at`
+
+func scanLink(row pgx.Row) (Link, error) {
+	var l Link
+	err := row.Scan(&l.ID, &l.CounselorID, &l.CounselorEmail, &l.StudentID, &l.Email, &l.Scope, &l.Status,
+		&l.CreatedAt, &l.ExpiresAt, &l.AcceptedAt, &l.EndedAt)
+	return l, err
+}
+
+func (s Service) queryLinks(ctx context.Context, q string, args ...any) ([]Link, error) {
+	rows, err := s.DB.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Link{}
+	for rows.Next() {
+		l, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// Invite creates a pending link and emails the student.
+func (s Service) Invite(ctx context.Context, counselorID, counselorEmail, email, scope string) (Link, error) {
+	email = strings.TrimSpace(email)
+	if scope == "" {
+		scope = ScopeRead
+	}
+	var errs validation.Errors
+	if addr, err := netmail.ParseAddress(email); err != nil || addr.Address != email {
+		errs.Add("email", "invalid", "invalid email")
+	} else if strings.EqualFold(email, counselorEmail) {
+		errs.Add("email", "invalid", "you can't invite yourself")
+	}
+	if scope != ScopeRead && scope != ScopeWrite {
+		errs.Add("scope", "invalid", "scope must be read or write")
+	}
+	if err := errs.Err(); err != nil {
+		return Link{}, err
+	}
+
+	// an expired invitation no longer blocks a new one (uq_counselor_links_open)
+	if err := s.expireInvites(ctx, counselorID); err != nil {
+		return Link{}, err
+	}
+	var id string
+	err := s.DB.QueryRow(ctx, `
+    INSERT INTO counselor_links(counselor_id, invite_email, scope, expires_at)
+    VALUES ($1,$2,$3,$4)
+    RETURNING id
+  `, counselorID, email, scope, time.Now().Add(s.inviteTTL())).Scan(&id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return Link{}, validation.Errors{{Field: "email", Code: "taken", Message: "this student is already invited or linked"}}
+	}
+	if err != nil {
+		return Link{}, err
+	}
+
+	// a mail outage shouldn't fail the invitation, the student also sees it
+	// in-app; sent in the background so a slow SMTP server doesn't hold the
+	// request
+	if s.Mailer == nil {
+		log.Printf("counselor: no mailer configured, invitation for %s not sent", email)
+	} else {
+		msg := mail.Message{
+			To:      email,
+			Subject: "UniChance: приглашение от консультанта",
+			Body: counselorEmail + " приглашает вас подключить консультанта к вашему профилю UniChance" +
+				" (доступ: " + scopeName(scope) + ").\n\n" +
+				"Принять или отклонить приглашение: " + strings.TrimRight(s.AppURL, "/") + "/counselors\n\n" +
+				"Приглашение действует " + s.inviteTTL().String() + ". Доступ можно отозвать в любой момент.\n",
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), inviteMailTimeout)
+			defer cancel()
+			if err := s.Mailer.Send(ctx, msg); err != nil {
+				log.Printf("counselor: invitation email to %s failed: %v", email, err)
+			}
+		}()
+	}
+
+	return s.getLink(ctx, id)
+}
+
+const inviteMailTimeout = 30 * time.Second
+
+func scopeName(scope string) string {
+	if scope == ScopeWrite {
+		return "просмотр и изменение"
+	}
+	return "только просмотр"
+}
+
+func (s Service) getLink(ctx context.Context, id string) (Link, error) {
+	l, err := scanLink(s.DB.QueryRow(ctx, `
+    SELECT `+linkColumns+`
+    FROM counselor_links l JOIN users cu ON cu.id = l.counselor_id
+    WHERE l.id=$1
+  `, id))
+	if err == pgx.ErrNoRows {
+		return l, ErrLinkNotFound
+	}
+	return l, err
+}
+
+// expireInvites marks the counselor's pending invitations past expires_at as
+// expired.
+func (s Service) expireInvites(ctx context.Context, counselorID string) error {
+	_, err := s.DB.Exec(ctx, `
+    UPDATE counselor_links SET status='expired', ended_at=expires_at
+    WHERE counselor_id=$1 AND status='pending' AND expires_at <= now()
+  `, counselorID)
+	return err
+}
+
+// CounselorLinks lists the counselor's links with the given status ("" = open:
+// pending or active), newest first.
+func (s Service) CounselorLinks(ctx context.Context, counselorID, status string) ([]Link, error) {
+	if err := s.expireInvites(ctx, counselorID); err != nil {
+		return nil, err
+	}
+	return s.queryLinks(ctx, `
+    SELECT `+linkColumns+`
+    FROM counselor_links l JOIN users cu ON cu.id = l.counselor_id
+    WHERE l.counselor_id=$1
+      AND (($2 = '' AND l.status IN ('pending','active')) OR l.status = $2)
+    ORDER BY l.created_at DESC
+  `, counselorID, status)
+}
+
+// EndLink lets the counselor cancel an invitation or drop a student.
+func (s Service) EndLink(ctx context.Context, counselorID, linkID string) error {
+	tag, err := s.DB.Exec(ctx, `
+    UPDATE counselor_links SET status='revoked', ended_at=now()
+    WHERE id::text=$1 AND counselor_id=$2 AND status IN ('pending','active')
+  `, linkID, counselorID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// StudentLinks lists the links of a student: accepted ones and pending
+// invitations sent to their email.
+func (s Service) StudentLinks(ctx context.Context, studentID, email string) ([]Link, error) {
+	return s.queryLinks(ctx, `
+    SELECT `+linkColumns+`
+    FROM counselor_links l JOIN users cu ON cu.id = l.counselor_id
+    WHERE l.student_id=$1
+       OR (l.status='pending' AND lower(l.invite_email)=lower($2) AND l.expires_at > now())
+    ORDER BY l.created_at DESC
+  `, studentID, email)
+}
+
+// Accept is the student's consent: the pending invitation to their email
+// becomes an active link. Requires a verified email.
+func (s Service) Accept(ctx context.Context, studentID, email, linkID string) (Link, error) {
+	var verified bool
+	err := s.DB.QueryRow(ctx,
+		`SELECT email_verified_at IS NOT NULL FROM users WHERE id=$1`, studentID,
+	).Scan(&verified)
+	if err != nil {
+		return Link{}, err
+	}
+	if !verified {
+		return Link{}, ErrEmailNotVerified
+	}
+
+	tag, err := s.DB.Exec(ctx, `
+    UPDATE counselor_links SET status='active', student_id=$2, accepted_at=now()
+    WHERE id::text=$1 AND status='pending' AND lower(invite_email)=lower($3) AND expires_at > now()
+  `, linkID, studentID, email)
+	if err != nil {
+		return Link{}, err
+	}
+	if tag.RowsAffected() == 0 {
+		return Link{}, ErrLinkNotFound
+	}
+	return s.getLink(ctx, linkID)
+}
+
+// Decline refuses a pending invitation.
+func (s Service) Decline(ctx context.Context, email, linkID string) error {
+	return s.end(ctx, `
+    UPDATE counselor_links SET status='declined', ended_at=now()
+    WHERE id::text=$1 AND status='pending' AND lower(invite_email)=lower($2)
+  `, linkID, email)
+}
+
+// Revoke withdraws the student's consent for an active link.
+func (s Service) Revoke(ctx context.Context, studentID, linkID string) error {
+	return s.end(ctx, `
+    UPDATE counselor_links SET status='revoked', ended_at=now()
+    WHERE id::text=$1 AND status='active' AND student_id=$2
+  `, linkID, studentID)
+}
+
+func (s Service) end(ctx context.Context, q string, args ...any) error {
+	tag, err := s.DB.Exec(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// CheckLink implements middleware.CounselorAccess. A student who deleted
+// their account is out of reach during the grace period; the link itself
+// stays, so restoring the account restores the counselor's access.
+func (s Service) CheckLink(ctx context.Context, counselorID, studentID string, write bool) (string, error) {
+	var id, scope string
+	err := s.DB.QueryRow(ctx, `
+    SELECT l.id, l.scope FROM counselor_links l
+    JOIN users su ON su.id = l.student_id
+    WHERE l.counselor_id=$1 AND l.student_id::text=$2 AND l.status='active'
+      AND su.deleted_at IS NULL
+  `, counselorID, studentID).Scan(&id, &scope)
+	if err == pgx.ErrNoRows {
+		return "", middleware.ErrNoLink
+	}
+	if err != nil {
+		return "", err
+	}
+	if write && scope != ScopeWrite {
+		return id, middleware.ErrNoLink
+	}
+	return id, nil
+}
+
+// LogAccess implements middleware.CounselorAccess. A failed insert is logged,
+// not returned: the response was already produced.
+func (s Service) LogAccess(ctx context.Context, linkID, counselorID, studentID, method, path string, status int, ip string) {
+	_, err := s.DB.Exec(ctx, `
+    INSERT INTO counselor_access_log(link_id, counselor_id, student_id, method, path, status, ip)
+    SELECT NULLIF($1,'')::uuid, $2, u.id, $4, $5, $6, NULLIF($7,'')
+    FROM users u WHERE u.id::text=$3
+  `, linkID, counselorID, studentID, method, path, status, ip)
+	if err != nil {
+		log.Printf("counselor: access log for %s failed: %v", studentID, err)
+	}
+}
+
+// AccessLog is the audit trail of counselor requests on the student's data.
+func (s Service) AccessLog(ctx context.Context, studentID string, limit int) ([]AccessEntry, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	rows, err := s.DB.Query(ctx, `
+    SELECT cu.email, a.method, a.path, a.status, a.ip, a.created_at
+    FROM counselor_access_log a
+    LEFT JOIN users cu ON cu.id = a.counselor_id
+    WHERE a.student_id=$1
+    ORDER BY a.created_at DESC
+    LIMIT $2
+  `, studentID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []AccessEntry{}
+	for rows.Next() {
+		var e AccessEntry
+		if err := rows.Scan(&e.CounselorEmail, &e.Method, &e.Path, &e.Status, &e.IP, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,194 @@
+package counselor
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"unichance-backend-go/internal/mail"
+	"unichance-backend-go/internal/middleware"
+	"unichance-backend-go/internal/validation"
+)
+
+// sentMail collects recipients; invitations are sent in the background.
+type sentMail struct{ to chan string }
+
+func (m *sentMail) Send(ctx context.Context, msg mail.Message) error {
+	m.to <- msg.To
+	return nil
+}
+
+func (m *sentMail) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case to := <-m.to:
+		return to
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail sent")
+		return ""
+	}
+}
+
+// testService needs a migrated database in DATABASE_URL.
+func testService(t *testing.T) (Service, *sentMail) {
+	t.Helper()
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		t.Skip("DATABASE_URL not set, skipping database tests")
+	}
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	m := &sentMail{to: make(chan string, 16)}
+	return Service{DB: pool, Mailer: m, AppURL: "http://app"}, m
+}
+
+// testUser creates a user; verified sets email_verified_at.
+func testUser(t *testing.T, s Service, verified bool) (id, email string) {
+	t.Helper()
+	email = "counselor-" + uuid.NewString()[:8] + "@example.com"
+	err := s.DB.QueryRow(context.Background(), `
+    INSERT INTO users(email, password_hash, email_verified_at)
+    VALUES ($1, '', CASE WHEN $2 THEN now() END)
+    RETURNING id
+  `, email, verified).Scan(&id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.DB.Exec(context.Background(), `DELETE FROM users WHERE id=$1`, id) })
+	return id, email
+}
+
+func TestInviteAcceptCheckLink(t *testing.T) {
+	s, sent := testService(t)
+	ctx := context.Background()
+	counselorID, counselorEmail := testUser(t, s, true)
+	studentID, studentEmail := testUser(t, s, true)
+
+	link, err := s.Invite(ctx, counselorID, counselorEmail, studentEmail, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Status != "pending" || link.Scope != ScopeRead {
+		t.Fatalf("invite: %+v", link)
+	}
+	if to := sent.next(t); to != studentEmail {
+		t.Errorf("mail to %s", to)
+	}
+	if _, err := s.Invite(ctx, counselorID, counselorEmail, studentEmail, ""); !isTaken(err) {
+		t.Errorf("second open invite: %v", err)
+	}
+	if _, err := s.CheckLink(ctx, counselorID, studentID, false); err != middleware.ErrNoLink {
+		t.Errorf("pending link grants access: %v", err)
+	}
+
+	if _, err := s.Accept(ctx, studentID, "someone-else@example.com", link.ID); err != ErrLinkNotFound {
+		t.Errorf("accept by another email: %v", err)
+	}
+	link, err = s.Accept(ctx, studentID, studentEmail, link.ID)
+	if err != nil || link.Status != "active" {
+		t.Fatalf("accept: %+v, %v", link, err)
+	}
+	if id, err := s.CheckLink(ctx, counselorID, studentID, false); err != nil || id != link.ID {
+		t.Errorf("read access: %q, %v", id, err)
+	}
+	if _, err := s.CheckLink(ctx, counselorID, studentID, true); err != middleware.ErrNoLink {
+		t.Errorf("read link allows writes: %v", err)
+	}
+
+	// a soft-deleted student is out of reach until restored
+	s.DB.Exec(ctx, `UPDATE users SET deleted_at=now() WHERE id=$1`, studentID)
+	if _, err := s.CheckLink(ctx, counselorID, studentID, false); err != middleware.ErrNoLink {
+		t.Errorf("deleted student still readable: %v", err)
+	}
+	s.DB.Exec(ctx, `UPDATE users SET deleted_at=NULL WHERE id=$1`, studentID)
+	if _, err := s.CheckLink(ctx, counselorID, studentID, false); err != nil {
+		t.Errorf("restored student: %v", err)
+	}
+
+	if err := s.Revoke(ctx, studentID, link.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CheckLink(ctx, counselorID, studentID, false); err != middleware.ErrNoLink {
+		t.Errorf("revoked link grants access: %v", err)
+	}
+}
+
+func TestAcceptNeedsVerifiedEmail(t *testing.T) {
+	s, _ := testService(t)
+	ctx := context.Background()
+	counselorID, counselorEmail := testUser(t, s, true)
+	studentID, studentEmail := testUser(t, s, false)
+
+	link, err := s.Invite(ctx, counselorID, counselorEmail, studentEmail, ScopeWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Accept(ctx, studentID, studentEmail, link.ID); err != ErrEmailNotVerified {
+		t.Errorf("unverified accept: %v", err)
+	}
+}
+
+func TestDeclineAndExpiredInvites(t *testing.T) {
+	s, _ := testService(t)
+	ctx := context.Background()
+	counselorID, counselorEmail := testUser(t, s, true)
+	studentID, studentEmail := testUser(t, s, true)
+
+	if _, err := s.Invite(ctx, counselorID, counselorEmail, counselorEmail, ""); !isInvalid(err) {
+		t.Errorf("self invite: %v", err)
+	}
+
+	link, err := s.Invite(ctx, counselorID, counselorEmail, studentEmail, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Decline(ctx, studentEmail, link.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Decline(ctx, studentEmail, link.ID); err != ErrLinkNotFound {
+		t.Errorf("second decline: %v", err)
+	}
+
+	// an invitation that ran out can't be accepted and doesn't block a new one
+	link, err = s.Invite(ctx, counselorID, counselorEmail, studentEmail, "")
+	if err != nil {
+		t.Fatalf("re-invite after decline: %v", err)
+	}
+	s.DB.Exec(ctx, `UPDATE counselor_links SET expires_at=now() - interval '1 minute' WHERE id=$1`, link.ID)
+	if _, err := s.Accept(ctx, studentID, studentEmail, link.ID); err != ErrLinkNotFound {
+		t.Errorf("accept expired: %v", err)
+	}
+	if _, err := s.Invite(ctx, counselorID, counselorEmail, studentEmail, ""); err != nil {
+		t.Errorf("re-invite after expiry: %v", err)
+	}
+	if expired, err := s.CounselorLinks(ctx, counselorID, "expired"); err != nil || len(expired) != 1 {
+		t.Errorf("expired links: %+v, %v", expired, err)
+	}
+}
+
+func isTaken(err error) bool {
+	errs, ok := validation.As(err)
+	return ok && len(errs) == 1 && errs[0].Code == "taken"
+}
+
+func isInvalid(err error) bool {
+	errs, ok := validation.As(err)
+	return ok && len(errs) == 1 && errs[0].Code == "invalid"
+}
+
+func TestInviteWithoutMailer(t *testing.T) {
+	s, _ := testService(t)
+	s.Mailer = nil
+	counselorID, counselorEmail := testUser(t, s, true)
+	_, studentEmail := testUser(t, s, true)
+	if _, err := s.Invite(context.Background(), counselorID, counselorEmail, studentEmail, ""); err != nil {
+		t.Fatal(err)
+	}
+}
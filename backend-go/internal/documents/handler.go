@@ -0,0 +1,132 @@
+package documents
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"unichance-backend-go/internal/middleware"
+	"unichance-backend-go/internal/validation"
+)
+
+type Handler struct{ Svc Service }
+
+// multipartOverhead is room for the multipart envelope and the other form
+// fields on top of the file itself.
+const multipartOverhead = 64 << 10
+
+// Upload takes multipart/form-data with "file" and an optional "kind".
+// 201 for a new document, 200 with duplicate=true when the same file was
+// uploaded before.
+func (h Handler) Upload(c echo.Context) error {
+	userID := middleware.SubjectID(c)
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, h.Svc.maxBytes()+multipartOverhead)
+
+	fh, err := c.FormFile("file")
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": ErrTooLarge.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "multipart field \"file\" is required"})
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "bad file"})
+	}
+	defer f.Close()
+
+	d, created, err := h.Svc.Upload(req.Context(), userID, c.FormValue("kind"), fh.Filename, f)
+	if verrs, ok := validation.As(err); ok {
+		return c.JSON(http.StatusUnprocessableEntity, verrs.Body())
+	}
+	switch {
+	case errors.Is(err, ErrTooLarge):
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrUnsupported):
+		return c.JSON(http.StatusUnsupportedMediaType, map[string]string{"error": err.Error()})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	status := http.StatusCreated
+	if !created {
+		status = http.StatusOK
+	}
+	return c.JSON(status, map[string]any{"document": d, "duplicate": !created})
+}
+
+func (h Handler) List(c echo.Context) error {
+	items, err := h.Svc.List(c.Request().Context(), middleware.SubjectID(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]any{"items": items})
+}
+
+func (h Handler) Get(c echo.Context) error {
+	d, err := h.Svc.Get(c.Request().Context(), middleware.SubjectID(c), c.Param("id"))
+	if err != nil {
+		return docError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]any{"document": d})
+}
+
+// Download streams the file with its sniffed type, always as an attachment
+// so an uploaded file never renders inline on our origin.
+func (h Handler) Download(c echo.Context) error {
+	d, rc, err := h.Svc.Open(c.Request().Context(), middleware.SubjectID(c), c.Param("id"))
+	if err != nil {
+		return docError(c, err)
+	}
+	defer rc.Close()
+
+	hdr := c.Response().Header()
+	hdr.Set(echo.HeaderContentType, d.ContentType)
+	hdr.Set(echo.HeaderContentLength, strconv.FormatInt(d.Size, 10))
+	hdr.Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": d.Filename}))
+	hdr.Set(echo.HeaderXContentTypeOptions, "nosniff")
+	hdr.Set("Cache-Control", "private, no-store")
+	c.Response().WriteHeader(http.StatusOK)
+	_, err = io.Copy(c.Response(), rc)
+	return err
+}
+
+func (h Handler) Delete(c echo.Context) error {
+	err := h.Svc.Delete(c.Request().Context(), middleware.SubjectID(c), c.Param("id"))
+	if err != nil {
+		return docError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// LinkField: PUT /documents/:id/fields/:field.
+func (h Handler) LinkField(c echo.Context) error {
+	err := h.Svc.LinkField(c.Request().Context(), middleware.SubjectID(c), c.Param("id"), c.Param("field"))
+	if err != nil {
+		return docError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h Handler) UnlinkField(c echo.Context) error {
+	err := h.Svc.UnlinkField(c.Request().Context(), middleware.SubjectID(c), c.Param("id"), c.Param("field"))
+	if err != nil {
+		return docError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func docError(c echo.Context, err error) error {
+	if verrs, ok := validation.As(err); ok {
+		return c.JSON(http.StatusUnprocessableEntity, verrs.Body())
+	}
+	if errors.Is(err, ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
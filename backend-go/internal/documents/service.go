@@ -0,0 +1,342 @@
+// Package documents is the document vault: transcripts, test reports and
+// certificates a user uploads to back their profile. File bytes go to a
+// Storage backend (local directory or S3); Postgres keeps the metadata, a
+// per-user SHA-256 for dedup, and links from profile fields to the
+// document that proves them (the IELTS report behind "ielts").
+package documents
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"unichance-backend-go/internal/profile"
+	"unichance-backend-go/internal/validation"
+)
+
+var (
+	ErrNotFound    = errors.New("document not found")
+	ErrTooLarge    = errors.New("file is too large")
+	ErrUnsupported = errors.New("unsupported file type; upload a PDF, PNG, JPEG or WebP")
+)
+
+// DefaultMaxBytes applies when Service.MaxBytes is unset.
+const DefaultMaxBytes = 10 << 20
+
+// Kinds of documents.
+var Kinds = map[string]bool{"transcript": true, "test_report": true, "certificate": true, "other": true}
+
+// allowedTypes are the sniffed content types we accept. The client's
+// Content-Type header is never trusted.
+var allowedTypes = map[string]bool{
+	"application/pdf": true,
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/webp":      true,
+}
+
+type Service struct {
+	DB       *pgxpool.Pool
+	Storage  Storage
+	MaxBytes int64
+}
+
+func (s Service) maxBytes() int64 {
+	if s.MaxBytes <= 0 {
+		return DefaultMaxBytes
+	}
+	return s.MaxBytes
+}
+
+type Document struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size_bytes"`
+	SHA256      string    `json:"sha256"`
+	Fields      []string  `json:"fields"` // profile fields this document backs
+	CreatedAt   time.Time `json:"created_at"`
+
+	storageKey string
+}
+
+// readLimited reads all of r, failing with ErrTooLarge past max bytes.
+func readLimited(r io.Reader, max int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// sniff returns the content type of data when it's one we accept.
+func sniff(data []byte) (string, error) {
+	ct := http.DetectContentType(data)
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	if !allowedTypes[ct] {
+		return "", ErrUnsupported
+	}
+	return ct, nil
+}
+
+// cleanFilename keeps the base name only and drops control characters, so it
+// is safe to echo back in Content-Disposition.
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" || !utf8.ValidString(name) {
+		return "document"
+	}
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return name
+}
+
+// Upload stores a file for the user. An identical file (same SHA-256) is not
+// stored twice: the existing document comes back with created=false.
+func (s Service) Upload(ctx context.Context, userID, kind, filename string, r io.Reader) (Document, bool, error) {
+	if kind == "" {
+		kind = "other"
+	}
+	if !Kinds[kind] {
+		var errs validation.Errors
+		errs.Add("kind", "invalid", "kind must be transcript, test_report, certificate or other")
+		return Document{}, false, errs.Err()
+	}
+
+	data, err := readLimited(r, s.maxBytes())
+	if err != nil {
+		return Document{}, false, err
+	}
+	if len(data) == 0 {
+		var errs validation.Errors
+		errs.Add("file", "required", "file is empty")
+		return Document{}, false, errs.Err()
+	}
+	ct, err := sniff(data)
+	if err != nil {
+		return Document{}, false, err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	if d, err := s.byHash(ctx, userID, hash); err == nil {
+		return d, false, nil
+	} else if !errors.Is(err, ErrNotFound) {
+		return Document{}, false, err
+	}
+
+	key := userID + "/" + hash
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return Document{}, false, err
+	}
+	defer tx.Rollback(ctx)
+	if err := lockKey(ctx, tx, key); err != nil {
+		return Document{}, false, err
+	}
+	if err := s.Storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), ct); err != nil {
+		return Document{}, false, err
+	}
+
+	d := Document{Kind: kind, Filename: cleanFilename(filename), ContentType: ct,
+		Size: int64(len(data)), SHA256: hash, Fields: []string{}, storageKey: key}
+	err = tx.QueryRow(ctx, `
+    INSERT INTO documents (user_id, kind, filename, content_type, size_bytes, sha256, storage_key)
+    VALUES ($1,$2,$3,$4,$5,$6,$7)
+    RETURNING id, created_at
+  `, userID, d.Kind, d.Filename, d.ContentType, d.Size, d.SHA256, key).Scan(&d.ID, &d.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		// a concurrent upload of the same file won; the object key is the same
+		existing, err := s.byHash(ctx, userID, hash)
+		return existing, false, err
+	}
+	if err != nil {
+		return Document{}, false, err
+	}
+	return d, true, tx.Commit(ctx)
+}
+
+// lockKey serialises Upload and Delete of one storage key until tx ends, so
+// a delete can't remove the object a concurrent re-upload has just written.
+func lockKey(ctx context.Context, tx pgx.Tx, key string) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('documents:' || $1))`, key)
+	return err
+}
+
+const docColumns = `d.id, d.kind, d.filename, d.content_type, d.size_bytes, d.sha256, d.storage_key, d.created_at,
+  coalesce((SELECT array_agg(f.field ORDER BY f.field) FROM document_field_links f WHERE f.document_id = d.id), '{}')`
+
+func scanDoc(row pgx.Row) (Document, error) {
+	var d Document
+	err := row.Scan(&d.ID, &d.Kind, &d.Filename, &d.ContentType, &d.Size, &d.SHA256, &d.storageKey, &d.CreatedAt, &d.Fields)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return d, ErrNotFound
+	}
+	return d, err
+}
+
+func (s Service) byHash(ctx context.Context, userID, hash string) (Document, error) {
+	return scanDoc(s.DB.QueryRow(ctx,
+		`SELECT `+docColumns+` FROM documents d WHERE d.user_id::text=$1 AND d.sha256=$2`, userID, hash))
+}
+
+func (s Service) Get(ctx context.Context, userID, id string) (Document, error) {
+	return scanDoc(s.DB.QueryRow(ctx,
+		`SELECT `+docColumns+` FROM documents d WHERE d.user_id::text=$1 AND d.id::text=$2`, userID, id))
+}
+
+func (s Service) List(ctx context.Context, userID string) ([]Document, error) {
+	rows, err := s.DB.Query(ctx,
+		`SELECT `+docColumns+` FROM documents d WHERE d.user_id::text=$1 ORDER BY d.created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Document{}
+	for rows.Next() {
+		d, err := scanDoc(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// Open returns the document and its bytes; the caller closes the reader.
+func (s Service) Open(ctx context.Context, userID, id string) (Document, io.ReadCloser, error) {
+	d, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return d, nil, err
+	}
+	rc, err := s.Storage.Get(ctx, d.storageKey)
+	if errors.Is(err, ErrObjectNotFound) {
+		return d, nil, ErrNotFound
+	}
+	return d, rc, err
+}
+
+// Delete removes the row (and its field links) and then the object, under
+// the key's lock and only if no other row still points at the key. If the
+// object can't be deleted the row stays, so nothing is left dangling.
+func (s Service) Delete(ctx context.Context, userID, id string) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var key string
+	err = tx.QueryRow(ctx,
+		`SELECT storage_key FROM documents WHERE user_id::text=$1 AND id::text=$2`, userID, id,
+	).Scan(&key)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := lockKey(ctx, tx, key); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM documents WHERE user_id::text=$1 AND id::text=$2`, userID, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound // deleted concurrently
+	}
+
+	var referenced bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM documents WHERE storage_key=$1)`, key).Scan(&referenced)
+	if err != nil {
+		return err
+	}
+	if !referenced {
+		if err := s.Storage.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// LinkField marks the document as the proof for a profile field, replacing
+// any document linked to that field before.
+func (s Service) LinkField(ctx context.Context, userID, id, field string) error {
+	if !profile.IsField(field) {
+		var errs validation.Errors
+		errs.Add("field", "unknown", "unknown profile field")
+		return errs.Err()
+	}
+	tag, err := s.DB.Exec(ctx, `
+    INSERT INTO document_field_links (user_id, field, document_id)
+    SELECT user_id, $3, id FROM documents WHERE user_id::text=$1 AND id::text=$2
+    ON CONFLICT (user_id, field) DO UPDATE SET document_id = EXCLUDED.document_id, created_at = now()
+  `, userID, id, field)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s Service) UnlinkField(ctx context.Context, userID, id, field string) error {
+	tag, err := s.DB.Exec(ctx,
+		`DELETE FROM document_field_links WHERE user_id::text=$1 AND document_id::text=$2 AND field=$3`,
+		userID, id, field)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PurgeUser deletes every stored object of the user. The rows go with the
+// user through ON DELETE CASCADE (account.Service.Blobs).
+func (s Service) PurgeUser(ctx context.Context, userID string) error {
+	rows, err := s.DB.Query(ctx, `SELECT storage_key FROM documents WHERE user_id::text=$1`, userID)
+	if err != nil {
+		return err
+	}
+	keys, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := s.Storage.Delete(ctx, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package documents
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+		err  error
+	}{
+		{"pdf", "%PDF-1.7\n...", "application/pdf", nil},
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", "image/png", nil},
+		{"jpeg", "\xff\xd8\xff\xe0\x00\x10JFIF", "image/jpeg", nil},
+		{"html", "<html><script>alert(1)</script>", "", ErrUnsupported},
+		{"text", "just a transcript", "", ErrUnsupported},
+	}
+	for _, tt := range tests {
+		got, err := sniff([]byte(tt.data))
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("%s: sniff = %q, %v; want %q, %v", tt.name, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestReadLimited(t *testing.T) {
+	if _, err := readLimited(strings.NewReader("12345"), 5); err != nil {
+		t.Errorf("at the limit: %v", err)
+	}
+	if _, err := readLimited(strings.NewReader("123456"), 5); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("over the limit: %v, want ErrTooLarge", err)
+	}
+}
+
+func TestCleanFilename(t *testing.T) {
+	tests := map[string]string{
+		"ielts.pdf":                  "ielts.pdf",
+		"../../etc/passwd":           "passwd",
+		`C:\Users\me\transcript.pdf`: "transcript.pdf",
+		"a\"b\r\nc.pdf":              "abc.pdf",
+		"":                           "document",
+	}
+	for in, want := range tests {
+		if got := cleanFilename(in); got != want {
+			t.Errorf("cleanFilename(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDeleteRacingReupload(t *testing.T) {
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		t.Skip("DATABASE_URL not set, skipping database tests")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+	var userID string
+	err = pool.QueryRow(ctx, `INSERT INTO users(email, password_hash) VALUES ($1, '') RETURNING id`,
+		"documents-"+uuid.NewString()[:8]+"@example.com").Scan(&userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Exec(ctx, `DELETE FROM users WHERE id=$1`, userID)
+	s := Service{DB: pool, Storage: LocalStorage{Dir: t.TempDir()}}
+	pdf := "%PDF-1.4\n1 0 obj\n"
+
+	for i := 0; i < 20; i++ {
+		d, _, err := s.Upload(ctx, userID, "transcript", "t.pdf", strings.NewReader(pdf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() { defer wg.Done(); s.Delete(ctx, userID, d.ID) }()
+		go func() { defer wg.Done(); s.Upload(ctx, userID, "transcript", "t.pdf", strings.NewReader(pdf)) }()
+		wg.Wait()
+
+		// whatever won, a remaining row must still have its bytes
+		docs, err := s.List(ctx, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, doc := range docs {
+			_, rc, err := s.Open(ctx, userID, doc.ID)
+			if err != nil {
+				t.Fatalf("round %d: row without object: %v", i, err)
+			}
+			rc.Close()
+			if err := s.Delete(ctx, userID, doc.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package documents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrObjectNotFound is returned by Storage.Get for a missing key.
+var ErrObjectNotFound = errors.New("object not found")
+
+// Storage keeps document bytes; metadata lives in Postgres. Keys are
+// slash-separated ("<user id>/<sha256>").
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object; a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// LocalStorage stores objects as files under Dir.
+type LocalStorage struct {
+	Dir string
+}
+
+func (s LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if key == "" || strings.Contains(key, "..") || clean == "/" {
+		return "", errors.New("invalid storage key")
+	}
+	return filepath.Join(s.Dir, filepath.FromSlash(clean)), nil
+}
+
+// Put writes to a temp file and renames it, so readers never see a partial object.
+func (s LocalStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	return f, err
+}
+
+func (s LocalStorage) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// NewStorage picks the backend by name: "local" (files under dir) or "s3".
+func NewStorage(backend, dir string, s3 S3Storage) (Storage, error) {
+	switch backend {
+	case "", "local":
+		return LocalStorage{Dir: dir}, nil
+	case "s3":
+		if s3.Endpoint == "" || s3.Bucket == "" {
+			return nil, errors.New("s3 storage needs an endpoint and a bucket")
+		}
+		return s3, nil
+	}
+	return nil, fmt.Errorf("unknown document storage %q", backend)
+}
@@ -0,0 +1,46 @@
+package documents
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s := LocalStorage{Dir: t.TempDir()}
+
+	if err := s.Put(ctx, "user/abc", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	rc, err := s.Get(ctx, "user/abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello" {
+		t.Errorf("Get = %q", data)
+	}
+
+	if err := s.Delete(ctx, "user/abc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, "user/abc"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Get after Delete: %v, want ErrObjectNotFound", err)
+	}
+	if err := s.Delete(ctx, "user/abc"); err != nil {
+		t.Errorf("Delete of a missing key: %v", err)
+	}
+}
+
+func TestLocalStorageRejectsTraversal(t *testing.T) {
+	s := LocalStorage{Dir: t.TempDir()}
+	for _, key := range []string{"", "/", "../etc/passwd", "user/../../x"} {
+		if err := s.Put(context.Background(), key, strings.NewReader("x"), 1, ""); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package fx
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var codeRe = regexp.MustCompile(`^[A-Z]{3}$`)
+
+func checkRate(r Rate) error {
+	if !codeRe.MatchString(r.Currency) {
+		return fmt.Errorf("bad currency code %q", r.Currency)
+	}
+	if r.PerEUR <= 0 {
+		return fmt.Errorf("%s: rate must be positive", r.Currency)
+	}
+	return nil
+}
+
+// ParseCSV reads "date,currency,per_eur" rows (header optional), e.g.
+//
+//	date,currency,per_eur
+//	2024-06-03,USD,1.0842
+//	2024-06-03,KZT,486.37
+//
+// EUR rows are skipped: EUR is the base.
+func ParseCSV(r io.Reader) ([]Rate, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 3
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	var out []Rate
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(rec[0], "date") {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(rec[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad date %q", line, rec[0])
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rec[2]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad rate %q", line, rec[2])
+		}
+		row := Rate{Currency: Normalize(rec[1]), Date: date, PerEUR: rate}
+		if row.Currency == "EUR" {
+			continue
+		}
+		if err := checkRate(row); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		out = append(out, row)
+	}
+	return out, nil
+}
+
+// ecbEnvelope is the eurofxref XML format (daily, 90-day and history files):
+// <Cube><Cube time="2024-06-03"><Cube currency="USD" rate="1.0842"/>...
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECB reads an ECB eurofxref XML file.
+func ParseECB(r io.Reader) ([]Rate, error) {
+	var env ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&env); err != nil {
+		return nil, fmt.Errorf("ecb xml: %w", err)
+	}
+	var out []Rate
+	for _, day := range env.Days {
+		date, err := time.Parse("2006-01-02", day.Time)
+		if err != nil {
+			return nil, fmt.Errorf("ecb xml: bad time %q", day.Time)
+		}
+		for _, c := range day.Rates {
+			rate, err := strconv.ParseFloat(c.Rate, 64)
+			if err != nil {
+				return nil, fmt.Errorf("ecb xml: %s on %s: bad rate %q", c.Currency, day.Time, c.Rate)
+			}
+			row := Rate{Currency: Normalize(c.Currency), Date: date, PerEUR: rate}
+			if err := checkRate(row); err != nil {
+				return nil, fmt.Errorf("ecb xml: %w", err)
+			}
+			out = append(out, row)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("ecb xml: no rates found")
+	}
+	return out, nil
+}
@@ -0,0 +1,63 @@
+package fx
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	rates, err := ParseCSV(strings.NewReader(`date,currency,per_eur
+# comment
+2024-06-03,USD,1.0842
+2024-06-03, kzt ,486.37
+2024-06-03,EUR,1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 2 || rates[1].Currency != "KZT" || rates[1].PerEUR != 486.37 {
+		t.Errorf("got %+v", rates)
+	}
+
+	for _, bad := range []string{
+		"2024-13-01,USD,1.08\n",
+		"2024-06-03,US,1.08\n",
+		"2024-06-03,USD,-1\n",
+		"2024-06-03,USD\n",
+	} {
+		if _, err := ParseCSV(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseCSV(%q) accepted", bad)
+		}
+	}
+}
+
+func TestParseECB(t *testing.T) {
+	const doc = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+  <gesmes:subject>Reference rates</gesmes:subject>
+  <Cube>
+    <Cube time="2024-06-03">
+      <Cube currency="USD" rate="1.0842"/>
+      <Cube currency="JPY" rate="169.85"/>
+    </Cube>
+    <Cube time="2024-05-31">
+      <Cube currency="USD" rate="1.0848"/>
+    </Cube>
+  </Cube>
+</gesmes:Envelope>`
+	rates, err := ParseECB(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 3 {
+		t.Fatalf("got %d rates, want 3", len(rates))
+	}
+	latest := NewRates(rates)
+	if latest.PerEUR["USD"] != 1.0842 || latest.Dates["USD"].Format("2006-01-02") != "2024-06-03" {
+		t.Errorf("latest USD = %v on %v", latest.PerEUR["USD"], latest.Dates["USD"])
+	}
+
+	if _, err := ParseECB(strings.NewReader("<Envelope/>")); err == nil {
+		t.Error("empty document accepted")
+	}
+}
@@ -0,0 +1,80 @@
+// Package fx converts money between currencies with dated exchange rates
+// from the fx_rates table. Rates follow the ECB convention: units of the
+// currency per 1 EUR, so any pair converts through EUR. The same math is
+// available in SQL as fx_convert() for filters and sorting.
+package fx
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+// ErrNoRate: there is no rate for one of the currencies.
+var ErrNoRate = errors.New("no exchange rate for currency")
+
+// Rate is one fx_rates row.
+type Rate struct {
+	Currency string
+	Date     time.Time
+	PerEUR   float64
+}
+
+// Rates is a snapshot of the latest rate per currency.
+type Rates struct {
+	PerEUR map[string]float64   // EUR is implied (1)
+	Dates  map[string]time.Time // date of each rate
+}
+
+// NewRates builds a snapshot, keeping the newest rate of each currency.
+func NewRates(rows []Rate) Rates {
+	r := Rates{PerEUR: map[string]float64{}, Dates: map[string]time.Time{}}
+	for _, row := range rows {
+		cur := Normalize(row.Currency)
+		if d, ok := r.Dates[cur]; ok && d.After(row.Date) {
+			continue
+		}
+		r.PerEUR[cur] = row.PerEUR
+		r.Dates[cur] = row.Date
+	}
+	return r
+}
+
+// Normalize upper-cases and trims a currency code.
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (r Rates) perEUR(code string) (float64, bool) {
+	if code == "EUR" {
+		return 1, true
+	}
+	v, ok := r.PerEUR[code]
+	return v, ok && v > 0
+}
+
+// Has reports whether amounts in code can be converted.
+func (r Rates) Has(code string) bool {
+	_, ok := r.perEUR(Normalize(code))
+	return ok
+}
+
+// Convert converts amount from one currency to another.
+func (r Rates) Convert(amount float64, from, to string) (float64, error) {
+	from, to = Normalize(from), Normalize(to)
+	if from == to {
+		return amount, nil
+	}
+	f, ok := r.perEUR(from)
+	if !ok {
+		return 0, ErrNoRate
+	}
+	t, ok := r.perEUR(to)
+	if !ok {
+		return 0, ErrNoRate
+	}
+	return round2(amount / f * t), nil
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
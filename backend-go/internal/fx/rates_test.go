@@ -0,0 +1,40 @@
+package fx
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRatesConvert(t *testing.T) {
+	d1 := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	d0 := d1.AddDate(0, 0, -1)
+	r := NewRates([]Rate{
+		{Currency: "USD", Date: d1, PerEUR: 1.08},
+		{Currency: "USD", Date: d0, PerEUR: 2}, // older, ignored
+		{Currency: "kzt", Date: d1, PerEUR: 486},
+	})
+
+	tests := []struct {
+		amount   float64
+		from, to string
+		want     float64
+		err      error
+	}{
+		{100, "EUR", "USD", 108, nil},
+		{108, "USD", "EUR", 100, nil},
+		{1080, "USD", "KZT", 486000, nil},
+		{5, "usd", "USD", 5, nil},
+		{5, "GBP", "USD", 0, ErrNoRate},
+		{5, "USD", "GBP", 0, ErrNoRate},
+	}
+	for _, tt := range tests {
+		got, err := r.Convert(tt.amount, tt.from, tt.to)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("Convert(%v %s -> %s) = %v, %v; want %v, %v", tt.amount, tt.from, tt.to, got, err, tt.want, tt.err)
+		}
+	}
+	if !r.Has("EUR") || !r.Has("kzt") || r.Has("GBP") {
+		t.Error("Has: EUR and KZT known, GBP not")
+	}
+}
@@ -0,0 +1,61 @@
+package fx
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Service struct {
+	DB *pgxpool.Pool
+}
+
+// Latest loads the newest rate of every currency as of today.
+func (s Service) Latest(ctx context.Context) (Rates, error) {
+	return s.AsOf(ctx, time.Now())
+}
+
+// AsOf loads the newest rate of every currency on or before date.
+func (s Service) AsOf(ctx context.Context, date time.Time) (Rates, error) {
+	rows, err := s.DB.Query(ctx, `
+    SELECT DISTINCT ON (currency) currency, rate_date, per_eur::float8
+    FROM fx_rates WHERE rate_date <= $1
+    ORDER BY currency, rate_date DESC
+  `, date)
+	if err != nil {
+		return Rates{}, err
+	}
+	list, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Rate, error) {
+		var r Rate
+		err := row.Scan(&r.Currency, &r.Date, &r.PerEUR)
+		return r, err
+	})
+	if err != nil {
+		return Rates{}, err
+	}
+	return NewRates(list), nil
+}
+
+// Store upserts rates; re-importing a file overwrites the same dates.
+func (s Service) Store(ctx context.Context, rates []Rate, source string) (int, error) {
+	batch := &pgx.Batch{}
+	for _, r := range rates {
+		batch.Queue(`
+      INSERT INTO fx_rates (currency, rate_date, per_eur, source)
+      VALUES ($1,$2,$3,$4)
+      ON CONFLICT (currency, rate_date)
+      DO UPDATE SET per_eur = EXCLUDED.per_eur, source = EXCLUDED.source, imported_at = now()
+    `, r.Currency, r.Date, r.PerEUR, source)
+	}
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, err
+	}
+	return len(rates), tx.Commit(ctx)
+}
@@ -1,13 +1,18 @@
 package http
 
 import (
+	"net"
+
 	"unichance-backend-go/internal/universities"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	echoMw "github.com/labstack/echo/v4/middleware"
 
+	"unichance-backend-go/internal/account"
 	"unichance-backend-go/internal/auth"
+	"unichance-backend-go/internal/counselor"
+	"unichance-backend-go/internal/documents"
 	appMw "unichance-backend-go/internal/middleware"
 	"unichance-backend-go/internal/profile"
 	"unichance-backend-go/internal/programs"
@@ -15,15 +20,28 @@ import (
 
 type Deps struct {
 	AuthHandler         auth.Handler
+	AccountHandler      account.Handler
+	CounselorHandler    counselor.Handler
+	DocumentsHandler    documents.Handler
 	ProgramsHandler     programs.Handler
 	ProfileHandler      profile.Handler
 	UniversitiesHandler universities.Handler
 	LLMHandler          interface{}
-	JwtSecret           string
+	TokenKeys           appMw.TokenKeys
+	Revocation          appMw.RevocationChecker // jti denylist; nil disables the check
+	CounselorAccess     appMw.CounselorAccess   // counselor -> student links + access log
+
+	EmailVerifier        appMw.EmailVerifier
+	RequireVerifiedEmail bool // gate score-saving endpoints on a verified email
+
+	// proxies whose X-Forwarded-For is trusted for c.RealIP(); none means the
+	// TCP peer address is the client IP
+	TrustedProxies []*net.IPNet
 }
 
 func NewRouter(d Deps) *echo.Echo {
 	e := echo.New()
+	e.IPExtractor = ipExtractor(d.TrustedProxies)
 
 	e.Use(echoMw.Logger())
 	e.Use(echoMw.Recover())
@@ -31,47 +49,162 @@ func NewRouter(d Deps) *echo.Echo {
 		AllowOrigins: []string{"http://localhost:5173"},
 		AllowHeaders: []string{"Authorization", "Content-Type"},
 		AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		// the OIDC state cookie travels with the callback request
+		AllowCredentials: true,
 	}))
 
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"http://localhost:5173", "http://127.0.0.1:5173"},
-		AllowMethods: []string{echo.GET, echo.POST, echo.PUT, echo.DELETE, echo.OPTIONS},
-		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowOrigins:     []string{"http://localhost:5173", "http://127.0.0.1:5173"},
+		AllowMethods:     []string{echo.GET, echo.POST, echo.PUT, echo.PATCH, echo.DELETE, echo.OPTIONS},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowCredentials: true,
 	}))
 
 	e.GET("/health", func(c echo.Context) error { return c.String(200, "ok") })
+	e.GET("/.well-known/jwks.json", d.AuthHandler.JWKS)
+
+	requireAuth := appMw.RequireAuth(d.TokenKeys, d.Revocation)
+
+	// score-saving and outcome-reporting endpoints: optionally verified emails only
+	var verifiedEmail []echo.MiddlewareFunc
+	if d.RequireVerifiedEmail && d.EmailVerifier != nil {
+		verifiedEmail = append(verifiedEmail, appMw.RequireVerifiedEmail(d.EmailVerifier))
+	}
+	saveScore := append([]echo.MiddlewareFunc{requireAuth}, verifiedEmail...)
 
 	// auth (public)
 	e.POST("/auth/register", d.AuthHandler.Register)
 	e.POST("/auth/login", d.AuthHandler.Login)
+	e.POST("/auth/refresh", d.AuthHandler.Refresh)
+	e.POST("/auth/password/forgot", d.AuthHandler.ForgotPassword)
+	e.POST("/auth/password/reset", d.AuthHandler.ResetPassword)
+	e.POST("/auth/verify", d.AuthHandler.VerifyEmail)
+	e.GET("/auth/oidc/:provider/start", d.AuthHandler.OIDCStart)
+	e.POST("/auth/oidc/:provider/callback", d.AuthHandler.OIDCCallback)
 
 	// auth/me (protected)
-	e.GET("/auth/me", d.AuthHandler.Me, appMw.RequireAuth(d.JwtSecret))
+	e.GET("/auth/me", d.AuthHandler.Me, requireAuth)
+	e.POST("/auth/logout", d.AuthHandler.Logout, requireAuth)
+	e.POST("/auth/verify/resend", d.AuthHandler.ResendVerification, requireAuth)
+	e.GET("/auth/sessions", d.AuthHandler.Sessions, requireAuth)
+	e.DELETE("/auth/sessions/:id", d.AuthHandler.RevokeSession, requireAuth)
+	e.DELETE("/auth/sessions", d.AuthHandler.RevokeAllSessions, requireAuth)
 
 	// programs (public)
 	e.GET("/programs", d.ProgramsHandler.List)
 	// alias for frontend compatibility
 	e.GET("/programs/search", d.ProgramsHandler.List)
+	e.GET("/programs/:id", d.ProgramsHandler.Get)
+
+	// scoring weights in use (public, read-only)
+	e.GET("/scoring/config", d.ProgramsHandler.ScoringConfig)
 
 	// smart-search (protected)
-	e.GET("/programs/smart-search", d.ProgramsHandler.SmartSearch, appMw.RequireAuth(d.JwtSecret))
+	e.GET("/programs/smart-search", d.ProgramsHandler.SmartSearch, requireAuth)
+	e.GET("/programs/smart-search/compare", d.ProgramsHandler.CompareScenario, requireAuth)
+
+	// personal data: export + account deletion (protected)
+	e.GET("/me/export", d.AccountHandler.Export, requireAuth)
+	e.DELETE("/me", d.AccountHandler.Delete, requireAuth)
+
+	e.GET("/grading-systems", d.ProfileHandler.GradingSystems)
 
 	// profile (protected)
-	e.GET("/profile/me", d.ProfileHandler.GetMe, appMw.RequireAuth(d.JwtSecret))
-	e.POST("/profile/me", d.ProfileHandler.UpsertMe, appMw.RequireAuth(d.JwtSecret))
-	e.POST("/score", d.ProfileHandler.ScoreProgram, appMw.RequireAuth(d.JwtSecret))
+	e.GET("/profile/me", d.ProfileHandler.GetMe, requireAuth)
+	e.POST("/profile/me", d.ProfileHandler.UpsertMe, requireAuth)
+	e.PATCH("/profile/me", d.ProfileHandler.PatchMe, requireAuth)
+	e.GET("/profile/me/history", d.ProfileHandler.History, requireAuth)
+	e.GET("/profile/me/diff", d.ProfileHandler.Diff, requireAuth)
+	e.GET("/profile/me/completeness", d.ProfileHandler.Completeness, requireAuth)
+	e.GET("/profile/me/achievements", d.ProfileHandler.ListAchievements, requireAuth)
+	e.POST("/profile/me/achievements", d.ProfileHandler.CreateAchievement, requireAuth)
+	e.PUT("/profile/me/achievements/:id", d.ProfileHandler.UpdateAchievement, requireAuth)
+	e.DELETE("/profile/me/achievements/:id", d.ProfileHandler.DeleteAchievement, requireAuth)
+	e.GET("/profile/me/scenarios", d.ProfileHandler.ListScenarios, requireAuth)
+	e.POST("/profile/me/scenarios", d.ProfileHandler.CreateScenario, requireAuth)
+	e.GET("/profile/me/scenarios/:id", d.ProfileHandler.GetScenario, requireAuth)
+	e.PUT("/profile/me/scenarios/:id", d.ProfileHandler.UpdateScenario, requireAuth)
+	e.DELETE("/profile/me/scenarios/:id", d.ProfileHandler.DeleteScenario, requireAuth)
+	e.GET("/profile/me/outcomes", d.ProfileHandler.ListOutcomes, requireAuth)
+	e.POST("/profile/me/outcomes", d.ProfileHandler.ReportOutcome, saveScore...)
+	e.DELETE("/profile/me/outcomes/:id", d.ProfileHandler.DeleteOutcome, requireAuth)
+	e.POST("/score", d.ProfileHandler.ScoreProgram, saveScore...)
+
+	// document vault (protected)
+	e.POST("/documents", d.DocumentsHandler.Upload, requireAuth)
+	e.GET("/documents", d.DocumentsHandler.List, requireAuth)
+	e.GET("/documents/:id", d.DocumentsHandler.Get, requireAuth)
+	e.GET("/documents/:id/download", d.DocumentsHandler.Download, requireAuth)
+	e.DELETE("/documents/:id", d.DocumentsHandler.Delete, requireAuth)
+	e.PUT("/documents/:id/fields/:field", d.DocumentsHandler.LinkField, requireAuth)
+	e.DELETE("/documents/:id/fields/:field", d.DocumentsHandler.UnlinkField, requireAuth)
 
 	// LLM proxy (protected)
 	if d.LLMHandler != nil {
 		// use reflection to call method if present
 		if h, ok := d.LLMHandler.(interface{ ImprovementTips(echo.Context) error }); ok {
-			e.POST("/api/llm/improvement-tips", h.ImprovementTips, appMw.RequireAuth(d.JwtSecret))
+			e.POST("/api/llm/improvement-tips", h.ImprovementTips, requireAuth)
 		}
 	}
 
+	// student side of counselor links (protected)
+	e.GET("/me/counselors", d.CounselorHandler.MyCounselors, requireAuth)
+	e.GET("/me/counselors/access-log", d.CounselorHandler.AccessLog, requireAuth)
+	e.POST("/me/counselors/:id/accept", d.CounselorHandler.Accept, requireAuth)
+	e.POST("/me/counselors/:id/decline", d.CounselorHandler.Decline, requireAuth)
+	e.DELETE("/me/counselors/:id", d.CounselorHandler.Revoke, requireAuth)
+	e.GET("/profile/me/scores", d.ProfileHandler.Scores, requireAuth)
+
+	// counselor (protected + role)
+	cg := e.Group("/counselor", requireAuth, appMw.RequireRole(appMw.RoleCounselor))
+	cg.POST("/invitations", d.CounselorHandler.Invite)
+	cg.GET("/invitations", d.CounselorHandler.Invitations)
+	cg.GET("/students", d.CounselorHandler.Students)
+	cg.DELETE("/links/:id", d.CounselorHandler.EndLink)
+
+	// a linked student's data: same handlers as /profile/me etc. with the
+	// student as subject; read links GET only, every request is logged
+	if d.CounselorAccess != nil {
+		sg := cg.Group("/students/:student_id", appMw.ActAsStudent(d.CounselorAccess))
+		sg.GET("/profile", d.ProfileHandler.GetMe)
+		sg.POST("/profile", d.ProfileHandler.UpsertMe)
+		sg.PATCH("/profile", d.ProfileHandler.PatchMe)
+		sg.GET("/profile/history", d.ProfileHandler.History)
+		sg.GET("/profile/completeness", d.ProfileHandler.Completeness)
+		sg.GET("/profile/achievements", d.ProfileHandler.ListAchievements)
+		sg.POST("/profile/achievements", d.ProfileHandler.CreateAchievement)
+		sg.PUT("/profile/achievements/:id", d.ProfileHandler.UpdateAchievement)
+		sg.DELETE("/profile/achievements/:id", d.ProfileHandler.DeleteAchievement)
+		sg.GET("/scenarios", d.ProfileHandler.ListScenarios)
+		sg.GET("/outcomes", d.ProfileHandler.ListOutcomes)
+		sg.GET("/scores", d.ProfileHandler.Scores)
+		sg.POST("/score", d.ProfileHandler.ScoreProgram, verifiedEmail...)
+		sg.GET("/smart-search", d.ProgramsHandler.SmartSearch)
+		sg.GET("/smart-search/compare", d.ProgramsHandler.CompareScenario)
+		sg.GET("/documents", d.DocumentsHandler.List)
+		sg.GET("/documents/:id/download", d.DocumentsHandler.Download)
+	}
+
+	// admin (protected + role)
+	admin := e.Group("/admin", requireAuth, appMw.RequireRole(appMw.RoleAdmin))
+	admin.PUT("/users/:id/role", d.AuthHandler.SetRole)
+
 	// universities (public)
 	e.GET("/universities/:id", d.UniversitiesHandler.GetByID)
 	e.GET("/universities", d.UniversitiesHandler.List) // ← добавить эту строку
 
 	return e
 }
+
+// ipExtractor decides what c.RealIP() returns. Login throttling keys on it, so
+// X-Forwarded-For is only read when the request came through a listed proxy.
+func ipExtractor(trusted []*net.IPNet) echo.IPExtractor {
+	if len(trusted) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	opts := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, r := range trusted {
+		opts = append(opts, echo.TrustIPRange(r))
+	}
+	return echo.ExtractIPFromXFFHeader(opts...)
+}
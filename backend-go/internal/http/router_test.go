@@ -0,0 +1,29 @@
+package http
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPExtractor(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	cases := []struct {
+		name    string
+		trusted []*net.IPNet
+		peer    string
+		want    string
+	}{
+		{"no proxies: header ignored", nil, "203.0.113.7:4000", "203.0.113.7"},
+		{"untrusted peer: header ignored", []*net.IPNet{proxies}, "203.0.113.7:4000", "203.0.113.7"},
+		{"trusted proxy: client from header", []*net.IPNet{proxies}, "10.1.2.3:4000", "198.51.100.9"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("POST", "/auth/login", nil)
+		req.RemoteAddr = c.peer
+		req.Header.Set("X-Forwarded-For", "198.51.100.9")
+		if got := ipExtractor(c.trusted)(req); got != c.want {
+			t.Errorf("%s: %s, want %s", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"time"
+)
+
+// JWK is the public half of an asymmetric key (RFC 7517). HS256 secrets are
+// never published.
+type JWK struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys that may currently verify a token: the current
+// key and retired keys within the grace window.
+func (r *Ring) JWKS() JWKSet {
+	now := time.Now()
+	r.mu.RLock()
+	keys := append([]*Key{r.current}, r.retired...)
+	grace := r.opts.Grace
+	r.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, k := range keys {
+		if k == nil || k.Alg == AlgHS256 {
+			continue
+		}
+		if k.RetiredAt != nil && !now.Before(k.RetiredAt.Add(grace)) {
+			continue
+		}
+		switch pub := k.Private.Public().(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kid: k.ID, Kty: "RSA", Alg: k.Alg, Use: "sig",
+				N: b64(pub.N.Bytes()),
+				E: b64(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				Kid: k.ID, Kty: "OKP", Alg: k.Alg, Use: "sig",
+				Crv: "Ed25519", X: b64(pub),
+			})
+		}
+	}
+	return set
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
@@ -0,0 +1,373 @@
+// Package jwtkeys holds the signing keys for UniChance access tokens. A Ring
+// signs with its current key (kid in the JWT header) and verifies against the
+// current key plus retired keys still inside the grace window, so rotation
+// never invalidates tokens that are in flight.
+package jwtkeys
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+
+	// LegacyKID verifies tokens minted before kid headers existed (plain JWT_SECRET).
+	LegacyKID = "legacy"
+)
+
+type Key struct {
+	ID        string
+	Alg       string
+	Secret    []byte        // HS256
+	Private   crypto.Signer // RS256: *rsa.PrivateKey, EdDSA: ed25519.PrivateKey
+	CreatedAt time.Time
+	RetiredAt *time.Time // stopped signing; still verifies until RetiredAt+grace
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	switch k.Alg {
+	case AlgRS256:
+		return jwt.SigningMethodRS256
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodHS256
+}
+
+func (k *Key) signingKey() any {
+	if k.Alg == AlgHS256 {
+		return k.Secret
+	}
+	return k.Private
+}
+
+func (k *Key) verifyKey() any {
+	if k.Alg == AlgHS256 {
+		return k.Secret
+	}
+	return k.Private.Public()
+}
+
+// GenerateKey creates a fresh key for alg with a random kid.
+func GenerateKey(alg string) (*Key, error) {
+	kidBytes := make([]byte, 8)
+	if _, err := rand.Read(kidBytes); err != nil {
+		return nil, err
+	}
+	k := &Key{
+		ID:        base64.RawURLEncoding.EncodeToString(kidBytes),
+		Alg:       alg,
+		CreatedAt: time.Now(),
+	}
+	switch alg {
+	case AlgHS256:
+		k.Secret = make([]byte, 32)
+		if _, err := rand.Read(k.Secret); err != nil {
+			return nil, err
+		}
+	case AlgRS256:
+		pk, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		k.Private = pk
+	case AlgEdDSA:
+		_, pk, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		k.Private = pk
+	default:
+		return nil, fmt.Errorf("jwtkeys: unsupported alg %q", alg)
+	}
+	return k, nil
+}
+
+type Options struct {
+	Alg   string        // algorithm for newly generated keys (default HS256)
+	Grace time.Duration // how long retired keys keep verifying (default 24h)
+	// LegacySecret (JWT_SECRET) verifies kid-less tokens and, without a Store,
+	// is the only signing key. Once another key signs, legacy tokens only
+	// verify for LegacyGrace (default Grace) after that switch.
+	LegacySecret string
+	LegacyGrace  time.Duration
+	Store        Store // nil = keys live in memory only
+}
+
+// missReloadEvery limits how often an unknown kid makes the ring re-read the
+// store: a key rotated by another instance shows up at once, a flood of
+// forged kids costs at most one query per interval.
+const missReloadEvery = 10 * time.Second
+
+type Ring struct {
+	opts Options
+
+	mu          sync.RWMutex
+	current     *Key
+	retired     []*Key
+	legacy      *Key
+	legacyUntil time.Time // zero while the legacy secret is the signing key
+
+	missMu         sync.Mutex
+	lastMissReload time.Time
+}
+
+// New builds a ring. With a Store, keys are loaded from it (one is generated if
+// it is empty); without one, the legacy secret signs as kid "legacy".
+func New(ctx context.Context, opts Options) (*Ring, error) {
+	if opts.Alg == "" {
+		opts.Alg = AlgHS256
+	}
+	if opts.Grace <= 0 {
+		opts.Grace = 24 * time.Hour
+	}
+	if opts.LegacyGrace <= 0 {
+		opts.LegacyGrace = opts.Grace
+	}
+	r := &Ring{opts: opts}
+	if opts.LegacySecret != "" {
+		r.legacy = &Key{ID: LegacyKID, Alg: AlgHS256, Secret: []byte(opts.LegacySecret)}
+	}
+
+	if opts.Store == nil {
+		if r.legacy == nil {
+			return nil, errors.New("jwtkeys: need a store or a legacy secret")
+		}
+		if opts.Alg != AlgHS256 {
+			k, err := GenerateKey(opts.Alg)
+			if err != nil {
+				return nil, err
+			}
+			r.current = k
+			r.legacyUntil = time.Now().Add(opts.LegacyGrace)
+		} else {
+			r.current = r.legacy
+		}
+		return r, nil
+	}
+
+	if err := r.Reload(ctx); err != nil {
+		return nil, err
+	}
+	if r.current == nil {
+		if err := r.Rotate(ctx); err != nil {
+			return nil, err
+		}
+	}
+	first, err := opts.Store.FirstCreated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.legacyUntil = first.Add(opts.LegacyGrace)
+	return r, nil
+}
+
+// Sign sets kid and signs claims with the current key.
+func (r *Ring) Sign(claims jwt.Claims) (string, error) {
+	r.mu.RLock()
+	k := r.current
+	r.mu.RUnlock()
+
+	t := jwt.NewWithClaims(k.method(), claims)
+	t.Header["kid"] = k.ID
+	return t.SignedString(k.signingKey())
+}
+
+// Keyfunc resolves the verification key by kid and refuses any alg other than
+// the one the key was created for (no HS/RS confusion). An unknown kid may be
+// a key another instance just rotated in, so it triggers a (rate-limited)
+// Reload before being rejected.
+func (r *Ring) Keyfunc(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	k := r.lookup(kid, time.Now())
+	if k == nil && r.reloadOnMiss(kid) {
+		k = r.lookup(kid, time.Now())
+	}
+	if k == nil {
+		return nil, fmt.Errorf("jwtkeys: unknown kid %q", kid)
+	}
+	if t.Method.Alg() != k.Alg {
+		return nil, fmt.Errorf("jwtkeys: alg %s not allowed for kid %q", t.Method.Alg(), kid)
+	}
+	return k.verifyKey(), nil
+}
+
+// ValidMethods is passed to jwt.WithValidMethods.
+func (r *Ring) ValidMethods() []string {
+	return []string{AlgHS256, AlgRS256, AlgEdDSA}
+}
+
+func (r *Ring) lookup(kid string, now time.Time) *Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if kid == "" || kid == LegacyKID {
+		if r.current == r.legacy || now.Before(r.legacyUntil) {
+			return r.legacy
+		}
+		return nil
+	}
+	if r.current != nil && r.current.ID == kid {
+		return r.current
+	}
+	for _, k := range r.retired {
+		if k.ID == kid && k.RetiredAt != nil && now.Before(k.RetiredAt.Add(r.opts.Grace)) {
+			return k
+		}
+	}
+	return nil
+}
+
+// Rotate generates a new current key and retires the old one.
+func (r *Ring) Rotate(ctx context.Context) error {
+	k, err := GenerateKey(r.opts.Alg)
+	if err != nil {
+		return err
+	}
+	if r.opts.Store != nil {
+		if err := r.opts.Store.Rotate(ctx, k); err != nil {
+			return err
+		}
+		return r.Reload(ctx)
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.current != nil && r.current != r.legacy {
+		r.current.RetiredAt = &now
+		r.retired = append(r.retired, r.current)
+	}
+	if r.current == r.legacy {
+		r.legacyUntil = now.Add(r.opts.LegacyGrace)
+	}
+	r.current = k
+	r.prune(now)
+	return nil
+}
+
+// RotateIfDue rotates when the current key is older than every. With a Store
+// the check runs under a lock there, so several API instances rotate once.
+func (r *Ring) RotateIfDue(ctx context.Context, every time.Duration) error {
+	if r.opts.Store != nil {
+		k, err := GenerateKey(r.opts.Alg)
+		if err != nil {
+			return err
+		}
+		if err := r.opts.Store.RotateIfOlder(ctx, k, every); err != nil {
+			return err
+		}
+		return r.Reload(ctx)
+	}
+	r.mu.RLock()
+	due := r.current == r.legacy || time.Since(r.current.CreatedAt) >= every
+	r.mu.RUnlock()
+	if !due {
+		return nil
+	}
+	return r.Rotate(ctx)
+}
+
+// Reload replaces the in-memory keys with the store's view.
+func (r *Ring) Reload(ctx context.Context) error {
+	if r.opts.Store == nil {
+		return nil
+	}
+	keys, err := r.opts.Store.Load(ctx, time.Now().Add(-r.opts.Grace))
+	if err != nil {
+		return err
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+
+	var current *Key
+	var retired []*Key
+	for _, k := range keys {
+		if k.RetiredAt == nil && current == nil {
+			current = k
+			continue
+		}
+		if k.RetiredAt == nil {
+			// a newer key exists; treat older active ones as retired now
+			now := time.Now()
+			k.RetiredAt = &now
+		}
+		retired = append(retired, k)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if current != nil {
+		r.current = current
+	}
+	r.retired = retired
+	return nil
+}
+
+// reloadOnMiss reloads the store for an unknown kid, at most once per
+// missReloadEvery; it reports whether it did.
+func (r *Ring) reloadOnMiss(kid string) bool {
+	if r.opts.Store == nil || kid == "" || kid == LegacyKID {
+		return false
+	}
+	r.missMu.Lock()
+	if time.Since(r.lastMissReload) < missReloadEvery {
+		r.missMu.Unlock()
+		return false
+	}
+	r.lastMissReload = time.Now()
+	r.missMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return r.Reload(ctx) == nil
+}
+
+// StartRotation rotates on schedule until ctx is done. Errors are reported via
+// logf and retried on the next tick.
+func (r *Ring) StartRotation(ctx context.Context, every time.Duration, logf func(string, ...any)) {
+	tick := every / 10
+	if tick > time.Hour {
+		tick = time.Hour
+	}
+	if tick < time.Second {
+		tick = time.Second
+	}
+	go func() {
+		t := time.NewTicker(tick)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if err := r.RotateIfDue(ctx, every); err != nil && logf != nil {
+					logf("jwtkeys: rotation failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+func (r *Ring) prune(now time.Time) {
+	kept := r.retired[:0]
+	for _, k := range r.retired {
+		if k.RetiredAt != nil && now.Before(k.RetiredAt.Add(r.opts.Grace)) {
+			kept = append(kept, k)
+		}
+	}
+	r.retired = kept
+}
@@ -0,0 +1,226 @@
+package jwtkeys
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func parse(r *Ring, tok string) error {
+	_, err := jwt.Parse(tok, r.Keyfunc, jwt.WithValidMethods(r.ValidMethods()))
+	return err
+}
+
+func claims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "u1", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func TestLegacySecretOnly(t *testing.T) {
+	r, err := New(context.Background(), Options{LegacySecret: "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := r.Sign(claims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parse(r, tok); err != nil {
+		t.Fatalf("own token rejected: %v", err)
+	}
+
+	// tokens minted before kid headers existed
+	old, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims()).SignedString([]byte("s3cret"))
+	if err := parse(r, old); err != nil {
+		t.Fatalf("kid-less legacy token rejected: %v", err)
+	}
+	if len(r.JWKS().Keys) != 0 {
+		t.Fatal("HS256 secrets must not be published")
+	}
+}
+
+func TestRotationGraceWindow(t *testing.T) {
+	for _, alg := range []string{AlgHS256, AlgRS256, AlgEdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			ctx := context.Background()
+			r, err := New(ctx, Options{Alg: alg, Grace: time.Hour, LegacySecret: "s3cret"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Rotate(ctx); err != nil {
+				t.Fatal(err)
+			}
+			before, _ := r.Sign(claims())
+			if err := r.Rotate(ctx); err != nil {
+				t.Fatal(err)
+			}
+			after, _ := r.Sign(claims())
+
+			if err := parse(r, before); err != nil {
+				t.Fatalf("token from previous key rejected inside grace: %v", err)
+			}
+			if err := parse(r, after); err != nil {
+				t.Fatalf("token from current key rejected: %v", err)
+			}
+
+			// push the retired key past the grace window
+			past := time.Now().Add(-2 * time.Hour)
+			r.mu.Lock()
+			for _, k := range r.retired {
+				k.RetiredAt = &past
+			}
+			r.mu.Unlock()
+			if err := parse(r, before); err == nil {
+				t.Fatal("token from expired key accepted")
+			}
+
+			wantJWKS := 1
+			if alg == AlgHS256 {
+				wantJWKS = 0
+			}
+			if n := len(r.JWKS().Keys); n != wantJWKS {
+				t.Fatalf("JWKS has %d keys, want %d", n, wantJWKS)
+			}
+		})
+	}
+}
+
+func TestAlgConfusionRejected(t *testing.T) {
+	r, err := New(context.Background(), Options{Alg: AlgRS256, LegacySecret: "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// HS256 token carrying the RSA kid, "signed" with something public-ish
+	tk := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	tk.Header["kid"] = r.current.ID
+	forged, _ := tk.SignedString([]byte("s3cret"))
+	if err := parse(r, forged); err == nil {
+		t.Fatal("HS256 token accepted for RS256 kid")
+	}
+
+	tk = jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	tk.Header["kid"] = "nope"
+	unknown, _ := tk.SignedString([]byte("s3cret"))
+	if err := parse(r, unknown); err == nil {
+		t.Fatal("unknown kid accepted")
+	}
+}
+
+// memStore is a Store shared by several rings, like jwt_signing_keys is by
+// several API instances.
+type memStore struct {
+	mu   sync.Mutex
+	keys []*Key
+}
+
+func (s *memStore) Load(ctx context.Context, retiredSince time.Time) ([]*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*Key
+	for _, k := range s.keys {
+		if k.RetiredAt == nil || k.RetiredAt.After(retiredSince) {
+			c := *k
+			out = append(out, &c)
+		}
+	}
+	return out, nil
+}
+
+func (s *memStore) Rotate(ctx context.Context, k *Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, old := range s.keys {
+		if old.RetiredAt == nil {
+			old.RetiredAt = &now
+		}
+	}
+	s.keys = append(s.keys, k)
+	return nil
+}
+
+func (s *memStore) RotateIfOlder(ctx context.Context, k *Key, age time.Duration) error {
+	return s.Rotate(ctx, k)
+}
+
+func (s *memStore) FirstCreated(ctx context.Context) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var first time.Time
+	for _, k := range s.keys {
+		if first.IsZero() || k.CreatedAt.Before(first) {
+			first = k.CreatedAt
+		}
+	}
+	return first, nil
+}
+
+func TestUnknownKidReloadsStore(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{}
+	a, err := New(ctx, Options{Store: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(ctx, Options{Store: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// instance a rotates; b learns about the new kid from the token itself
+	if err := a.Rotate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	tok, _ := a.Sign(claims())
+	if err := parse(b, tok); err != nil {
+		t.Fatalf("token from a freshly rotated key rejected: %v", err)
+	}
+
+	// the next miss inside the interval doesn't hit the store again
+	if err := a.Rotate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	tok, _ = a.Sign(claims())
+	if err := parse(b, tok); err == nil {
+		t.Fatal("reload on miss is not rate-limited")
+	}
+	b.missMu.Lock()
+	b.lastMissReload = time.Now().Add(-missReloadEvery)
+	b.missMu.Unlock()
+	if err := parse(b, tok); err != nil {
+		t.Fatalf("after the interval: %v", err)
+	}
+}
+
+func TestLegacyTokensOnlyWithinGrace(t *testing.T) {
+	ctx := context.Background()
+	old, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims()).SignedString([]byte("s3cret"))
+
+	store := &memStore{}
+	r, err := New(ctx, Options{Store: store, LegacySecret: "s3cret", LegacyGrace: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parse(r, old); err != nil {
+		t.Fatalf("legacy token rejected right after the switch: %v", err)
+	}
+
+	// the switch to kid keys happened two hours ago
+	store.keys[0].CreatedAt = time.Now().Add(-2 * time.Hour)
+	r, err = New(ctx, Options{Store: store, LegacySecret: "s3cret", LegacyGrace: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parse(r, old); err == nil {
+		t.Fatal("legacy token accepted after the grace window")
+	}
+
+	// without a store the legacy secret keeps signing, so it keeps verifying
+	r, _ = New(ctx, Options{LegacySecret: "s3cret", LegacyGrace: time.Nanosecond})
+	time.Sleep(time.Millisecond)
+	if err := parse(r, old); err != nil {
+		t.Fatalf("legacy-only ring rejected its own secret: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package jwtkeys
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Store persists keys so every API instance signs with the same key and
+// restarts don't invalidate issued tokens.
+type Store interface {
+	// Load returns active keys and keys retired after retiredSince.
+	Load(ctx context.Context, retiredSince time.Time) ([]*Key, error)
+	// Rotate makes k the only active key.
+	Rotate(ctx context.Context, k *Key) error
+	// RotateIfOlder does Rotate unless the active key is younger than age.
+	RotateIfOlder(ctx context.Context, k *Key, age time.Duration) error
+	// FirstCreated is when the oldest stored key was created, i.e. when kid
+	// keys took over from the legacy secret (zero if there are none).
+	FirstCreated(ctx context.Context) (time.Time, error)
+}
+
+// PgStore keeps keys in jwt_signing_keys. Private material is stored as PKCS#8
+// PEM; restrict access to that table accordingly.
+type PgStore struct {
+	DB *pgxpool.Pool
+}
+
+// rotationLock is an arbitrary pg_advisory_xact_lock id for key rotation.
+const rotationLock = 7_100_100_7
+
+func (s PgStore) Load(ctx context.Context, retiredSince time.Time) ([]*Key, error) {
+	rows, err := s.DB.Query(ctx, `
+    SELECT kid, alg, secret, private_pem, created_at, retired_at
+    FROM jwt_signing_keys
+    WHERE retired_at IS NULL OR retired_at > $1
+  `, retiredSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*Key
+	for rows.Next() {
+		var k Key
+		var privPEM *string
+		if err := rows.Scan(&k.ID, &k.Alg, &k.Secret, &privPEM, &k.CreatedAt, &k.RetiredAt); err != nil {
+			return nil, err
+		}
+		if privPEM != nil {
+			block, _ := pem.Decode([]byte(*privPEM))
+			if block == nil {
+				return nil, errors.New("jwtkeys: bad PEM for kid " + k.ID)
+			}
+			pk, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			signer, ok := pk.(crypto.Signer)
+			if !ok {
+				return nil, errors.New("jwtkeys: unsupported private key for kid " + k.ID)
+			}
+			k.Private = signer
+		}
+		keys = append(keys, &k)
+	}
+	return keys, rows.Err()
+}
+
+func (s PgStore) FirstCreated(ctx context.Context) (time.Time, error) {
+	var first *time.Time
+	err := s.DB.QueryRow(ctx, `SELECT min(created_at) FROM jwt_signing_keys`).Scan(&first)
+	if err != nil || first == nil {
+		return time.Time{}, err
+	}
+	return *first, nil
+}
+
+func (s PgStore) Rotate(ctx context.Context, k *Key) error {
+	return s.rotate(ctx, k, 0)
+}
+
+func (s PgStore) RotateIfOlder(ctx context.Context, k *Key, age time.Duration) error {
+	return s.rotate(ctx, k, age)
+}
+
+func (s PgStore) rotate(ctx context.Context, k *Key, minAge time.Duration) error {
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, rotationLock); err != nil {
+		return err
+	}
+
+	if minAge > 0 {
+		var newest time.Time
+		err := tx.QueryRow(ctx,
+			`SELECT max(created_at) FROM jwt_signing_keys WHERE retired_at IS NULL`,
+		).Scan(&newest)
+		if err != nil && err != pgx.ErrNoRows {
+			return err
+		}
+		if !newest.IsZero() && time.Since(newest) < minAge {
+			return nil // another instance already rotated
+		}
+	}
+
+	var privPEM *string
+	if k.Private != nil {
+		der, err := x509.MarshalPKCS8PrivateKey(k.Private)
+		if err != nil {
+			return err
+		}
+		p := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		privPEM = &p
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE jwt_signing_keys SET retired_at=now() WHERE retired_at IS NULL`); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+    INSERT INTO jwt_signing_keys(kid, alg, secret, private_pem, created_at)
+    VALUES ($1,$2,$3,$4,$5)
+  `, k.ID, k.Alg, k.Secret, privPEM, k.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,182 @@
+// Package livingcost holds the cost-of-living dataset (living_costs) used
+// for the total cost of attendance: rent, food and other monthly costs plus
+// yearly health insurance and visa fees, per country and optionally city.
+package livingcost
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"unichance-backend-go/internal/scoring"
+)
+
+// Entry is one living_costs row. City "" is the country-wide default.
+type Entry struct {
+	CountryCode   string
+	City          string
+	Currency      string
+	HousingMonth  float64
+	FoodMonth     float64
+	OtherMonth    float64
+	InsuranceYear float64
+	VisaFee       float64
+}
+
+// Yearly converts the entry to yearly costs (in Currency).
+func (e Entry) Yearly() scoring.LivingCosts {
+	return scoring.YearlyLivingCosts(e.HousingMonth, e.FoodMonth, e.OtherMonth, e.InsuranceYear, e.VisaFee)
+}
+
+var (
+	countryRe  = regexp.MustCompile(`^[A-Z]{2}$`)
+	currencyRe = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// csvColumns is the CSV layout; the header row is required.
+var csvColumns = []string{"country_code", "city", "currency", "housing_month", "food_month", "other_month", "insurance_year", "visa_fee"}
+
+// ParseCSV reads entries, e.g.
+//
+//	country_code,city,currency,housing_month,food_month,other_month,insurance_year,visa_fee
+//	DE,Munich,EUR,850,300,150,1300,75
+//	DE,,EUR,550,250,120,1300,75
+func ParseCSV(r io.Reader) ([]Entry, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	if len(header) != len(csvColumns) {
+		return nil, fmt.Errorf("header: want %s", strings.Join(csvColumns, ","))
+	}
+	for i, col := range csvColumns {
+		if !strings.EqualFold(strings.TrimSpace(header[i]), col) {
+			return nil, fmt.Errorf("header: column %d is %q, want %q", i+1, header[i], col)
+		}
+	}
+
+	var out []Entry
+	seen := map[string]bool{}
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		e := Entry{
+			CountryCode: strings.ToUpper(strings.TrimSpace(rec[0])),
+			City:        strings.TrimSpace(rec[1]),
+			Currency:    strings.ToUpper(strings.TrimSpace(rec[2])),
+		}
+		if !countryRe.MatchString(e.CountryCode) {
+			return nil, fmt.Errorf("line %d: bad country_code %q", line, rec[0])
+		}
+		if !currencyRe.MatchString(e.Currency) {
+			return nil, fmt.Errorf("line %d: bad currency %q", line, rec[2])
+		}
+		amounts := []*float64{&e.HousingMonth, &e.FoodMonth, &e.OtherMonth, &e.InsuranceYear, &e.VisaFee}
+		for i, dst := range amounts {
+			v := strings.TrimSpace(rec[3+i])
+			if v == "" {
+				continue
+			}
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || f < 0 {
+				return nil, fmt.Errorf("line %d: bad %s %q", line, csvColumns[3+i], v)
+			}
+			*dst = f
+		}
+		key := e.CountryCode + "/" + strings.ToLower(e.City)
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: duplicate %s %s", line, e.CountryCode, e.City)
+		}
+		seen[key] = true
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+type Repo struct {
+	DB *pgxpool.Pool
+}
+
+// Store upserts entries by country and city.
+func (r Repo) Store(ctx context.Context, entries []Entry, source string) (int, error) {
+	batch := &pgx.Batch{}
+	for _, e := range entries {
+		batch.Queue(`
+      INSERT INTO living_costs (country_code, city, currency, housing_month, food_month, other_month,
+                                insurance_year, visa_fee, source)
+      VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+      ON CONFLICT (country_code, lower(city)) DO UPDATE SET
+        city = EXCLUDED.city, currency = EXCLUDED.currency,
+        housing_month = EXCLUDED.housing_month, food_month = EXCLUDED.food_month,
+        other_month = EXCLUDED.other_month, insurance_year = EXCLUDED.insurance_year,
+        visa_fee = EXCLUDED.visa_fee, source = EXCLUDED.source, updated_at = now()
+    `, e.CountryCode, e.City, e.Currency, e.HousingMonth, e.FoodMonth, e.OtherMonth,
+			e.InsuranceYear, e.VisaFee, source)
+	}
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, err
+	}
+	return len(entries), tx.Commit(ctx)
+}
+
+// LateralSQL joins the best living_costs row for a university: the city
+// when present, else the country default. Alias the universities table "u".
+const LateralSQL = `LEFT JOIN LATERAL (
+      SELECT lc.currency::text, lc.housing_month::float8, lc.food_month::float8, lc.other_month::float8,
+             lc.insurance_year::float8, lc.visa_fee::float8
+      FROM living_costs lc
+      WHERE lc.country_code = u.country_code
+        AND (lower(lc.city) = lower(coalesce(u.city, '')) OR lc.city = '')
+      ORDER BY lc.city = '' ASC
+      LIMIT 1
+    ) lc ON true`
+
+// Columns selects the LateralSQL row for Scan.
+const Columns = `lc.currency, lc.housing_month, lc.food_month, lc.other_month, lc.insurance_year, lc.visa_fee`
+
+// Scanned receives Columns; all nil when there is no row.
+type Scanned struct {
+	Currency                                             *string
+	HousingMonth, FoodMonth, OtherMonth, Insurance, Visa *float64
+}
+
+// Dest returns Scan destinations matching Columns.
+func (s *Scanned) Dest() []any {
+	return []any{&s.Currency, &s.HousingMonth, &s.FoodMonth, &s.OtherMonth, &s.Insurance, &s.Visa}
+}
+
+// Yearly returns the yearly costs and their currency; ok is false without a row.
+func (s Scanned) Yearly() (scoring.LivingCosts, string, bool) {
+	if s.Currency == nil {
+		return scoring.LivingCosts{}, "", false
+	}
+	f := func(p *float64) float64 {
+		if p == nil {
+			return 0
+		}
+		return *p
+	}
+	return scoring.YearlyLivingCosts(f(s.HousingMonth), f(s.FoodMonth), f(s.OtherMonth), f(s.Insurance), f(s.Visa)),
+		*s.Currency, true
+}
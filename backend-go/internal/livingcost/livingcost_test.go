@@ -0,0 +1,44 @@
+package livingcost
+
+import (
+	"strings"
+	"testing"
+)
+
+const header = "country_code,city,currency,housing_month,food_month,other_month,insurance_year,visa_fee\n"
+
+func TestParseCSV(t *testing.T) {
+	entries, err := ParseCSV(strings.NewReader(header + `de,Munich,eur,850,300,150,1300,75
+DE,,EUR,550,250,120,1300,
+# Stuttgart pending
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	if e := entries[0]; e.CountryCode != "DE" || e.Currency != "EUR" || e.City != "Munich" {
+		t.Errorf("entry normalized wrong: %+v", e)
+	}
+	if y := entries[0].Yearly(); y.Housing != 10200 || y.Total() != 16975 {
+		t.Errorf("Yearly = %+v", y)
+	}
+	if entries[1].City != "" || entries[1].VisaFee != 0 {
+		t.Errorf("country default: %+v", entries[1])
+	}
+}
+
+func TestParseCSVRejects(t *testing.T) {
+	for name, doc := range map[string]string{
+		"no header":      "DE,Munich,EUR,850,300,150,1300,75\n",
+		"bad country":    header + "DEU,Munich,EUR,850,300,150,1300,75\n",
+		"bad currency":   header + "DE,Munich,EURO,850,300,150,1300,75\n",
+		"negative":       header + "DE,Munich,EUR,-850,300,150,1300,75\n",
+		"duplicate city": header + "DE,Munich,EUR,850,300,150,1300,75\nDE,munich,EUR,900,300,150,1300,75\n",
+	} {
+		if _, err := ParseCSV(strings.NewReader(doc)); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
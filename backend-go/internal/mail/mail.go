@@ -0,0 +1,16 @@
+// Package mail sends transactional email (password reset, verification).
+// SMTPMailer is used in production, FileMailer writes messages to a local
+// outbox directory so flows can be exercised without a network.
+package mail
+
+import "context"
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string // plain text
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
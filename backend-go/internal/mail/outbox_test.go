@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailerWritesEml(t *testing.T) {
+	dir := t.TempDir()
+	m := FileMailer{Dir: filepath.Join(dir, "outbox"), From: "UniChance <no-reply@unichance.local>"}
+
+	err := m.Send(context.Background(), Message{
+		To:      "student@example.com",
+		Subject: "Test",
+		Body:    "line one\nline two",
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "outbox", "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("expected 1 .eml file, got %d", len(files))
+	}
+	b, _ := os.ReadFile(files[0])
+	got := string(b)
+	for _, want := range []string{"To: student@example.com\r\n", "Subject: Test\r\n", "line one\r\nline two"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("message missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderHeaders(t *testing.T) {
+	got := string(render("UniChance <no-reply@unichance.local>", Message{
+		To:      "victim@example.com\r\nBcc: everyone@example.com",
+		Subject: "UniChance: сброс пароля\nX-Injected: 1",
+		Body:    "body",
+	}))
+	head, _, _ := strings.Cut(got, "\r\n\r\n")
+	if strings.Contains(head, "\nBcc:") || strings.Contains(head, "\nX-Injected:") {
+		t.Errorf("header injected:\n%s", head)
+	}
+	if !strings.Contains(head, "Subject: =?UTF-8?b?") {
+		t.Errorf("subject not RFC 2047 encoded:\n%s", head)
+	}
+	for _, line := range strings.Split(head, "\r\n") {
+		for _, r := range line {
+			if r > 127 {
+				t.Fatalf("non-ASCII header line %q", line)
+			}
+		}
+	}
+}
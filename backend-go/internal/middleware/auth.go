@@ -1,19 +1,42 @@
 package middleware
 
 import (
+	"context"
+	"encoding/json"
+	"math"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 )
 
 type CtxUser struct {
-	ID    string
-	Email string
+	ID        string
+	Email     string
+	Role      string
+	JTI       string    `json:"-"` // access token id, used for logout/denylist
+	SessionID string    `json:"-"` // "sid" claim, empty for tokens issued before sessions
+	ExpiresAt time.Time `json:"-"` // access token expiry
 }
 
-func RequireAuth(jwtSecret string) echo.MiddlewareFunc {
+// RevocationChecker reports whether an otherwise valid access token was revoked
+// server-side (logout, revoked session, password change).
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, jti, sessionID, userID string, issuedAt time.Time) (bool, error)
+}
+
+// TokenKeys resolves the verification key for a token from its kid/alg headers
+// (jwtkeys.Ring).
+type TokenKeys interface {
+	Keyfunc(t *jwt.Token) (any, error)
+	ValidMethods() []string
+}
+
+// RequireAuth validates the bearer token. revoked may be nil, in which case the
+// denylist is not consulted.
+func RequireAuth(keys TokenKeys, revoked RevocationChecker) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 
@@ -26,9 +49,7 @@ func RequireAuth(jwtSecret string) echo.MiddlewareFunc {
 
 			tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
 
-			token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (any, error) {
-				return []byte(jwtSecret), nil
-			})
+			token, err := jwt.Parse(tokenStr, keys.Keyfunc, jwt.WithValidMethods(keys.ValidMethods()))
 			if err != nil || !token.Valid {
 				return c.JSON(http.StatusUnauthorized, map[string]string{
 					"error": "invalid token",
@@ -44,6 +65,12 @@ func RequireAuth(jwtSecret string) echo.MiddlewareFunc {
 
 			sub, _ := claims["sub"].(string)
 			email, _ := claims["email"].(string)
+			jti, _ := claims["jti"].(string)
+			sid, _ := claims["sid"].(string)
+			role, _ := claims["role"].(string)
+			if role == "" {
+				role = RoleStudent // tokens issued before roles existed
+			}
 
 			if sub == "" || email == "" {
 				return c.JSON(http.StatusUnauthorized, map[string]string{
@@ -51,12 +78,50 @@ func RequireAuth(jwtSecret string) echo.MiddlewareFunc {
 				})
 			}
 
+			var exp time.Time
+			iat := issuedAt(claims)
+			if d, err := claims.GetExpirationTime(); err == nil && d != nil {
+				exp = d.Time
+			}
+
+			if revoked != nil {
+				isRevoked, err := revoked.IsRevoked(c.Request().Context(), jti, sid, sub, iat)
+				if err != nil {
+					return c.JSON(http.StatusInternalServerError, map[string]string{
+						"error": "token check failed",
+					})
+				}
+				if isRevoked {
+					return c.JSON(http.StatusUnauthorized, map[string]string{
+						"error": "token revoked",
+					})
+				}
+			}
+
 			c.Set("user", CtxUser{
-				ID:    sub,
-				Email: email,
+				ID:        sub,
+				Email:     email,
+				Role:      role,
+				JTI:       jti,
+				SessionID: sid,
+				ExpiresAt: exp,
 			})
 
 			return next(c)
 		}
 	}
 }
+
+// issuedAt reads iat at full precision: jwt's NumericDate truncates it to
+// seconds, and revocation compares it with a sub-second timestamp.
+func issuedAt(claims jwt.MapClaims) time.Time {
+	switch v := claims["iat"].(type) {
+	case float64:
+		return time.UnixMicro(int64(math.Round(v * 1e6)))
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return time.UnixMicro(int64(math.Round(f * 1e6)))
+		}
+	}
+	return time.Time{}
+}
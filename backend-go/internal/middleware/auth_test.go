@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+var testSecret = []byte("s3cret")
+
+type hmacKeys struct{}
+
+func (hmacKeys) Keyfunc(t *jwt.Token) (any, error) { return testSecret, nil }
+func (hmacKeys) ValidMethods() []string            { return []string{"HS256"} }
+
+type fakeRevocations struct {
+	revoked map[string]bool // by jti
+	err     error
+	iat     time.Time // last issuedAt seen
+}
+
+func (f *fakeRevocations) IsRevoked(ctx context.Context, jti, sessionID, userID string, issuedAt time.Time) (bool, error) {
+	f.iat = issuedAt
+	return f.revoked[jti], f.err
+}
+
+func sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok
+}
+
+func TestRequireAuth(t *testing.T) {
+	revocations := &fakeRevocations{revoked: map[string]bool{"dead": true}}
+	e := echo.New()
+	e.GET("/me", func(c echo.Context) error {
+		u := c.Get("user").(CtxUser)
+		return c.String(http.StatusOK, u.ID+" "+u.Role+" "+u.SessionID)
+	}, RequireAuth(hmacKeys{}, revocations))
+
+	now := time.Now()
+	valid := jwt.MapClaims{
+		"sub": "u1", "email": "a@b.kz", "jti": "live", "sid": "s1",
+		"iat": float64(now.UnixMicro()) / 1e6, "exp": now.Add(time.Minute).Unix(),
+	}
+	with := func(k string, v any) jwt.MapClaims {
+		c := jwt.MapClaims{}
+		for key, val := range valid {
+			c[key] = val
+		}
+		c[k] = v
+		return c
+	}
+	cases := []struct {
+		name   string
+		header string
+		want   int
+		body   string
+	}{
+		{"no header", "", http.StatusUnauthorized, ""},
+		{"not bearer", "Basic abc", http.StatusUnauthorized, ""},
+		{"garbage", "Bearer abc", http.StatusUnauthorized, ""},
+		{"expired", "Bearer " + sign(t, with("exp", now.Add(-time.Minute).Unix())), http.StatusUnauthorized, ""},
+		{"no email", "Bearer " + sign(t, with("email", "")), http.StatusUnauthorized, ""},
+		{"revoked", "Bearer " + sign(t, with("jti", "dead")), http.StatusUnauthorized, ""},
+		{"valid, pre-roles token", "Bearer " + sign(t, valid), http.StatusOK, "u1 student s1"},
+		{"valid with role", "Bearer " + sign(t, with("role", RoleCounselor)), http.StatusOK, "u1 counselor s1"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if c.header != "" {
+			req.Header.Set("Authorization", c.header)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != c.want || (c.body != "" && rec.Body.String() != c.body) {
+			t.Errorf("%s: %d %q, want %d %q", c.name, rec.Code, rec.Body.String(), c.want, c.body)
+		}
+	}
+
+	// iat reaches the revocation check at microsecond precision
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+sign(t, valid))
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	if !revocations.iat.Equal(time.UnixMicro(now.UnixMicro())) {
+		t.Errorf("iat = %v, want %v", revocations.iat, time.UnixMicro(now.UnixMicro()))
+	}
+
+	revocations.err = errors.New("db down")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("checker error: %d", rec.Code)
+	}
+}
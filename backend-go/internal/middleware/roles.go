@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	RoleStudent   = "student"
+	RoleCounselor = "counselor"
+	RoleEditor    = "editor"
+	RoleAdmin     = "admin"
+)
+
+// ValidRole reports whether r is one of the roles known to users.role.
+func ValidRole(r string) bool {
+	switch r {
+	case RoleStudent, RoleCounselor, RoleEditor, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// RequireRole lets the request through if the authenticated user has any of
+// roles. Admins pass every check. Must run after RequireAuth:
+//
+//	e.PUT("/admin/...", h, requireAuth, RequireRole(RoleAdmin))
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			u, ok := c.Get("user").(CtxUser)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			}
+			if u.Role == RoleAdmin {
+				return next(c)
+			}
+			for _, r := range roles {
+				if u.Role == r {
+					return next(c)
+				}
+			}
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "insufficient role"})
+		}
+	}
+}
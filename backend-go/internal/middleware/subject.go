@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ErrNoLink: the counselor has no active link to the student, or only a
+// read-only one for a write request.
+var ErrNoLink = errors.New("no active link to this student")
+
+// CounselorAccess checks counselor -> student links and records every access
+// (counselor.Service).
+type CounselorAccess interface {
+	// CheckLink returns the active link id; ErrNoLink when there is none or
+	// write is requested on a read-only link.
+	CheckLink(ctx context.Context, counselorID, studentID string, write bool) (string, error)
+	LogAccess(ctx context.Context, linkID, counselorID, studentID, method, path string, status int, ip string)
+}
+
+// ActAsStudent serves /counselor/students/:student_id/... with the student as the
+// subject: handlers that use SubjectID read and write the student's data.
+// GET/HEAD need a read link, everything else a write link. Every request,
+// allowed or not, lands in the access log. Must run after RequireAuth.
+func ActAsStudent(access CounselorAccess) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			u, ok := c.Get("user").(CtxUser)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			}
+			ctx := c.Request().Context()
+			studentID := c.Param("student_id")
+			method := c.Request().Method
+			write := method != http.MethodGet && method != http.MethodHead
+
+			linkID, err := access.CheckLink(ctx, u.ID, studentID, write)
+			if err != nil {
+				status := http.StatusInternalServerError
+				if errors.Is(err, ErrNoLink) {
+					status = http.StatusForbidden
+				}
+				access.LogAccess(ctx, linkID, u.ID, studentID, method, c.Request().URL.Path, status, c.RealIP())
+				return c.JSON(status, map[string]string{"error": err.Error()})
+			}
+
+			c.Set("subject", studentID)
+			err = next(c)
+			status := c.Response().Status
+			if he, ok := err.(*echo.HTTPError); ok {
+				status = he.Code
+			}
+			access.LogAccess(ctx, linkID, u.ID, studentID, method, c.Request().URL.Path, status, c.RealIP())
+			return err
+		}
+	}
+}
+
+// SubjectID is the user whose data a request works on: the student under
+// ActAsStudent, otherwise the authenticated user.
+func SubjectID(c echo.Context) string {
+	if s, ok := c.Get("subject").(string); ok && s != "" {
+		return s
+	}
+	return c.Get("user").(CtxUser).ID
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+type fakeAccess struct {
+	scopes map[string]string // student -> "read" | "write"
+	logged []int
+}
+
+func (f *fakeAccess) CheckLink(ctx context.Context, counselorID, studentID string, write bool) (string, error) {
+	scope, ok := f.scopes[studentID]
+	if !ok || (write && scope != "write") {
+		return "", ErrNoLink
+	}
+	return "link-" + studentID, nil
+}
+
+func (f *fakeAccess) LogAccess(ctx context.Context, linkID, counselorID, studentID, method, path string, status int, ip string) {
+	f.logged = append(f.logged, status)
+}
+
+func TestActAsStudent(t *testing.T) {
+	access := &fakeAccess{scopes: map[string]string{"s-read": "read", "s-write": "write"}}
+	e := echo.New()
+	setUser := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user", CtxUser{ID: "counselor"})
+			return next(c)
+		}
+	}
+	subject := func(c echo.Context) error { return c.String(http.StatusOK, SubjectID(c)) }
+	g := e.Group("/counselor/students/:student_id", setUser, ActAsStudent(access))
+	g.GET("/profile", subject)
+	g.PATCH("/profile", subject)
+
+	tests := []struct {
+		method, student string
+		want            int
+	}{
+		{http.MethodGet, "s-read", http.StatusOK},
+		{http.MethodPatch, "s-read", http.StatusForbidden},
+		{http.MethodPatch, "s-write", http.StatusOK},
+		{http.MethodGet, "stranger", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(tt.method, "/counselor/students/"+tt.student+"/profile", nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.student, rec.Code, tt.want)
+		}
+		if rec.Code == http.StatusOK && rec.Body.String() != tt.student {
+			t.Errorf("%s %s: subject %q", tt.method, tt.student, rec.Body.String())
+		}
+	}
+	if len(access.logged) != len(tests) {
+		t.Errorf("logged %d requests, want %d (denied ones too)", len(access.logged), len(tests))
+	}
+}
+
+func TestSubjectIDDefaultsToUser(t *testing.T) {
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	c.Set("user", CtxUser{ID: "me"})
+	if got := SubjectID(c); got != "me" {
+		t.Errorf("SubjectID = %q, want me", got)
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// EmailVerifier reports whether the user confirmed their email address.
+type EmailVerifier interface {
+	IsEmailVerified(ctx context.Context, userID string) (bool, error)
+}
+
+// RequireVerifiedEmail must run after RequireAuth. It keeps unverified
+// (possibly spam) accounts away from endpoints that persist data, e.g. /score.
+func RequireVerifiedEmail(v EmailVerifier) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			u, ok := c.Get("user").(CtxUser)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			}
+			verified, err := v.IsEmailVerified(c.Request().Context(), u.ID)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"error": "email check failed"})
+			}
+			if !verified {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "email not verified"})
+			}
+			return next(c)
+		}
+	}
+}
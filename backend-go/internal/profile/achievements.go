@@ -0,0 +1,180 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"unichance-backend-go/internal/scoring"
+	"unichance-backend-go/internal/validation"
+)
+
+var ErrAchievementNotFound = errors.New("achievement not found")
+
+var achievementCategories = map[string]bool{
+	"olympiad": true, "leadership": true, "sports": true, "volunteering": true, "other": true,
+}
+
+// achievementLevelWeights turn an achievement into counter units: a school
+// event is worth half of a regional one, an international one three.
+var achievementLevelWeights = map[string]float64{
+	"school":        0.5,
+	"regional":      1,
+	"national":      2,
+	"international": 3,
+}
+
+// Validate checks an achievement before insert/update.
+func (a Achievement) Validate() error {
+	var errs validation.Errors
+	if !achievementCategories[a.Category] {
+		errs.Add("category", "invalid", "category must be one of olympiad, leadership, sports, volunteering, other")
+	}
+	if _, ok := achievementLevelWeights[a.Level]; !ok {
+		errs.Add("level", "invalid", "level must be one of school, regional, national, international")
+	}
+	if a.Year != nil && (*a.Year < 2000 || *a.Year > time.Now().Year()+1) {
+		errs.Add("year", "out_of_range", "year must be between 2000 and next year")
+	}
+	if strings.TrimSpace(a.Description) == "" {
+		errs.Add("description", "required", "description is required")
+	} else if len(a.Description) > 1000 {
+		errs.Add("description", "too_long", "description must be at most 1000 characters")
+	}
+	return errs.Err()
+}
+
+// AggregateAchievements sums achievements per category, weighted by level, into
+// the counters scoring.ComputeMatch understands.
+func AggregateAchievements(items []Achievement) scoring.AchievementCounts {
+	sums := map[string]float64{}
+	for _, a := range items {
+		sums[a.Category] += achievementLevelWeights[a.Level]
+	}
+	round := func(v float64) int { return int(math.Round(v)) }
+	return scoring.AchievementCounts{
+		Olympiads:    round(sums["olympiad"]),
+		Leadership:   round(sums["leadership"]),
+		Sports:       round(sums["sports"]),
+		Volunteering: round(sums["volunteering"]),
+		Other:        round(sums["other"]),
+	}
+}
+
+func (r Repo) ListAchievements(ctx context.Context, userID string) ([]Achievement, error) {
+	return listAchievements(ctx, r.DB, userID)
+}
+
+// rowsQuerier is satisfied by both *pgxpool.Pool and pgx.Tx.
+type rowsQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+func listAchievements(ctx context.Context, db rowsQuerier, userID string) ([]Achievement, error) {
+	rows, err := db.Query(ctx, `
+    SELECT id, category, level, year, description
+    FROM profile_achievements
+    WHERE user_id=$1
+    ORDER BY year DESC NULLS LAST, created_at DESC
+  `, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []Achievement{}
+	for rows.Next() {
+		var a Achievement
+		if err := rows.Scan(&a.ID, &a.Category, &a.Level, &a.Year, &a.Description); err != nil {
+			return nil, err
+		}
+		items = append(items, a)
+	}
+	return items, rows.Err()
+}
+
+func (r Repo) CreateAchievement(ctx context.Context, userID string, a Achievement) (Achievement, error) {
+	a.Description = strings.TrimSpace(a.Description)
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return a, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+    INSERT INTO profile_achievements(user_id, category, level, year, description)
+    VALUES ($1,$2,$3,$4,$5)
+    RETURNING id
+  `, userID, a.Category, a.Level, a.Year, a.Description).Scan(&a.ID)
+	if err != nil {
+		return a, err
+	}
+	if err := r.versionAchievements(ctx, tx, userID); err != nil {
+		return a, err
+	}
+	return a, tx.Commit(ctx)
+}
+
+func (r Repo) UpdateAchievement(ctx context.Context, userID string, a Achievement) (Achievement, error) {
+	a.Description = strings.TrimSpace(a.Description)
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return a, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+    UPDATE profile_achievements SET category=$3, level=$4, year=$5, description=$6
+    WHERE id::text=$1 AND user_id=$2
+  `, a.ID, userID, a.Category, a.Level, a.Year, a.Description)
+	if err != nil {
+		return a, err
+	}
+	if tag.RowsAffected() == 0 {
+		return a, ErrAchievementNotFound
+	}
+	if err := r.versionAchievements(ctx, tx, userID); err != nil {
+		return a, err
+	}
+	return a, tx.Commit(ctx)
+}
+
+func (r Repo) DeleteAchievement(ctx context.Context, userID, id string) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM profile_achievements WHERE id::text=$1 AND user_id=$2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAchievementNotFound
+	}
+	if err := r.versionAchievements(ctx, tx, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// versionAchievements writes a profile version after an achievement change,
+// in the same tx, so history shows when achievements changed. Without a
+// profile row there is nothing to version yet.
+func (r Repo) versionAchievements(ctx context.Context, tx pgx.Tx, userID string) error {
+	p, err := r.scanProfile(ctx, tx, selectProfile+" FOR UPDATE", userID)
+	if err == pgx.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = r.writeVersion(ctx, tx, p)
+	return err
+}
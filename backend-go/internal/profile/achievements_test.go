@@ -0,0 +1,51 @@
+package profile
+
+import (
+	"testing"
+
+	"unichance-backend-go/internal/scoring"
+	"unichance-backend-go/internal/validation"
+)
+
+func TestAggregateAchievements(t *testing.T) {
+	items := []Achievement{
+		{Category: "olympiad", Level: "international"},
+		{Category: "olympiad", Level: "school"},
+		{Category: "leadership", Level: "national"},
+		{Category: "volunteering", Level: "school"},
+		{Category: "volunteering", Level: "school"},
+		{Category: "sports", Level: "regional"},
+	}
+	got := AggregateAchievements(items)
+	want := scoring.AchievementCounts{Olympiads: 4, Leadership: 2, Sports: 1, Volunteering: 1}
+	if got != want {
+		t.Fatalf("AggregateAchievements = %+v, want %+v", got, want)
+	}
+
+	if got := AggregateAchievements(nil); got != (scoring.AchievementCounts{}) {
+		t.Fatalf("empty list = %+v", got)
+	}
+}
+
+func TestAchievementValidate(t *testing.T) {
+	year := 1990
+	err := Achievement{Category: "chess", Level: "galactic", Year: &year}.Validate()
+	verrs, ok := validation.As(err)
+	if !ok {
+		t.Fatalf("expected validation errors, got %v", err)
+	}
+	fields := map[string]bool{}
+	for _, e := range verrs {
+		fields[e.Field] = true
+	}
+	for _, f := range []string{"category", "level", "year", "description"} {
+		if !fields[f] {
+			t.Errorf("missing error for %s", f)
+		}
+	}
+
+	ok2 := Achievement{Category: "olympiad", Level: "national", Description: "Республиканская олимпиада по математике, 2 место"}
+	if err := ok2.Validate(); err != nil {
+		t.Fatalf("valid achievement rejected: %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package profile
+
+import "unichance-backend-go/internal/scoring"
+
+// Enrich builds the matcher input from a profile and its achievements.
+func Enrich(p Profile, achievements []Achievement) scoring.EnrichedStudentProfile {
+	return scoring.EnrichedStudentProfile{
+		GPA:            p.GPA,
+		GPAScale:       p.GPAScale,
+		GradingSystem:  deref(p.GradingSystem),
+		IELTS:          p.IELTS,
+		TOEFL:          p.TOEFL,
+		SAT:            p.SAT,
+		ACT:            p.ACT,
+		GREVerbal:      p.GREVerbal,
+		GREQuant:       p.GREQuant,
+		GMAT:           p.GMAT,
+		Duolingo:       p.Duolingo,
+		PTE:            p.PTE,
+		TestDaF:        p.TestDaF,
+		BudgetYear:     p.BudgetYear,
+		BudgetCurrency: p.BudgetCurrency,
+		Citizenship:    deref(p.CitizenshipCode),
+		GraduationYear: p.GraduationYear,
+		// structured achievements -> level-weighted counters
+		Achievements:        AggregateAchievements(achievements),
+		HasAchievementNotes: deref(p.Awards) != "" || deref(p.AchievementsSummary) != "",
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -1,7 +1,10 @@
 package profile
 
 import (
+  "context"
+  "io"
   "net/http"
+  "strconv"
 
   "github.com/labstack/echo/v4"
   "github.com/jackc/pgx/v5"
@@ -9,16 +12,24 @@ import (
 
   "unichance-backend-go/internal/middleware"
   "unichance-backend-go/internal/scoring"
+  "unichance-backend-go/internal/validation"
 )
 
 type Handler struct {
   Repo Repo
   DB *pgxpool.Pool
+  Matcher ProgramMatcher // scores POST /score the way smart search does
+}
+
+// ProgramMatcher scores a student against one program with the configured
+// scorer (programs.Handler); ok is false when there is no such program.
+type ProgramMatcher interface {
+  MatchProgram(ctx context.Context, programID string, student scoring.EnrichedStudentProfile) (m scoring.MatchScore, ok bool, err error)
 }
 
 func (h Handler) GetMe(c echo.Context) error {
-  u := c.Get("user").(middleware.CtxUser)
-  p, err := h.Repo.GetMyProfile(c.Request().Context(), u.ID)
+  userID := middleware.SubjectID(c)
+  p, err := h.Repo.GetMyProfile(c.Request().Context(), userID)
   if err != nil {
     if err == pgx.ErrNoRows {
       return c.JSON(http.StatusOK, map[string]any{"profile": nil})
@@ -29,65 +40,302 @@ func (h Handler) GetMe(c echo.Context) error {
 }
 
 func (h Handler) UpsertMe(c echo.Context) error {
-  u := c.Get("user").(middleware.CtxUser)
+  userID := middleware.SubjectID(c)
   var req Profile
   if err := c.Bind(&req); err != nil {
     return c.JSON(http.StatusBadRequest, map[string]string{"error":"bad body"})
   }
-  p, err := h.Repo.UpsertMyProfile(c.Request().Context(), u.ID, req)
+  return h.save(c, userID, req)
+}
+
+// PatchMe applies a JSON Merge Patch (RFC 7396): only the fields present in
+// the body change, null clears a field. Read, merge and save share one
+// transaction (Repo.PatchMyProfile).
+func (h Handler) PatchMe(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  ctx := c.Request().Context()
+
+  body, err := io.ReadAll(io.LimitReader(c.Request().Body, 64<<10))
+  if err != nil { return c.JSON(http.StatusBadRequest, map[string]string{"error":"bad body"}) }
+  currencies, err := h.Repo.Currencies(ctx)
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+
+  var patchErr error
+  saved, err := h.Repo.PatchMyProfile(ctx, userID, func(cur Profile) (Profile, error) {
+    next, err := ApplyPatch(cur, body)
+    if err != nil {
+      patchErr = err
+      return next, err
+    }
+    return next, next.Validate(currencies)
+  })
+  if err == ErrBadPatch { return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()}) }
+  if verrs, ok := validation.As(err); ok { return c.JSON(http.StatusUnprocessableEntity, verrs.Body()) }
+  if patchErr != nil { return c.JSON(http.StatusBadRequest, map[string]string{"error":"bad body"}) }
+  if err != nil { return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()}) }
+  return c.JSON(http.StatusOK, map[string]any{"profile": saved})
+}
+
+// save validates and upserts (writing a new profile version).
+func (h Handler) save(c echo.Context, userID string, p Profile) error {
+  ctx := c.Request().Context()
+  currencies, err := h.Repo.Currencies(ctx)
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  if verrs, ok := validation.As(p.Validate(currencies)); ok { return c.JSON(http.StatusUnprocessableEntity, verrs.Body()) }
+
+  saved, err := h.Repo.UpsertMyProfile(ctx, userID, p)
   if err != nil {
     return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
   }
-  return c.JSON(http.StatusOK, map[string]any{"profile": p})
+  return c.JSON(http.StatusOK, map[string]any{"profile": saved})
 }
 
 type scoreReq struct {
   ProgramID string `json:"program_id"`
+  Version   *int   `json:"version"` // optional: score a past profile version
+  ScenarioID string `json:"scenario_id"` // optional: what-if scenario on top of it
 }
 
 func (h Handler) ScoreProgram(c echo.Context) error {
-  u := c.Get("user").(middleware.CtxUser)
+  userID := middleware.SubjectID(c)
 
   var req scoreReq
   if err := c.Bind(&req); err != nil || req.ProgramID == "" {
     return c.JSON(http.StatusBadRequest, map[string]string{"error":"program_id required"})
   }
 
-  prof, err := h.Repo.GetMyProfile(c.Request().Context(), u.ID)
+  prof, achievements, err := h.Repo.ResolveProfile(c.Request().Context(), userID, req.Version)
+  if err == ErrVersionNotFound { return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()}) }
   if err != nil { return c.JSON(http.StatusBadRequest, map[string]string{"error":"profile not found"}) }
+  prof, err = h.Repo.ApplyScenario(c.Request().Context(), userID, req.ScenarioID, prof)
+  if err == ErrScenarioNotFound { return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()}) }
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
 
-  // requirements алу (егер requirements кестесі толса)
-  var r scoring.Requirements
-  err = h.DB.QueryRow(c.Request().Context(), `
-    SELECT min_gpa, min_ielts, min_toefl, min_sat
-    FROM requirements WHERE program_id=$1
-  `, req.ProgramID).Scan(&r.MinGPA, &r.MinIELTS, &r.MinTOEFL, &r.MinSAT)
-  // requirements жоқ болса — норма
-  if err != nil && err != pgx.ErrNoRows {
-    return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
-  }
-
-  // Check if user has achievements
-  hasAchievements := (prof.Awards != nil && *prof.Awards != "") || 
-                     (prof.AchievementsSummary != nil && *prof.AchievementsSummary != "")
+  match, ok, err := h.Matcher.MatchProgram(c.Request().Context(), req.ProgramID, Enrich(prof, achievements))
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  if !ok { return c.JSON(http.StatusNotFound, map[string]string{"error":"program not found"}) }
 
-  res := scoring.Compute(scoring.Profile{
-    GPA: prof.GPA, GPAScale: prof.GPAScale,
-    IELTS: prof.IELTS, TOEFL: prof.TOEFL, SAT: prof.SAT,
-    BudgetYear: prof.BudgetYear,
-    HasAchievements: hasAchievements,
-  }, r)
+  reasons := scoring.RenderReasons(match.Reasons)
 
   // Save to history
   _, _ = h.DB.Exec(c.Request().Context(), `
-    INSERT INTO scores(profile_id, program_id, score, reasons)
-    VALUES ($1,$2,$3,to_jsonb($4::text[]))
-  `, prof.ID, req.ProgramID, res.Score, res.Reasons)
+    INSERT INTO scores(profile_id, program_id, score, reasons, reason_codes, profile_version, scenario_id, scorer_version)
+    VALUES ($1,$2,$3,to_jsonb($4::text[]),$5,$6,NULLIF($7,'')::uuid,$8)
+  `, prof.ID, req.ProgramID, match.OverallScore, reasons, match.Reasons, req.Version, req.ScenarioID, match.ScorerVersion)
+
+  return c.JSON(http.StatusOK, map[string]any{
+    "score": match.OverallScore,
+    "category": match.Category,
+    "breakdown": match.BreakdownScore,
+    "reasons": reasons,
+    "reason_codes": match.Reasons,
+    "admit_probability": match.Probability,
+    "scorer_version": match.ScorerVersion,
+  })
+}
+
+// Scores is the /score history (?limit=, default 50).
+func (h Handler) Scores(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  limit, _ := strconv.Atoi(c.QueryParam("limit"))
+  items, err := h.Repo.ListScores(c.Request().Context(), userID, limit)
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  return c.JSON(http.StatusOK, map[string]any{"items": items})
+}
+
+// Completeness rates how complete the profile is for matching: weighted
+// percent, missing fields by impact and the confidence smart search reports.
+// A user without a profile gets 0% rather than an error.
+func (h Handler) Completeness(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  p, achievements, err := h.Repo.ResolveProfile(c.Request().Context(), userID, nil)
+  if err != nil && err != pgx.ErrNoRows { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  return c.JSON(http.StatusOK, scoring.AssessCompleteness(Enrich(p, achievements)))
+}
+
+// GradingSystems lists the supported grading systems for the profile form.
+func (h Handler) GradingSystems(c echo.Context) error {
+  return c.JSON(http.StatusOK, map[string]any{"items": scoring.GradingSystems()})
+}
+
+// ===== history: /profile/me/history, /profile/me/diff =====
+
+func (h Handler) History(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  versions, err := h.Repo.ListVersions(c.Request().Context(), userID)
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  return c.JSON(http.StatusOK, map[string]any{"versions": versions})
+}
+
+// Diff compares ?from= with ?to= (default: latest version).
+func (h Handler) Diff(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  ctx := c.Request().Context()
+
+  from, err := strconv.Atoi(c.QueryParam("from"))
+  if err != nil || from <= 0 { return c.JSON(http.StatusBadRequest, map[string]string{"error":"from version required"}) }
+  to := 0
+  if v := c.QueryParam("to"); v != "" {
+    if to, err = strconv.Atoi(v); err != nil || to <= 0 {
+      return c.JSON(http.StatusBadRequest, map[string]string{"error":"bad to version"})
+    }
+  }
+
+  a, err := h.Repo.GetVersion(ctx, userID, from)
+  if err == ErrVersionNotFound { return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()}) }
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+
+  b, err := h.Repo.GetVersion(ctx, userID, to)
+  if err == ErrVersionNotFound { return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()}) }
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
 
+  return c.JSON(http.StatusOK, Diff(a, b))
+}
+
+// ===== achievements: /profile/me/achievements =====
+
+func (h Handler) ListAchievements(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  items, err := h.Repo.ListAchievements(c.Request().Context(), userID)
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
   return c.JSON(http.StatusOK, map[string]any{
-    "score": res.Score,
-    "category": res.Category,
-    "breakdown": res.Breakdown,
-    "reasons": res.Reasons,
+    "items": items,
+    "counts": AggregateAchievements(items),
   })
 }
+
+func (h Handler) CreateAchievement(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  var req Achievement
+  if err := c.Bind(&req); err != nil { return c.JSON(http.StatusBadRequest, map[string]string{"error":"bad body"}) }
+  if verrs, ok := validation.As(req.Validate()); ok { return c.JSON(http.StatusUnprocessableEntity, verrs.Body()) }
+  a, err := h.Repo.CreateAchievement(c.Request().Context(), userID, req)
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  return c.JSON(http.StatusCreated, map[string]any{"achievement": a})
+}
+
+func (h Handler) UpdateAchievement(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  var req Achievement
+  if err := c.Bind(&req); err != nil { return c.JSON(http.StatusBadRequest, map[string]string{"error":"bad body"}) }
+  req.ID = c.Param("id")
+  if verrs, ok := validation.As(req.Validate()); ok { return c.JSON(http.StatusUnprocessableEntity, verrs.Body()) }
+  a, err := h.Repo.UpdateAchievement(c.Request().Context(), userID, req)
+  if err == ErrAchievementNotFound { return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()}) }
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  return c.JSON(http.StatusOK, map[string]any{"achievement": a})
+}
+
+func (h Handler) DeleteAchievement(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  err := h.Repo.DeleteAchievement(c.Request().Context(), userID, c.Param("id"))
+  if err == ErrAchievementNotFound { return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()}) }
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  return c.NoContent(http.StatusNoContent)
+}
+
+// ===== admission outcomes: /profile/me/outcomes =====
+
+func (h Handler) ListOutcomes(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  items, err := h.Repo.ListOutcomes(c.Request().Context(), userID)
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  return c.JSON(http.StatusOK, map[string]any{"items": items})
+}
+
+// ReportOutcome records a decision with a snapshot of the current profile
+// (the features the admission model is fitted on).
+func (h Handler) ReportOutcome(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  var req AdmissionOutcome
+  if err := c.Bind(&req); err != nil { return c.JSON(http.StatusBadRequest, map[string]string{"error":"bad body"}) }
+  if verrs, ok := validation.As(req.Validate()); ok { return c.JSON(http.StatusUnprocessableEntity, verrs.Body()) }
+  ctx := c.Request().Context()
+
+  p, achievements, err := h.Repo.ResolveProfile(ctx, userID, nil)
+  if err == pgx.ErrNoRows { return c.JSON(http.StatusNotFound, map[string]string{"error":"profile not found"}) }
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  req.Features = scoring.FeaturesOf(Enrich(p, achievements))
+
+  o, err := h.Repo.ReportOutcome(ctx, userID, req)
+  if verrs, ok := validation.As(err); ok { return c.JSON(http.StatusUnprocessableEntity, verrs.Body()) }
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  return c.JSON(http.StatusOK, map[string]any{"outcome": o})
+}
+
+func (h Handler) DeleteOutcome(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  err := h.Repo.DeleteOutcome(c.Request().Context(), userID, c.Param("id"))
+  if err == ErrOutcomeNotFound { return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()}) }
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  return c.NoContent(http.StatusNoContent)
+}
+
+// ===== what-if scenarios: /profile/me/scenarios =====
+
+func (h Handler) ListScenarios(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  items, err := h.Repo.ListScenarios(c.Request().Context(), userID)
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  return c.JSON(http.StatusOK, map[string]any{"items": items})
+}
+
+// GetScenario returns the scenario together with the profile it produces.
+func (h Handler) GetScenario(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  ctx := c.Request().Context()
+  s, err := h.Repo.GetScenario(ctx, userID, c.Param("id"))
+  if err == ErrScenarioNotFound { return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()}) }
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+
+  base, err := h.Repo.GetMyProfile(ctx, userID)
+  if err != nil && err != pgx.ErrNoRows { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  p, err := s.Apply(base)
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  return c.JSON(http.StatusOK, map[string]any{"scenario": s, "profile": p})
+}
+
+func (h Handler) CreateScenario(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  var req Scenario
+  if err := c.Bind(&req); err != nil { return c.JSON(http.StatusBadRequest, map[string]string{"error":"bad body"}) }
+  ctx := c.Request().Context()
+
+  err := h.checkScenario(ctx, userID, req)
+  if err == nil { req, err = h.Repo.CreateScenario(ctx, userID, req) }
+  if verrs, ok := validation.As(err); ok { return c.JSON(http.StatusUnprocessableEntity, verrs.Body()) }
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  return c.JSON(http.StatusCreated, map[string]any{"scenario": req})
+}
+
+func (h Handler) UpdateScenario(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  var req Scenario
+  if err := c.Bind(&req); err != nil { return c.JSON(http.StatusBadRequest, map[string]string{"error":"bad body"}) }
+  req.ID = c.Param("id")
+  ctx := c.Request().Context()
+
+  err := h.checkScenario(ctx, userID, req)
+  if err == nil { req, err = h.Repo.UpdateScenario(ctx, userID, req) }
+  if err == ErrScenarioNotFound { return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()}) }
+  if verrs, ok := validation.As(err); ok { return c.JSON(http.StatusUnprocessableEntity, verrs.Body()) }
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  return c.JSON(http.StatusOK, map[string]any{"scenario": req})
+}
+
+func (h Handler) DeleteScenario(c echo.Context) error {
+  userID := middleware.SubjectID(c)
+  err := h.Repo.DeleteScenario(c.Request().Context(), userID, c.Param("id"))
+  if err == ErrScenarioNotFound { return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()}) }
+  if err != nil { return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()}) }
+  return c.NoContent(http.StatusNoContent)
+}
+
+// checkScenario validates the overrides against the user's current profile.
+func (h Handler) checkScenario(ctx context.Context, userID string, s Scenario) error {
+  base, err := h.Repo.GetMyProfile(ctx, userID)
+  if err != nil && err != pgx.ErrNoRows { return err }
+  currencies, err := h.Repo.Currencies(ctx)
+  if err != nil { return err }
+  return s.Validate(base, currencies)
+}
@@ -4,13 +4,23 @@ type Profile struct {
 	ID     string `json:"id"`
 	UserID string `json:"user_id"`
 
-	GPA      *float64 `json:"gpa"`
-	GPAScale *float64 `json:"gpa_scale"`
+	GPA           *float64 `json:"gpa"`
+	GPAScale      *float64 `json:"gpa_scale"`
+	GradingSystem *string  `json:"grading_system"` // scoring.GradingSystems() code
 
 	IELTS *float64 `json:"ielts"`
 	TOEFL *int     `json:"toefl"`
 	SAT   *int     `json:"sat"`
 
+	ACT       *int     `json:"act"`
+	GREVerbal *int     `json:"gre_verbal"`
+	GREQuant  *int     `json:"gre_quant"`
+	GREAWA    *float64 `json:"gre_awa"`
+	GMAT      *int     `json:"gmat"`
+	Duolingo  *int     `json:"duolingo"`
+	PTE       *int     `json:"pte"`
+	TestDaF   *int     `json:"testdaf"` // lowest TDN section level, 3-5
+
 	BudgetYear     *float64 `json:"budget_year"`
 	BudgetCurrency *string  `json:"budget_currency"`
 
@@ -27,3 +37,12 @@ type ScoreResult struct {
 	Score   int      `json:"score"`
 	Reasons []string `json:"reasons"`
 }
+
+// Achievement is one row of profile_achievements.
+type Achievement struct {
+	ID          string `json:"id"`
+	Category    string `json:"category"` // olympiad | leadership | sports | volunteering | other
+	Level       string `json:"level"`    // school | regional | national | international
+	Year        *int   `json:"year"`
+	Description string `json:"description"`
+}
@@ -0,0 +1,131 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"unichance-backend-go/internal/scoring"
+	"unichance-backend-go/internal/validation"
+)
+
+var ErrOutcomeNotFound = errors.New("outcome not found")
+
+var outcomeDecisions = map[string]bool{"admitted": true, "rejected": true, "waitlisted": true}
+
+// maxOutcomesPerYear caps the programs one student reports for a cycle, so
+// a single account can't outweigh everyone else in the admission models.
+const maxOutcomesPerYear = 30
+
+// AdmissionOutcome is a decision the student reports for a program they
+// applied to. Features snapshot the profile at the first report, so later
+// profile edits don't rewrite what the decision was based on; cmd/fit_admission
+// fits the program's admission model on them.
+type AdmissionOutcome struct {
+	ID        string                    `json:"id"`
+	ProgramID string                    `json:"program_id"`
+	Year      int                       `json:"year"`     // admission cycle
+	Decision  string                    `json:"decision"` // admitted | rejected | waitlisted
+	Features  scoring.ApplicantFeatures `json:"features"`
+	CreatedAt time.Time                 `json:"created_at"`
+}
+
+// Validate checks a report before it is saved.
+func (o AdmissionOutcome) Validate() error {
+	var errs validation.Errors
+	if _, err := uuid.Parse(o.ProgramID); err != nil {
+		errs.Add("program_id", "invalid", "program_id must be a program id")
+	}
+	if o.Year < 2000 || o.Year > time.Now().Year()+1 {
+		errs.Add("year", "out_of_range", "year must be between 2000 and next year")
+	}
+	if !outcomeDecisions[o.Decision] {
+		errs.Add("decision", "invalid", "decision must be one of admitted, rejected, waitlisted")
+	}
+	return errs.Err()
+}
+
+func (r Repo) ListOutcomes(ctx context.Context, userID string) ([]AdmissionOutcome, error) {
+	rows, err := r.DB.Query(ctx, `
+    SELECT id, program_id::text, year, decision,
+      gpa_us4::float8, sat::float8, gre::float8, gmat::float8, ielts::float8, testdaf::float8, created_at
+    FROM admission_outcomes WHERE user_id=$1
+    ORDER BY year DESC, created_at DESC
+  `, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []AdmissionOutcome{}
+	for rows.Next() {
+		var o AdmissionOutcome
+		f := &o.Features
+		if err := rows.Scan(&o.ID, &o.ProgramID, &o.Year, &o.Decision,
+			&f.GPA, &f.SAT, &f.GRE, &f.GMAT, &f.IELTS, &f.TestDaF, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, o)
+	}
+	return items, rows.Err()
+}
+
+// ReportOutcome saves o with the features as a snapshot. Reporting the same
+// program and year again updates the decision (waitlisted -> admitted) and
+// keeps the original snapshot. At most maxOutcomesPerYear programs per year.
+func (r Repo) ReportOutcome(ctx context.Context, userID string, o AdmissionOutcome) (AdmissionOutcome, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return o, err
+	}
+	defer tx.Rollback(ctx)
+
+	// the user row serialises reports racing past the cap
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id=$1 FOR NO KEY UPDATE`, userID); err != nil {
+		return o, err
+	}
+	var others int
+	if err := tx.QueryRow(ctx, `
+    SELECT count(*) FROM admission_outcomes
+    WHERE user_id=$1 AND year=$2 AND program_id::text <> $3
+  `, userID, o.Year, o.ProgramID).Scan(&others); err != nil {
+		return o, err
+	}
+	if others >= maxOutcomesPerYear {
+		return o, validation.Errors{{Field: "year", Code: "limit_reached",
+			Message: fmt.Sprintf("at most %d outcomes per year", maxOutcomesPerYear)}}
+	}
+
+	f := &o.Features
+	err = tx.QueryRow(ctx, `
+    INSERT INTO admission_outcomes(user_id, program_id, year, decision, gpa_us4, sat, gre, gmat, ielts, testdaf)
+    VALUES ($1,$2::uuid,$3,$4,$5,round($6::float8),round($7::float8),round($8::float8),$9,$10)
+    ON CONFLICT (user_id, program_id, year) DO UPDATE SET decision = EXCLUDED.decision
+    RETURNING id, gpa_us4::float8, sat::float8, gre::float8, gmat::float8, ielts::float8, testdaf::float8, created_at
+  `, userID, o.ProgramID, o.Year, o.Decision, f.GPA, f.SAT, f.GRE, f.GMAT, f.IELTS, f.TestDaF).
+		Scan(&o.ID, &f.GPA, &f.SAT, &f.GRE, &f.GMAT, &f.IELTS, &f.TestDaF, &o.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return o, validation.Errors{{Field: "program_id", Code: "not_found", Message: "program not found"}}
+	}
+	if err != nil {
+		return o, err
+	}
+	return o, tx.Commit(ctx)
+}
+
+func (r Repo) DeleteOutcome(ctx context.Context, userID, id string) error {
+	tag, err := r.DB.Exec(ctx,
+		`DELETE FROM admission_outcomes WHERE id::text=$1 AND user_id=$2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrOutcomeNotFound
+	}
+	return nil
+}
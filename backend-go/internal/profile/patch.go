@@ -0,0 +1,98 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"unichance-backend-go/internal/validation"
+)
+
+var ErrBadPatch = errors.New("merge patch must be a JSON object")
+
+// MergePatch applies an RFC 7396 JSON Merge Patch to doc: members present in
+// patch replace those in doc, null removes them, nested objects merge.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var p any
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+	var d any
+	if len(bytes.TrimSpace(doc)) > 0 {
+		if err := json.Unmarshal(doc, &d); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(mergeValue(d, p))
+}
+
+func mergeValue(target, patch any) any {
+	pm, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	tm, ok := target.(map[string]any)
+	if !ok {
+		tm = map[string]any{}
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(tm, k)
+			continue
+		}
+		tm[k] = mergeValue(tm[k], v)
+	}
+	return tm
+}
+
+// readOnlyFields can't be changed through PATCH.
+var readOnlyFields = map[string]bool{"id": true, "user_id": true}
+
+// ApplyPatch merges patch into cur. Unknown and read-only members are reported
+// as field errors instead of being silently dropped.
+func ApplyPatch(cur Profile, patch []byte) (Profile, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil || members == nil {
+		return cur, ErrBadPatch
+	}
+
+	known := fieldMap(Profile{})
+	var errs validation.Errors
+	for k := range members {
+		if _, ok := known[k]; !ok {
+			errs.Add(k, "unknown", "unknown profile field")
+		} else if readOnlyFields[k] {
+			errs.Add(k, "read_only", "field can't be changed")
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return cur, err
+	}
+
+	doc, err := json.Marshal(cur)
+	if err != nil {
+		return cur, err
+	}
+	merged, err := MergePatch(doc, patch)
+	if err != nil {
+		return cur, err
+	}
+
+	var out Profile
+	if err := json.Unmarshal(merged, &out); err != nil {
+		// wrong JSON type for a field, e.g. "gpa": "high"
+		var te *json.UnmarshalTypeError
+		if errors.As(err, &te) {
+			return cur, validation.Errors{{Field: te.Field, Code: "invalid_type", Message: "expected " + te.Type.String()}}
+		}
+		return cur, err
+	}
+	out.ID, out.UserID = cur.ID, cur.UserID
+	return out, nil
+}
+
+// IsField reports whether name is a writable profile JSON field.
+func IsField(name string) bool {
+	_, ok := fieldMap(Profile{})[name]
+	return ok && !readOnlyFields[name]
+}
@@ -0,0 +1,89 @@
+package profile
+
+import (
+	"testing"
+
+	"unichance-backend-go/internal/validation"
+)
+
+func TestMergePatchRFC7396(t *testing.T) {
+	// examples from RFC 7396, appendix A
+	tests := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("MergePatch(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestApplyPatchKeepsOtherFields(t *testing.T) {
+	usd := "USD"
+	cur := Profile{ID: "p1", UserID: "u1", GPA: fptr(3.6), SAT: iptr(1400), BudgetYear: fptr(20000), BudgetCurrency: &usd}
+
+	next, err := ApplyPatch(cur, []byte(`{"ielts": 7.5, "sat": null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.IELTS == nil || *next.IELTS != 7.5 {
+		t.Errorf("ielts not set: %v", next.IELTS)
+	}
+	if next.SAT != nil {
+		t.Errorf("sat not cleared: %v", *next.SAT)
+	}
+	if next.GPA == nil || *next.GPA != 3.6 || next.BudgetYear == nil || next.BudgetCurrency == nil {
+		t.Errorf("untouched fields lost: %+v", next)
+	}
+	if next.ID != "p1" || next.UserID != "u1" {
+		t.Errorf("ids changed: %s %s", next.ID, next.UserID)
+	}
+
+	for _, patch := range []string{`{"user_id":"x"}`, `{"shoe_size":42}`, `{"gpa":"high"}`} {
+		if _, ok := validation.As(func() error { _, err := ApplyPatch(cur, []byte(patch)); return err }()); !ok {
+			t.Errorf("patch %s: expected field errors", patch)
+		}
+	}
+	if _, err := ApplyPatch(cur, []byte(`[1,2]`)); err != ErrBadPatch {
+		t.Errorf("array patch: err = %v", err)
+	}
+}
+
+func TestProfileValidate(t *testing.T) {
+	currencies := []string{"USD", "EUR", "KZT"}
+	rub, kz, xx := "RUB", "KZ", "XX"
+	scale5 := 5.0
+
+	fields := func(p Profile) map[string]bool {
+		out := map[string]bool{}
+		verrs, _ := validation.As(p.Validate(currencies))
+		for _, e := range verrs {
+			out[e.Field] = true
+		}
+		return out
+	}
+
+	ok := Profile{GPA: fptr(4.6), GPAScale: &scale5, IELTS: fptr(6.5), TOEFL: iptr(100), SAT: iptr(1450), CitizenshipCode: &kz}
+	if f := fields(ok); len(f) != 0 {
+		t.Fatalf("valid profile rejected: %v", f)
+	}
+
+	bad := Profile{GPA: fptr(4.3), IELTS: fptr(7.25), TOEFL: iptr(121), SAT: iptr(1455), BudgetCurrency: &rub, CitizenshipCode: &xx}
+	f := fields(bad)
+	for _, want := range []string{"gpa", "ielts", "toefl", "sat", "budget_currency", "citizenship_code"} {
+		if !f[want] {
+			t.Errorf("expected error for %s, got %v", want, f)
+		}
+	}
+}
@@ -3,16 +3,67 @@ package profile
 import (
 	"context"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Repo struct{ DB *pgxpool.Pool }
 
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 func (r Repo) UpsertMyProfile(ctx context.Context, userID string, p Profile) (Profile, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return Profile{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	saved, err := r.upsertProfile(ctx, tx, userID, p)
+	if err != nil {
+		return Profile{}, err
+	}
+	return saved, tx.Commit(ctx)
+}
+
+// PatchMyProfile is the read-modify-write behind PATCH /profile/me: the
+// profile is read FOR UPDATE and apply's result saved in the same tx, so
+// concurrent patches can't drop each other's fields. apply gets the zero
+// Profile when there is none yet; its error aborts the save.
+func (r Repo) PatchMyProfile(ctx context.Context, userID string, apply func(Profile) (Profile, error)) (Profile, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return Profile{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	// the user row also serialises patches racing to create the profile
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id=$1 FOR NO KEY UPDATE`, userID); err != nil {
+		return Profile{}, err
+	}
+	cur, err := r.scanProfile(ctx, tx, selectProfile+" FOR UPDATE", userID)
+	if err != nil && err != pgx.ErrNoRows {
+		return Profile{}, err
+	}
+	next, err := apply(cur)
+	if err != nil {
+		return Profile{}, err
+	}
+	saved, err := r.upsertProfile(ctx, tx, userID, next)
+	if err != nil {
+		return Profile{}, err
+	}
+	return saved, tx.Commit(ctx)
+}
+
+// upsertProfile saves p and writes its version inside tx.
+func (r Repo) upsertProfile(ctx context.Context, tx pgx.Tx, userID string, p Profile) (Profile, error) {
 	// 1 user = 1 profile (MVP)
 	q := `
-  INSERT INTO profiles(user_id,gpa,gpa_scale,ielts,toefl,sat,budget_year,budget_currency,awards,achievements_summary,achievements_count,citizenship_code,graduation_year)
-  VALUES ($1,$2,$3,$4,$5,$6,$7,NULLIF($8,'')::tuition_currency,$9,$10,$11,$12,$13)
+  INSERT INTO profiles(user_id,gpa,gpa_scale,ielts,toefl,sat,budget_year,budget_currency,awards,achievements_summary,achievements_count,citizenship_code,graduation_year,grading_system,act,gre_verbal,gre_quant,gre_awa,gmat,duolingo,pte,testdaf)
+  VALUES ($1,$2,$3,$4,$5,$6,$7,NULLIF($8,'')::tuition_currency,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22)
   ON CONFLICT (user_id) DO UPDATE SET
     gpa=EXCLUDED.gpa,
     gpa_scale=EXCLUDED.gpa_scale,
@@ -26,40 +77,65 @@ func (r Repo) UpsertMyProfile(ctx context.Context, userID string, p Profile) (Pr
     achievements_count=EXCLUDED.achievements_count,
     citizenship_code=EXCLUDED.citizenship_code,
     graduation_year=EXCLUDED.graduation_year,
+    grading_system=EXCLUDED.grading_system,
+    act=EXCLUDED.act,
+    gre_verbal=EXCLUDED.gre_verbal,
+    gre_quant=EXCLUDED.gre_quant,
+    gre_awa=EXCLUDED.gre_awa,
+    gmat=EXCLUDED.gmat,
+    duolingo=EXCLUDED.duolingo,
+    pte=EXCLUDED.pte,
+    testdaf=EXCLUDED.testdaf,
     updated_at=now()
-  RETURNING id, user_id, gpa, gpa_scale, ielts, toefl, sat, budget_year, budget_currency::text, awards, achievements_summary, achievements_count, citizenship_code, graduation_year
+  RETURNING id, user_id, gpa, gpa_scale, ielts, toefl, sat, budget_year, budget_currency::text, awards, achievements_summary, achievements_count, citizenship_code, graduation_year, grading_system,
+    act, gre_verbal, gre_quant, gre_awa, gmat, duolingo, pte, testdaf
   `
-	return r.scanProfile(ctx, q,
+	saved, err := r.scanProfile(ctx, tx, q,
 		userID,
 		p.GPA, p.GPAScale, p.IELTS, p.TOEFL, p.SAT,
 		p.BudgetYear, strOrEmpty(p.BudgetCurrency),
 		p.Awards, p.AchievementsSummary, p.AchievementsCount, p.CitizenshipCode, p.GraduationYear,
+		p.GradingSystem,
+		p.ACT, p.GREVerbal, p.GREQuant, p.GREAWA, p.GMAT, p.Duolingo, p.PTE, p.TestDaF,
 	)
+	if err != nil {
+		return Profile{}, err
+	}
+	// every save is kept as an immutable version
+	if _, err := r.writeVersion(ctx, tx, saved); err != nil {
+		return Profile{}, err
+	}
+	return saved, nil
 }
 
-func (r Repo) GetMyProfile(ctx context.Context, userID string) (Profile, error) {
-	q := `
-  SELECT id, user_id, gpa, gpa_scale, ielts, toefl, sat, budget_year, budget_currency::text, awards, achievements_summary, achievements_count, citizenship_code, graduation_year
+// selectProfile reads the user's profile; inside a tx append FOR UPDATE.
+const selectProfile = `
+  SELECT id, user_id, gpa, gpa_scale, ielts, toefl, sat, budget_year, budget_currency::text, awards, achievements_summary, achievements_count, citizenship_code, graduation_year, grading_system,
+    act, gre_verbal, gre_quant, gre_awa, gmat, duolingo, pte, testdaf
   FROM profiles
   WHERE user_id=$1
   `
-	return r.scanProfile(ctx, q, userID)
+
+func (r Repo) GetMyProfile(ctx context.Context, userID string) (Profile, error) {
+	return r.scanProfile(ctx, r.DB, selectProfile, userID)
 }
 
-func (r Repo) scanProfile(ctx context.Context, q string, args ...any) (Profile, error) {
+func (r Repo) scanProfile(ctx context.Context, db querier, q string, args ...any) (Profile, error) {
 	var p Profile
 	var cur *string
 	var acCount *int
 	var citizenCode *string
 	var gradYear *int
 
-	err := r.DB.QueryRow(ctx, q, args...).Scan(
+	err := db.QueryRow(ctx, q, args...).Scan(
 		&p.ID, &p.UserID,
 		&p.GPA, &p.GPAScale,
 		&p.IELTS, &p.TOEFL, &p.SAT,
 		&p.BudgetYear, &cur,
 		&p.Awards, &p.AchievementsSummary,
 		&acCount, &citizenCode, &gradYear,
+		&p.GradingSystem,
+		&p.ACT, &p.GREVerbal, &p.GREQuant, &p.GREAWA, &p.GMAT, &p.Duolingo, &p.PTE, &p.TestDaF,
 	)
 	if cur != nil {
 		p.BudgetCurrency = cur
@@ -0,0 +1,103 @@
+package profile
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testRepo needs a migrated database in DATABASE_URL; it returns a fresh user.
+func testRepo(t *testing.T) (Repo, string) {
+	t.Helper()
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		t.Skip("DATABASE_URL not set, skipping database tests")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	var userID string
+	err = pool.QueryRow(ctx,
+		`INSERT INTO users(email, password_hash) VALUES ($1, '') RETURNING id`,
+		"profile-"+uuid.NewString()[:8]+"@example.com",
+	).Scan(&userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pool.Exec(context.Background(), `DELETE FROM users WHERE id=$1`, userID) })
+	return Repo{DB: pool}, userID
+}
+
+func TestAchievementChangesWriteVersions(t *testing.T) {
+	r, userID := testRepo(t)
+	ctx := context.Background()
+	if _, err := r.UpsertMyProfile(ctx, userID, Profile{GPA: fptr(3.5)}); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := r.CreateAchievement(ctx, userID, Achievement{Category: "sports", Level: "school", Description: "Футбол"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Level = "national"
+	if _, err := r.UpdateAchievement(ctx, userID, a); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeleteAchievement(ctx, userID, a.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := r.ListVersions(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 4 {
+		t.Fatalf("got %d versions, want profile save + create + update + delete", len(versions))
+	}
+	// newest first: delete, update, create
+	if len(versions[0].Achievements) != 0 ||
+		len(versions[1].Achievements) != 1 || versions[1].Achievements[0].Level != "national" ||
+		len(versions[2].Achievements) != 1 || versions[2].Achievements[0].Level != "school" {
+		t.Errorf("achievement snapshots: %+v", versions[:3])
+	}
+}
+
+func TestConcurrentPatchesKeepEachOther(t *testing.T) {
+	r, userID := testRepo(t)
+	ctx := context.Background()
+
+	patches := []string{`{"gpa": 3.7}`, `{"ielts": 7.5}`, `{"sat": 1450}`, `{"toefl": 105}`}
+	var wg sync.WaitGroup
+	errs := make([]error, len(patches))
+	for i, body := range patches {
+		wg.Add(1)
+		go func(i int, body string) {
+			defer wg.Done()
+			_, errs[i] = r.PatchMyProfile(ctx, userID, func(cur Profile) (Profile, error) {
+				return ApplyPatch(cur, []byte(body))
+			})
+		}(i, body)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := r.GetMyProfile(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GPA == nil || p.IELTS == nil || p.SAT == nil || p.TOEFL == nil {
+		t.Errorf("a concurrent patch was lost: %+v", p)
+	}
+}
@@ -0,0 +1,149 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"unichance-backend-go/internal/validation"
+)
+
+var ErrScenarioNotFound = errors.New("scenario not found")
+
+// Scenario is a named what-if variant of the user's profile ("IELTS 7.5").
+// Overrides is a JSON Merge Patch applied to the current profile each time
+// the scenario is used, so later profile edits carry over.
+type Scenario struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Overrides json.RawMessage `json:"overrides"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Apply returns base with the scenario overrides merged in.
+func (s Scenario) Apply(base Profile) (Profile, error) {
+	return ApplyPatch(base, s.Overrides)
+}
+
+// Validate checks the name and that the overrides turn base into a valid
+// profile. Override errors are reported as "overrides.<field>".
+func (s Scenario) Validate(base Profile, currencies []string) error {
+	var errs validation.Errors
+	name := strings.TrimSpace(s.Name)
+	if name == "" {
+		errs.Add("name", "required", "name is required")
+	} else if len(name) > 100 {
+		errs.Add("name", "too_long", "name must be at most 100 characters")
+	}
+
+	p, err := s.Apply(base)
+	if err == nil {
+		err = p.Validate(currencies)
+	}
+	if err == ErrBadPatch {
+		errs.Add("overrides", "invalid", "overrides must be a JSON object")
+	} else if verrs, ok := validation.As(err); ok {
+		for _, f := range verrs {
+			errs.Add("overrides."+f.Field, f.Code, f.Message)
+		}
+	} else if err != nil {
+		return err
+	}
+	return errs.Err()
+}
+
+func (r Repo) ListScenarios(ctx context.Context, userID string) ([]Scenario, error) {
+	rows, err := r.DB.Query(ctx, `
+    SELECT id, name, overrides, created_at, updated_at
+    FROM profile_scenarios WHERE user_id=$1
+    ORDER BY created_at
+  `, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []Scenario{}
+	for rows.Next() {
+		var s Scenario
+		if err := rows.Scan(&s.ID, &s.Name, &s.Overrides, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, s)
+	}
+	return items, rows.Err()
+}
+
+func (r Repo) GetScenario(ctx context.Context, userID, id string) (Scenario, error) {
+	var s Scenario
+	err := r.DB.QueryRow(ctx, `
+    SELECT id, name, overrides, created_at, updated_at
+    FROM profile_scenarios WHERE id::text=$1 AND user_id=$2
+  `, id, userID).Scan(&s.ID, &s.Name, &s.Overrides, &s.CreatedAt, &s.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return s, ErrScenarioNotFound
+	}
+	return s, err
+}
+
+func (r Repo) CreateScenario(ctx context.Context, userID string, s Scenario) (Scenario, error) {
+	s.Name = strings.TrimSpace(s.Name)
+	err := r.DB.QueryRow(ctx, `
+    INSERT INTO profile_scenarios(user_id, name, overrides)
+    VALUES ($1,$2,$3)
+    RETURNING id, created_at, updated_at
+  `, userID, s.Name, s.Overrides).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+	return s, scenarioNameTaken(err)
+}
+
+func (r Repo) UpdateScenario(ctx context.Context, userID string, s Scenario) (Scenario, error) {
+	s.Name = strings.TrimSpace(s.Name)
+	err := r.DB.QueryRow(ctx, `
+    UPDATE profile_scenarios SET name=$3, overrides=$4
+    WHERE id::text=$1 AND user_id=$2
+    RETURNING created_at, updated_at
+  `, s.ID, userID, s.Name, s.Overrides).Scan(&s.CreatedAt, &s.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		return s, ErrScenarioNotFound
+	}
+	return s, scenarioNameTaken(err)
+}
+
+func (r Repo) DeleteScenario(ctx context.Context, userID, id string) error {
+	tag, err := r.DB.Exec(ctx,
+		`DELETE FROM profile_scenarios WHERE id::text=$1 AND user_id=$2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrScenarioNotFound
+	}
+	return nil
+}
+
+// ApplyScenario merges the user's scenario into base; "" returns base as is.
+func (r Repo) ApplyScenario(ctx context.Context, userID, scenarioID string, base Profile) (Profile, error) {
+	if scenarioID == "" {
+		return base, nil
+	}
+	s, err := r.GetScenario(ctx, userID, scenarioID)
+	if err != nil {
+		return base, err
+	}
+	return s.Apply(base)
+}
+
+// scenarioNameTaken maps the (user_id, name) unique violation to a field error.
+func scenarioNameTaken(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return validation.Errors{{Field: "name", Code: "taken", Message: "a scenario with this name already exists"}}
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package profile
+
+import (
+	"encoding/json"
+	"testing"
+
+	"unichance-backend-go/internal/validation"
+)
+
+func TestScenarioApplyKeepsBase(t *testing.T) {
+	base := Profile{ID: "p1", UserID: "u1", GPA: fptr(3.5), IELTS: fptr(6.5), SAT: iptr(1300)}
+	s := Scenario{Name: "IELTS 7.5", Overrides: json.RawMessage(`{"ielts":7.5,"sat":null}`)}
+
+	p, err := s.Apply(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.IELTS == nil || *p.IELTS != 7.5 || p.SAT != nil || p.GPA == nil || *p.GPA != 3.5 {
+		t.Errorf("applied profile = %+v", p)
+	}
+	if *base.IELTS != 6.5 || base.SAT == nil {
+		t.Error("base profile must not change")
+	}
+}
+
+func TestScenarioValidate(t *testing.T) {
+	base := Profile{GPA: fptr(3.5)}
+	s := Scenario{Name: " ", Overrides: json.RawMessage(`{"ielts":7.3,"user_id":"x"}`)}
+
+	verrs, ok := validation.As(s.Validate(base, []string{"USD"}))
+	if !ok {
+		t.Fatal("expected validation errors")
+	}
+	fields := map[string]string{}
+	for _, f := range verrs {
+		fields[f.Field] = f.Code
+	}
+	if fields["name"] != "required" || fields["overrides.user_id"] != "read_only" {
+		t.Errorf("errors = %+v", verrs)
+	}
+
+	// value errors are only checked once the patch itself is acceptable
+	s = Scenario{Name: "bad ielts", Overrides: json.RawMessage(`{"ielts":7.3}`)}
+	verrs, _ = validation.As(s.Validate(base, nil))
+	if len(verrs) != 1 || verrs[0].Field != "overrides.ielts" {
+		t.Errorf("errors = %+v", verrs)
+	}
+
+	s = Scenario{Name: "array", Overrides: json.RawMessage(`[1]`)}
+	verrs, _ = validation.As(s.Validate(base, nil))
+	if len(verrs) != 1 || verrs[0].Field != "overrides" {
+		t.Errorf("errors = %+v", verrs)
+	}
+
+	s = Scenario{Name: "ok", Overrides: json.RawMessage(`{"ielts":7.5}`)}
+	if err := s.Validate(base, nil); err != nil {
+		t.Errorf("valid scenario: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// SavedScore is one row of the /score history.
+type SavedScore struct {
+	ID             string          `json:"id"`
+	ProgramID      string          `json:"program_id"`
+	ProgramTitle   string          `json:"program_title"`
+	Score          int             `json:"score"`
+	Reasons        json.RawMessage `json:"reasons"`
+	ReasonCodes    json.RawMessage `json:"reason_codes"` // [] for scores saved before reason codes
+	ProfileVersion *int            `json:"profile_version"`
+	ScenarioID     *string         `json:"scenario_id"`
+	ScorerVersion  string          `json:"scorer_version"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// ListScores returns the user's saved scores, newest first.
+func (r Repo) ListScores(ctx context.Context, userID string, limit int) ([]SavedScore, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	rows, err := r.DB.Query(ctx, `
+    SELECT s.id, s.program_id, pr.title, s.score, s.reasons, s.reason_codes, s.profile_version, s.scenario_id::text, s.scorer_version, s.created_at
+    FROM scores s
+    JOIN profiles p ON p.id = s.profile_id
+    JOIN programs pr ON pr.id = s.program_id
+    WHERE p.user_id=$1
+    ORDER BY s.created_at DESC
+    LIMIT $2
+  `, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []SavedScore{}
+	for rows.Next() {
+		var s SavedScore
+		if err := rows.Scan(&s.ID, &s.ProgramID, &s.ProgramTitle, &s.Score, &s.Reasons, &s.ReasonCodes,
+			&s.ProfileVersion, &s.ScenarioID, &s.ScorerVersion, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, s)
+	}
+	return items, rows.Err()
+}
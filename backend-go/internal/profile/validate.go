@@ -0,0 +1,147 @@
+package profile
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"unichance-backend-go/internal/scoring"
+	"unichance-backend-go/internal/validation"
+)
+
+// iso3166 holds the officially assigned ISO 3166-1 alpha-2 codes.
+var iso3166 = func() map[string]bool {
+	const codes = "AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS BT BV BW BY BZ " +
+		"CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR " +
+		"GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO JP " +
+		"KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ MR MS MT " +
+		"MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW " +
+		"SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG " +
+		"UM US UY UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW"
+	m := map[string]bool{}
+	for _, c := range strings.Fields(codes) {
+		m[c] = true
+	}
+	return m
+}()
+
+// Currencies returns the labels of the tuition_currency enum, so validation
+// follows the database instead of a copy of it.
+func (r Repo) Currencies(ctx context.Context) ([]string, error) {
+	var out []string
+	err := r.DB.QueryRow(ctx, `SELECT enum_range(NULL::tuition_currency)::text[]`).Scan(&out)
+	return out, err
+}
+
+// Validate checks profile values against their domains. currencies are the
+// allowed budget_currency values (see Repo.Currencies).
+func (p Profile) Validate(currencies []string) error {
+	var errs validation.Errors
+
+	if p.GradingSystem != nil && *p.GradingSystem != "" {
+		// the grading system defines the valid range, gpa_scale is ignored
+		g, ok := scoring.LookupGradingSystem(*p.GradingSystem)
+		if !ok {
+			errs.Add("grading_system", "invalid", "unknown grading_system")
+		} else if p.GPA != nil && !g.Valid(*p.GPA) {
+			errs.Add("gpa", "out_of_range", "gpa is outside the range of "+g.Name)
+		}
+	} else {
+		scale := 4.0
+		if p.GPAScale != nil {
+			scale = *p.GPAScale
+			if scale <= 0 || scale > 100 {
+				errs.Add("gpa_scale", "out_of_range", "gpa_scale must be greater than 0 and at most 100")
+			}
+		}
+		if p.GPA != nil && (*p.GPA < 0 || *p.GPA > scale) {
+			errs.Add("gpa", "out_of_range", "gpa must be between 0 and gpa_scale")
+		}
+	}
+
+	if p.IELTS != nil {
+		v := *p.IELTS
+		if v < 0 || v > 9 || !multipleOf(v, 0.5) {
+			errs.Add("ielts", "invalid", "ielts must be 0-9 in steps of 0.5")
+		}
+	}
+	if p.TOEFL != nil && (*p.TOEFL < 0 || *p.TOEFL > 120) {
+		errs.Add("toefl", "out_of_range", "toefl must be between 0 and 120")
+	}
+	if p.SAT != nil {
+		v := *p.SAT
+		if v < 400 || v > 1600 || v%10 != 0 {
+			errs.Add("sat", "invalid", "sat must be 400-1600 in steps of 10")
+		}
+	}
+
+	if p.ACT != nil && (*p.ACT < 1 || *p.ACT > 36) {
+		errs.Add("act", "out_of_range", "act must be between 1 and 36")
+	}
+	if p.GREVerbal != nil && (*p.GREVerbal < 130 || *p.GREVerbal > 170) {
+		errs.Add("gre_verbal", "out_of_range", "gre_verbal must be between 130 and 170")
+	}
+	if p.GREQuant != nil && (*p.GREQuant < 130 || *p.GREQuant > 170) {
+		errs.Add("gre_quant", "out_of_range", "gre_quant must be between 130 and 170")
+	}
+	if p.GREAWA != nil {
+		v := *p.GREAWA
+		if v < 0 || v > 6 || !multipleOf(v, 0.5) {
+			errs.Add("gre_awa", "invalid", "gre_awa must be 0-6 in steps of 0.5")
+		}
+	}
+	if p.GMAT != nil {
+		// classic 200-800 in steps of 10, Focus Edition 205-805 ending in 5
+		v := *p.GMAT
+		if v < 200 || v > 805 || v%5 != 0 {
+			errs.Add("gmat", "invalid", "gmat must be 200-805 (classic or Focus Edition)")
+		}
+	}
+	if p.Duolingo != nil {
+		v := *p.Duolingo
+		if v < 10 || v > 160 || v%5 != 0 {
+			errs.Add("duolingo", "invalid", "duolingo must be 10-160 in steps of 5")
+		}
+	}
+	if p.PTE != nil && (*p.PTE < 10 || *p.PTE > 90) {
+		errs.Add("pte", "out_of_range", "pte must be between 10 and 90")
+	}
+	if p.TestDaF != nil && (*p.TestDaF < 3 || *p.TestDaF > 5) {
+		errs.Add("testdaf", "out_of_range", "testdaf must be a TDN level between 3 and 5")
+	}
+
+	if p.BudgetYear != nil && *p.BudgetYear < 0 {
+		errs.Add("budget_year", "out_of_range", "budget_year must not be negative")
+	}
+	if p.BudgetCurrency != nil && *p.BudgetCurrency != "" && !contains(currencies, *p.BudgetCurrency) {
+		errs.Add("budget_currency", "invalid", "budget_currency must be one of "+strings.Join(currencies, ", "))
+	}
+
+	if p.CitizenshipCode != nil && *p.CitizenshipCode != "" && !iso3166[*p.CitizenshipCode] {
+		errs.Add("citizenship_code", "invalid", "citizenship_code must be an ISO 3166-1 alpha-2 code, e.g. KZ")
+	}
+	if p.GraduationYear != nil {
+		if y := *p.GraduationYear; y < 1950 || y > time.Now().Year()+10 {
+			errs.Add("graduation_year", "out_of_range", "graduation_year is out of range")
+		}
+	}
+	if p.AchievementsCount != nil && *p.AchievementsCount < 0 {
+		errs.Add("achievements_count", "out_of_range", "achievements_count must not be negative")
+	}
+	return errs.Err()
+}
+
+func multipleOf(v, step float64) bool {
+	q := v / step
+	return math.Abs(q-math.Round(q)) < 1e-9
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrVersionNotFound = errors.New("profile version not found")
+
+// ProfileVersion is an immutable snapshot written on every profile save. The
+// achievements are copied in so a past version can be rescored as it was.
+type ProfileVersion struct {
+	Version      int           `json:"version"`
+	CreatedAt    time.Time     `json:"created_at"`
+	Profile      Profile       `json:"profile"`
+	Achievements []Achievement `json:"achievements"`
+}
+
+type versionData struct {
+	Profile      Profile       `json:"profile"`
+	Achievements []Achievement `json:"achievements"`
+}
+
+// writeVersion appends the next version for p inside tx.
+func (r Repo) writeVersion(ctx context.Context, tx pgx.Tx, p Profile) (int, error) {
+	achievements, err := listAchievements(ctx, tx, p.UserID)
+	if err != nil {
+		return 0, err
+	}
+	data, err := json.Marshal(versionData{Profile: p, Achievements: achievements})
+	if err != nil {
+		return 0, err
+	}
+
+	// lock the profile row so concurrent saves get distinct numbers
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM profiles WHERE id=$1 FOR UPDATE`, p.ID); err != nil {
+		return 0, err
+	}
+	var version int
+	err = tx.QueryRow(ctx, `
+    INSERT INTO profile_versions(profile_id, user_id, version, data)
+    SELECT $1, $2, COALESCE(MAX(version), 0) + 1, $3
+    FROM profile_versions WHERE profile_id=$1
+    RETURNING version
+  `, p.ID, p.UserID, data).Scan(&version)
+	return version, err
+}
+
+// ListVersions returns the user's profile history, newest first.
+func (r Repo) ListVersions(ctx context.Context, userID string) ([]ProfileVersion, error) {
+	rows, err := r.DB.Query(ctx, `
+    SELECT version, created_at, data FROM profile_versions
+    WHERE user_id=$1 ORDER BY version DESC
+  `, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []ProfileVersion{}
+	for rows.Next() {
+		v, err := scanVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+// GetVersion loads one version; version <= 0 means the latest.
+func (r Repo) GetVersion(ctx context.Context, userID string, version int) (ProfileVersion, error) {
+	row := r.DB.QueryRow(ctx, `
+    SELECT version, created_at, data FROM profile_versions
+    WHERE user_id=$1 AND ($2 <= 0 OR version=$2)
+    ORDER BY version DESC LIMIT 1
+  `, userID, version)
+	v, err := scanVersion(row)
+	if err == pgx.ErrNoRows {
+		return ProfileVersion{}, ErrVersionNotFound
+	}
+	return v, err
+}
+
+// ResolveProfile is what scoring endpoints use: the live profile and
+// achievements, or a stored snapshot when version is set.
+func (r Repo) ResolveProfile(ctx context.Context, userID string, version *int) (Profile, []Achievement, error) {
+	if version != nil {
+		if *version <= 0 {
+			return Profile{}, nil, ErrVersionNotFound
+		}
+		v, err := r.GetVersion(ctx, userID, *version)
+		if err != nil {
+			return Profile{}, nil, err
+		}
+		return v.Profile, v.Achievements, nil
+	}
+
+	p, err := r.GetMyProfile(ctx, userID)
+	if err != nil {
+		return Profile{}, nil, err
+	}
+	achievements, err := r.ListAchievements(ctx, userID)
+	return p, achievements, err
+}
+
+// ParseVersion reads an optional ?version= / body value; "" means current.
+func ParseVersion(s string) (*int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v <= 0 {
+		return nil, ErrVersionNotFound
+	}
+	return &v, nil
+}
+
+func scanVersion(row pgx.Row) (ProfileVersion, error) {
+	var v ProfileVersion
+	var raw []byte
+	if err := row.Scan(&v.Version, &v.CreatedAt, &raw); err != nil {
+		return v, err
+	}
+	var d versionData
+	if err := json.Unmarshal(raw, &d); err != nil {
+		return v, err
+	}
+	v.Profile, v.Achievements = d.Profile, d.Achievements
+	if v.Achievements == nil {
+		v.Achievements = []Achievement{}
+	}
+	return v, nil
+}
+
+// FieldChange is one differing profile field between two versions.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// VersionDiff compares two snapshots.
+type VersionDiff struct {
+	From                int           `json:"from"`
+	To                  int           `json:"to"`
+	Changes             []FieldChange `json:"changes"`
+	AchievementsAdded   []Achievement `json:"achievements_added"`
+	AchievementsRemoved []Achievement `json:"achievements_removed"`
+}
+
+// Diff lists changed profile fields (by JSON name) and achievement changes.
+func Diff(from, to ProfileVersion) VersionDiff {
+	d := VersionDiff{
+		From:                from.Version,
+		To:                  to.Version,
+		Changes:             []FieldChange{},
+		AchievementsAdded:   []Achievement{},
+		AchievementsRemoved: []Achievement{},
+	}
+
+	a, b := fieldMap(from.Profile), fieldMap(to.Profile)
+	for k, bv := range b {
+		if k == "id" || k == "user_id" {
+			continue
+		}
+		if av := a[k]; !reflect.DeepEqual(av, bv) {
+			d.Changes = append(d.Changes, FieldChange{Field: k, From: av, To: bv})
+		}
+	}
+	sort.Slice(d.Changes, func(i, j int) bool { return d.Changes[i].Field < d.Changes[j].Field })
+
+	// an edited achievement shows up as removed (old) + added (new)
+	key := func(x Achievement) string {
+		if x.ID != "" {
+			return x.ID
+		}
+		return x.Category + "|" + x.Level + "|" + x.Description
+	}
+	before := map[string]Achievement{}
+	for _, x := range from.Achievements {
+		before[key(x)] = x
+	}
+	after := map[string]Achievement{}
+	for _, x := range to.Achievements {
+		after[key(x)] = x
+		if old, ok := before[key(x)]; !ok || !reflect.DeepEqual(old, x) {
+			d.AchievementsAdded = append(d.AchievementsAdded, x)
+		}
+	}
+	for _, x := range from.Achievements {
+		if cur, ok := after[key(x)]; !ok || !reflect.DeepEqual(cur, x) {
+			d.AchievementsRemoved = append(d.AchievementsRemoved, x)
+		}
+	}
+	return d
+}
+
+// fieldMap flattens a profile to its JSON fields so the diff follows whatever
+// the Profile struct contains.
+func fieldMap(p Profile) map[string]any {
+	raw, _ := json.Marshal(p)
+	m := map[string]any{}
+	_ = json.Unmarshal(raw, &m)
+	return m
+}
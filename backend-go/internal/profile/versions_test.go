@@ -0,0 +1,49 @@
+package profile
+
+import "testing"
+
+func fptr(v float64) *float64 { return &v }
+func iptr(v int) *int         { return &v }
+
+func TestDiff(t *testing.T) {
+	from := ProfileVersion{
+		Version: 1,
+		Profile: Profile{ID: "p1", UserID: "u1", GPA: fptr(3.4), IELTS: fptr(6.0), SAT: nil},
+		Achievements: []Achievement{
+			{ID: "a1", Category: "sports", Level: "school", Description: "Футбол"},
+			{ID: "a2", Category: "olympiad", Level: "regional", Description: "Математика"},
+		},
+	}
+	to := ProfileVersion{
+		Version: 3,
+		Profile: Profile{ID: "p1", UserID: "u1", GPA: fptr(3.7), IELTS: fptr(6.0), SAT: iptr(1420)},
+		Achievements: []Achievement{
+			{ID: "a2", Category: "olympiad", Level: "national", Description: "Математика"},
+			{ID: "a3", Category: "leadership", Level: "school", Description: "Президент класса"},
+		},
+	}
+
+	d := Diff(from, to)
+	if d.From != 1 || d.To != 3 {
+		t.Fatalf("versions = %d..%d", d.From, d.To)
+	}
+
+	got := map[string]FieldChange{}
+	for _, c := range d.Changes {
+		got[c.Field] = c
+	}
+	if len(got) != 2 {
+		t.Fatalf("changes = %+v, want gpa and sat only", d.Changes)
+	}
+	if c := got["gpa"]; c.From != 3.4 || c.To != 3.7 {
+		t.Errorf("gpa change = %+v", c)
+	}
+	if c := got["sat"]; c.From != nil || c.To != float64(1420) {
+		t.Errorf("sat change = %+v", c)
+	}
+
+	// a2 was upgraded (removed old + added new), a3 added, a1 removed
+	if len(d.AchievementsAdded) != 2 || len(d.AchievementsRemoved) != 2 {
+		t.Fatalf("added %+v removed %+v", d.AchievementsAdded, d.AchievementsRemoved)
+	}
+}
@@ -0,0 +1,111 @@
+package programs
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"unichance-backend-go/internal/fx"
+	"unichance-backend-go/internal/scoring"
+)
+
+var errBadCurrency = errors.New("unknown display_currency")
+
+var currencyRe = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// pickCurrency returns the display currency: requested when given (it must
+// have an exchange rate), otherwise the first usable fallback, then USD.
+func pickCurrency(rates fx.Rates, requested string, fallbacks ...string) (string, error) {
+	if c := fx.Normalize(requested); c != "" {
+		if !currencyRe.MatchString(c) || !rates.Has(c) {
+			return "", errBadCurrency
+		}
+		return c, nil
+	}
+	for _, c := range fallbacks {
+		if c = fx.Normalize(c); currencyRe.MatchString(c) && rates.Has(c) {
+			return c, nil
+		}
+	}
+	for _, c := range fallbacks {
+		if c = fx.Normalize(c); currencyRe.MatchString(c) {
+			return c, nil
+		}
+	}
+	return "USD", nil
+}
+
+// noRateError: tuition filters and sorting compare amounts in the display
+// currency, and programs priced in a currency without a rate would silently
+// drop out of them.
+type noRateError struct {
+	Display string
+	Missing []string
+}
+
+func (e *noRateError) Error() string {
+	return "no exchange rate from " + strings.Join(e.Missing, ", ") + " to " + e.Display +
+		"; tuition filters and sorting need fx rates (cmd/import_fx)"
+}
+
+// checkTuitionRates returns a *noRateError unless every tuition currency in
+// the catalogue converts to display.
+func (h Handler) checkTuitionRates(ctx context.Context, rates fx.Rates, display string) error {
+	currencies, err := h.Repo.TuitionCurrencies(ctx)
+	if err != nil {
+		return err
+	}
+	return missingRates(rates, currencies, display)
+}
+
+func missingRates(rates fx.Rates, currencies []string, display string) error {
+	var missing []string
+	for _, c := range currencies {
+		if _, err := rates.Convert(1, c, display); err != nil {
+			missing = append(missing, c)
+		}
+	}
+	if len(missing) > 0 {
+		return &noRateError{Display: display, Missing: missing}
+	}
+	return nil
+}
+
+// convertToDisplay puts the student's budget and every program's tuition
+// into currency before matching. Amounts without a rate keep their own
+// currency, and ComputeMatch won't compare them.
+func convertToDisplay(rates fx.Rates, currency string, student *scoring.EnrichedStudentProfile, programs []EnrichedProgramData) {
+	if student.BudgetYear != nil && student.BudgetCurrency != nil {
+		if v, err := rates.Convert(*student.BudgetYear, *student.BudgetCurrency, currency); err == nil {
+			student.BudgetYear, student.BudgetCurrency = &v, &currency
+		}
+	}
+	for i := range programs {
+		p := &programs[i]
+		if p.TuitionAmount != nil && p.TuitionCurrency != nil {
+			if v, err := rates.Convert(*p.TuitionAmount, *p.TuitionCurrency, currency); err == nil {
+				p.TuitionAmount, p.TuitionCurrency = &v, &currency
+				p.Program.TuitionConverted, p.Program.DisplayCurrency = &v, currency
+			}
+		}
+		// living costs follow the tuition; without a rate they are unknown
+		if p.LivingCosts != nil && p.TuitionCurrency != nil {
+			p.LivingCosts = convertLiving(rates, *p.LivingCosts, p.LivingCurrency, *p.TuitionCurrency)
+			p.LivingCurrency = *p.TuitionCurrency
+		}
+	}
+}
+
+// convertLiving converts yearly living costs; nil when there is no rate.
+func convertLiving(rates fx.Rates, l scoring.LivingCosts, from, to string) *scoring.LivingCosts {
+	out := l
+	for _, v := range []*float64{&out.Housing, &out.Food, &out.Other, &out.Insurance, &out.Visa} {
+		c, err := rates.Convert(*v, from, to)
+		if err != nil {
+			return nil
+		}
+		*v = c
+	}
+	return &out
+}
@@ -0,0 +1,73 @@
+package programs
+
+import (
+	"testing"
+	"time"
+
+	"unichance-backend-go/internal/fx"
+	"unichance-backend-go/internal/scoring"
+)
+
+func TestConvertToDisplay(t *testing.T) {
+	rates := fx.NewRates([]fx.Rate{
+		{Currency: "USD", Date: time.Now(), PerEUR: 1.1},
+		{Currency: "KZT", Date: time.Now(), PerEUR: 500},
+	})
+	kzt, eur, gbp := "KZT", "EUR", "GBP"
+	budget, tuition, other := 11_000_000.0, 20000.0, 9000.0
+	student := scoring.EnrichedStudentProfile{BudgetYear: &budget, BudgetCurrency: &kzt}
+	programs := []EnrichedProgramData{
+		{TuitionAmount: &tuition, TuitionCurrency: &eur},
+		{TuitionAmount: &other, TuitionCurrency: &gbp}, // no rate
+	}
+
+	convertToDisplay(rates, "USD", &student, programs)
+
+	if *student.BudgetYear != 24200 || *student.BudgetCurrency != "USD" {
+		t.Errorf("budget = %v %s, want 24200 USD", *student.BudgetYear, *student.BudgetCurrency)
+	}
+	if *programs[0].TuitionAmount != 22000 || programs[0].Program.DisplayCurrency != "USD" {
+		t.Errorf("tuition = %v, want 22000 USD", *programs[0].TuitionAmount)
+	}
+	if *programs[1].TuitionCurrency != "GBP" || programs[1].Program.TuitionConverted != nil {
+		t.Error("tuition without a rate must keep its currency")
+	}
+}
+
+func TestPickCurrency(t *testing.T) {
+	rates := fx.NewRates([]fx.Rate{{Currency: "KZT", Date: time.Now(), PerEUR: 500}})
+	tests := []struct {
+		requested string
+		fallbacks []string
+		want      string
+		wantErr   bool
+	}{
+		{"kzt", nil, "KZT", false},
+		{"GBP", nil, "", true},
+		{"US", nil, "", true},
+		{"", []string{"GBP", "KZT"}, "KZT", false},
+		{"", []string{"USD"}, "USD", false}, // no rates loaded: same-currency only
+		{"", nil, "USD", false},
+	}
+	for _, tt := range tests {
+		got, err := pickCurrency(rates, tt.requested, tt.fallbacks...)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("pickCurrency(%q, %v) = %q, %v", tt.requested, tt.fallbacks, got, err)
+		}
+	}
+}
+
+func TestMissingRates(t *testing.T) {
+	rates := fx.NewRates([]fx.Rate{{Currency: "KZT", Date: time.Now(), PerEUR: 500}})
+	if err := missingRates(rates, []string{"EUR", "KZT"}, "KZT"); err != nil {
+		t.Errorf("all rates known: %v", err)
+	}
+	if err := missingRates(fx.NewRates(nil), []string{"USD"}, "USD"); err != nil {
+		t.Errorf("same currency needs no rate: %v", err)
+	}
+	err := missingRates(rates, []string{"EUR", "GBP", "USD"}, "KZT")
+	noRate, ok := err.(*noRateError)
+	if !ok || len(noRate.Missing) != 2 || noRate.Missing[0] != "GBP" {
+		t.Errorf("missing GBP and USD: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package programs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+
+	"unichance-backend-go/internal/fx"
+	"unichance-backend-go/internal/livingcost"
+	"unichance-backend-go/internal/scoring"
+)
+
+var errProgramNotFound = errors.New("program not found")
+
+// ProgramDetail is a program with its full yearly cost of attendance.
+type ProgramDetail struct {
+	ProgramCard
+	Currency string `json:"currency"` // of cost_of_attendance
+	// tuition + living costs in the city; null when tuition is unknown
+	CostOfAttendance *scoring.CostOfAttendance `json:"cost_of_attendance"`
+}
+
+// GetByID loads a program with the living costs of its city.
+func (r Repo) GetByID(ctx context.Context, id string) (ProgramCard, livingcost.Scanned, error) {
+	var it ProgramCard
+	var living livingcost.Scanned
+	dest := []any{
+		&it.ID, &it.Title, &it.DegreeLevel, &it.Field, &it.Language,
+		&it.TuitionAmount, &it.TuitionCurrency,
+		&it.HasScholarship, &it.ScholarshipType, &it.ScholarshipPercentMin, &it.ScholarshipPercentMax,
+		&it.UniversityName, &it.CountryCode, &it.City, &it.QSRank, &it.THERank, &it.UniversityID,
+	}
+	err := r.DB.QueryRow(ctx, `
+    SELECT
+      p.id, p.title, p.degree_level::text, p.field, p.language,
+      p.tuition_amount, p.tuition_currency::text,
+      p.has_scholarship, p.scholarship_type, p.scholarship_percent_min, p.scholarship_percent_max,
+      u.name, u.country_code, u.city, u.qs_rank, u.the_rank, p.university_id,
+      `+livingcost.Columns+`
+    FROM programs p
+    JOIN universities u ON u.id = p.university_id
+    `+livingcost.LateralSQL+`
+    WHERE p.id::text = $1
+  `, id).Scan(append(dest, living.Dest()...)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return it, living, errProgramNotFound
+	}
+	return it, living, err
+}
+
+// detailCost puts tuition and living costs into one currency: the display
+// currency when the tuition converts, else the tuition's own.
+func detailCost(rates fx.Rates, display string, card ProgramCard, living livingcost.Scanned) ProgramDetail {
+	d := ProgramDetail{ProgramCard: card}
+	if card.TuitionAmount == nil {
+		return d
+	}
+	tuition, currency := *card.TuitionAmount, ""
+	if card.TuitionCurrency != nil {
+		currency = *card.TuitionCurrency
+		if v, err := rates.Convert(tuition, currency, display); err == nil {
+			tuition, currency = v, display
+			d.TuitionConverted, d.DisplayCurrency = &v, display
+		}
+	}
+
+	var lc *scoring.LivingCosts
+	if l, from, ok := living.Yearly(); ok {
+		if currency == "" {
+			currency = from
+		}
+		lc = convertLiving(rates, l, from, currency)
+	}
+	coa := scoring.TotalCostOfAttendance(tuition, lc)
+	d.Currency, d.CostOfAttendance = currency, &coa
+	return d
+}
+
+// Get is the program detail view: GET /programs/:id[?display_currency=].
+func (h Handler) Get(c echo.Context) error {
+	ctx := c.Request().Context()
+	rates, err := h.FX.Latest(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	display, err := pickCurrency(rates, c.QueryParam("display_currency"), h.DisplayCurrency)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	card, living, err := h.Repo.GetByID(ctx, c.Param("id"))
+	if err == errProgramNotFound {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, detailCost(rates, display, card, living))
+}
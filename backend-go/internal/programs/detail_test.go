@@ -0,0 +1,35 @@
+package programs
+
+import (
+	"testing"
+	"time"
+
+	"unichance-backend-go/internal/fx"
+	"unichance-backend-go/internal/livingcost"
+)
+
+func TestDetailCost(t *testing.T) {
+	rates := fx.NewRates([]fx.Rate{{Currency: "USD", Date: time.Now(), PerEUR: 1.1}})
+	eur, gbp := "EUR", "GBP"
+	tuition, housing := 1000.0, 500.0
+	card := ProgramCard{TuitionAmount: &tuition, TuitionCurrency: &eur}
+	living := livingcost.Scanned{Currency: &eur, HousingMonth: &housing}
+
+	d := detailCost(rates, "USD", card, living)
+	if d.Currency != "USD" || d.CostOfAttendance == nil || d.CostOfAttendance.Total != 7700 {
+		t.Errorf("EUR -> USD: %s %+v", d.Currency, d.CostOfAttendance)
+	}
+
+	// tuition without a rate stays in its own currency; EUR living costs
+	// can't be added to it
+	card.TuitionCurrency = &gbp
+	d = detailCost(rates, "USD", card, living)
+	if d.Currency != "GBP" || d.CostOfAttendance.Total != 1000 || d.CostOfAttendance.LivingIncluded {
+		t.Errorf("GBP: %s %+v", d.Currency, d.CostOfAttendance)
+	}
+
+	card.TuitionAmount = nil
+	if d = detailCost(rates, "USD", card, living); d.CostOfAttendance != nil {
+		t.Error("no tuition: cost of attendance must be null")
+	}
+}
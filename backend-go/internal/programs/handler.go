@@ -1,13 +1,18 @@
 package programs
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
 
+	"unichance-backend-go/internal/fx"
 	"unichance-backend-go/internal/middleware"
 	"unichance-backend-go/internal/profile"
 	"unichance-backend-go/internal/scoring"
@@ -17,6 +22,12 @@ type Handler struct {
 	Repo        Repo
 	DB          *pgxpool.Pool
 	ProfileRepo profile.Repo
+	FX          fx.Service
+	Scorer      scoring.Scorer
+
+	// DisplayCurrency is the default currency tuition is shown, filtered
+	// and sorted in; "" means USD.
+	DisplayCurrency string
 }
 
 func splitCSV(s string) []string {
@@ -54,6 +65,21 @@ func (h Handler) List(c echo.Context) error {
 		sch = &b
 	}
 
+	rates, err := h.FX.Latest(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	display, err := pickCurrency(rates, c.QueryParam("display_currency"), h.DisplayCurrency)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if sort := c.QueryParam("sort"); minT != nil || maxT != nil || sort == "tuition_asc" || sort == "tuition_desc" {
+		if err := h.checkTuitionRates(c.Request().Context(), rates, display); err != nil {
+			return currencyError(c, err)
+		}
+	}
+
 	params := ListParams{
 		Q:           c.QueryParam("q"),
 		Countries:   splitCSV(c.QueryParam("countries")),
@@ -66,6 +92,8 @@ func (h Handler) List(c echo.Context) error {
 		Sort:        c.QueryParam("sort"),
 		Page:        page,
 		Limit:       limit,
+
+		DisplayCurrency: display,
 	}
 
 	items, total, err := h.Repo.List(c.Request().Context(), params)
@@ -78,19 +106,141 @@ func (h Handler) List(c echo.Context) error {
 		"limit": params.Limit,
 		"total": total,
 		"items": items,
+
+		"display_currency": display,
 	})
 }
 
 // SmartSearch performs intelligent program-student matching
 func (h Handler) SmartSearch(c echo.Context) error {
 	// Require authentication
-	u := c.Get("user").(middleware.CtxUser)
+	userID := middleware.SubjectID(c)
+	ctx := c.Request().Context()
+
+	// Load student profile (optionally a past ?version= and a ?scenario_id=)
+	version, err := profile.ParseVersion(c.QueryParam("version"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "bad version",
+		})
+	}
+	studentProfile, err := h.loadStudent(ctx, userID, version, c.QueryParam("scenario_id"))
+	if err != nil {
+		return studentError(c, err)
+	}
+
+	params, rates, err := h.displayParams(c, studentProfile)
+	if err != nil {
+		return currencyError(c, err)
+	}
+	enrichedPrograms, err := h.Repo.ListEnrichedForSmartSearch(ctx, params)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	convertToDisplay(rates, params.DisplayCurrency, &studentProfile, enrichedPrograms)
+
+	// Perform smart search matching
+	response := h.Repo.PerformSmartSearch(ctx, h.Scorer, enrichedPrograms, studentProfile)
+	response.Currency = params.DisplayCurrency
+
+	// match_history keeps the student's real standing: no past versions,
+	// no what-ifs, and only the student's own searches, not a counselor
+	// viewing them. Written in the background, best effort.
+	if version == nil && c.QueryParam("scenario_id") == "" && userID == c.Get("user").(middleware.CtxUser).ID {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), matchHistoryTimeout)
+			defer cancel()
+			if err := h.Repo.SaveMatchHistory(ctx, userID, response); err != nil {
+				log.Printf("programs: match history for %s: %v", userID, err)
+			}
+		}()
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+const matchHistoryTimeout = 10 * time.Second
+
+// CompareScenario runs smart search for the base profile and for
+// ?scenario_id=, and reports how programs move between reach/target/safety.
+// Takes the same filters as SmartSearch.
+func (h Handler) CompareScenario(c echo.Context) error {
+	userID := middleware.SubjectID(c)
+	ctx := c.Request().Context()
+
+	scenarioID := c.QueryParam("scenario_id")
+	if scenarioID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "scenario_id required",
+		})
+	}
+
+	version, err := profile.ParseVersion(c.QueryParam("version"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "bad version",
+		})
+	}
+	base, err := h.loadStudent(ctx, userID, version, "")
+	if err != nil {
+		return studentError(c, err)
+	}
+	scenario, err := h.loadStudent(ctx, userID, version, scenarioID)
+	if err != nil {
+		return studentError(c, err)
+	}
 
-	// Parse query parameters
-	countries := splitCSV(c.QueryParam("countries"))
-	fields := splitCSV(c.QueryParam("fields"))
-	levels := splitCSV(c.QueryParam("levels"))
+	// same programs and currency for both runs
+	params, rates, err := h.displayParams(c, base)
+	if err != nil {
+		return currencyError(c, err)
+	}
+	enrichedPrograms, err := h.Repo.ListEnrichedForSmartSearch(ctx, params)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	convertToDisplay(rates, params.DisplayCurrency, &base, enrichedPrograms)
+	convertToDisplay(rates, params.DisplayCurrency, &scenario, nil)
 
+	baseRes := h.Repo.PerformSmartSearch(ctx, h.Scorer, enrichedPrograms, base)
+	scenarioRes := h.Repo.PerformSmartSearch(ctx, h.Scorer, enrichedPrograms, scenario)
+	baseRes.Currency, scenarioRes.Currency = params.DisplayCurrency, params.DisplayCurrency
+	cmp := CompareSmartSearch(baseRes, scenarioRes)
+	cmp.ScenarioID = scenarioID
+	return c.JSON(http.StatusOK, cmp)
+}
+
+// MatchProgram scores one program for the student exactly as smart search
+// does: same scorer, same data, amounts in the student's budget currency.
+// ok is false when there is no such program.
+func (h Handler) MatchProgram(ctx context.Context, programID string, student scoring.EnrichedStudentProfile) (scoring.MatchScore, bool, error) {
+	rates, err := h.FX.Latest(ctx)
+	if err != nil {
+		return scoring.MatchScore{}, false, err
+	}
+	budget := ""
+	if student.BudgetCurrency != nil {
+		budget = *student.BudgetCurrency
+	}
+	params := SmartSearchParams{ProgramIDs: []string{programID}, Take: 1}
+	params.DisplayCurrency, err = pickCurrency(rates, "", budget, h.DisplayCurrency)
+	if err != nil {
+		return scoring.MatchScore{}, false, err
+	}
+	list, err := h.Repo.ListEnrichedForSmartSearch(ctx, params)
+	if err != nil || len(list) == 0 {
+		return scoring.MatchScore{}, false, err
+	}
+	convertToDisplay(rates, params.DisplayCurrency, &student, list)
+	return h.Scorer.Score(student, list[0].context()), true, nil
+}
+
+// smartSearchParams reads the smart search filters from the query string.
+func smartSearchParams(c echo.Context) SmartSearchParams {
 	var maxTuition *float64
 	if v := c.QueryParam("max_tuition"); v != "" {
 		f, _ := strconv.ParseFloat(v, 64)
@@ -102,58 +252,89 @@ func (h Handler) SmartSearch(c echo.Context) error {
 		take = 30
 	}
 
-	ctx := c.Request().Context()
+	return SmartSearchParams{
+		Countries:    splitCSV(c.QueryParam("countries")),
+		Fields:       splitCSV(c.QueryParam("fields")),
+		DegreeLevels: splitCSV(c.QueryParam("levels")),
+		MaxTuition:   maxTuition,
+		Take:         take,
+	}
+}
 
-	// Load student profile
-	prof, err := h.ProfileRepo.GetMyProfile(ctx, u.ID)
+// displayParams reads the smart search filters and resolves the display
+// currency: ?display_currency, else the student's budget currency.
+func (h Handler) displayParams(c echo.Context, student scoring.EnrichedStudentProfile) (SmartSearchParams, fx.Rates, error) {
+	params := smartSearchParams(c)
+	rates, err := h.FX.Latest(c.Request().Context())
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "profile not found, please fill profile first",
-		})
+		return params, rates, err
+	}
+	budget := ""
+	if student.BudgetCurrency != nil {
+		budget = *student.BudgetCurrency
+	}
+	params.DisplayCurrency, err = pickCurrency(rates, c.QueryParam("display_currency"), budget, h.DisplayCurrency)
+	if err == nil && params.MaxTuition != nil {
+		err = h.checkTuitionRates(c.Request().Context(), rates, params.DisplayCurrency)
 	}
+	return params, rates, err
+}
 
-	// Build enriched student profile
-	studentProfile := scoring.EnrichedStudentProfile{
-		GPA:            prof.GPA,
-		GPAScale:       prof.GPAScale,
-		IELTS:          prof.IELTS,
-		TOEFL:          prof.TOEFL,
-		SAT:            prof.SAT,
-		BudgetYear:     prof.BudgetYear,
-		BudgetCurrency: prof.BudgetCurrency,
-		Citizenship:    "US", // TODO: Load from profile if available
-		GraduationYear: prof.GraduationYear,
-	}
-
-	// If profile has achievements info, parse it
-	// For now, assume achievements_count is in profile
-	studentProfile.Achievements.Olympiads = 0
-	studentProfile.Achievements.Leadership = 0
-	studentProfile.Achievements.Sports = 0
-	studentProfile.Achievements.Volunteering = 0
-	studentProfile.Achievements.Other = 0
-
-	// Load enriched programs
-	params := SmartSearchParams{
-		Countries:    countries,
-		Fields:       fields,
-		DegreeLevels: levels,
-		MaxTuition:   maxTuition,
-		Take:         take,
+func currencyError(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	var noRate *noRateError
+	if err == errBadCurrency {
+		status = http.StatusBadRequest
+	} else if errors.As(err, &noRate) {
+		status = http.StatusUnprocessableEntity
 	}
+	return c.JSON(status, map[string]string{
+		"error": err.Error(),
+	})
+}
 
-	enrichedPrograms, err := h.Repo.ListEnrichedForSmartSearch(ctx, params)
+// errNoProfile: the user has not filled their profile yet.
+var errNoProfile = errors.New("profile not found, please fill profile first")
+
+// loadStudent builds the matcher input from the user's profile (or a past
+// version) with scenarioID applied; "" means no scenario.
+func (h Handler) loadStudent(ctx context.Context, userID string, version *int, scenarioID string) (scoring.EnrichedStudentProfile, error) {
+	prof, achievements, err := h.ProfileRepo.ResolveProfile(ctx, userID, version)
+	if err == profile.ErrVersionNotFound {
+		return scoring.EnrichedStudentProfile{}, err
+	}
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return scoring.EnrichedStudentProfile{}, errNoProfile
+	}
+	prof, err = h.ProfileRepo.ApplyScenario(ctx, userID, scenarioID, prof)
+	if err != nil {
+		return scoring.EnrichedStudentProfile{}, err
 	}
 
-	// Perform smart search matching
-	response := h.Repo.PerformSmartSearch(ctx, enrichedPrograms, studentProfile)
+	return profile.Enrich(prof, achievements), nil
+}
 
-	// Save results to match_history (optional)
-	// This is optional and could be async
+// studentError answers a loadStudent error.
+func studentError(c echo.Context, err error) error {
+	status := http.StatusInternalServerError
+	switch err {
+	case profile.ErrVersionNotFound, profile.ErrScenarioNotFound:
+		status = http.StatusNotFound
+	case errNoProfile:
+		status = http.StatusBadRequest
+	}
+	return c.JSON(status, map[string]string{
+		"error": err.Error(),
+	})
+}
 
-	return c.JSON(http.StatusOK, response)
+// ScoringConfig shows the scorer in use and the match weights it reads
+// (read-only; the weights change through SCORING_WEIGHTS).
+func (h Handler) ScoringConfig(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]any{
+		"scorer":   h.Scorer.Version(),
+		"scorers":  scoring.ScorerVersions(),
+		"weights":  scoring.ActiveWeights.Get(),
+		"defaults": scoring.DefaultWeights(),
+	})
 }
@@ -9,6 +9,9 @@ type ProgramCard struct {
 
 	TuitionAmount   *float64 `json:"tuition_amount"`
 	TuitionCurrency *string  `json:"tuition_currency"`
+	// tuition in the requested display currency; null without an exchange rate
+	TuitionConverted *float64 `json:"tuition_converted"`
+	DisplayCurrency  string   `json:"display_currency,omitempty"`
 
 	HasScholarship        bool    `json:"has_scholarship"`
 	ScholarshipType       *string `json:"scholarship_type"`
@@ -5,6 +5,7 @@ import (
   "fmt"
   "strings"
 
+  "github.com/jackc/pgx/v5"
   "github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -22,6 +23,20 @@ type ListParams struct {
   Sort string
   Page int
   Limit int
+  // tuition filters and sorting work in this currency (fx_convert); ""
+  // compares raw amounts
+  DisplayCurrency string
+}
+
+// TuitionCurrencies lists the currencies programs are priced in.
+func (r Repo) TuitionCurrencies(ctx context.Context) ([]string, error) {
+  rows, err := r.DB.Query(ctx, `
+    SELECT DISTINCT tuition_currency::text FROM programs
+    WHERE tuition_currency IS NOT NULL AND tuition_amount IS NOT NULL
+    ORDER BY 1
+  `)
+  if err != nil { return nil, err }
+  return pgx.CollectRows(rows, pgx.RowTo[string])
 }
 
 func (r Repo) List(ctx context.Context, p ListParams) (items []ProgramCard, total int, err error) {
@@ -54,11 +69,19 @@ func (r Repo) List(ctx context.Context, p ListParams) (items []ProgramCard, tota
   if p.Currency != "" {
     add("programs.tuition_currency::text = $%d", p.Currency)
   }
+  // tuition in the display currency; NULL when there is no rate, so the
+  // handler checks the rates first (checkTuitionRates)
+  tuitionSQL := "programs.tuition_amount"
+  if p.DisplayCurrency != "" {
+    // inlined rather than a $n arg: the count query may not reference it
+    if !currencyRe.MatchString(p.DisplayCurrency) { return nil, 0, errBadCurrency }
+    tuitionSQL = "fx_convert(programs.tuition_amount, programs.tuition_currency::text, '" + p.DisplayCurrency + "')"
+  }
   if p.MinTuition != nil {
-    add("programs.tuition_amount >= $%d", *p.MinTuition)
+    add(tuitionSQL+" >= $%d", *p.MinTuition)
   }
   if p.MaxTuition != nil {
-    add("programs.tuition_amount <= $%d", *p.MaxTuition)
+    add(tuitionSQL+" <= $%d", *p.MaxTuition)
   }
   if p.Scholarship != nil {
     add("programs.has_scholarship = $%d", *p.Scholarship)
@@ -73,9 +96,9 @@ func (r Repo) List(ctx context.Context, p ListParams) (items []ProgramCard, tota
   } else {
     switch p.Sort {
     case "tuition_asc":
-      orderSQL = "programs.tuition_amount ASC NULLS LAST"
+      orderSQL = tuitionSQL + " ASC NULLS LAST"
     case "tuition_desc":
-      orderSQL = "programs.tuition_amount DESC NULLS LAST"
+      orderSQL = tuitionSQL + " DESC NULLS LAST"
     case "qs":
       orderSQL = "universities.qs_rank ASC NULLS LAST"
     case "the":
@@ -106,7 +129,7 @@ func (r Repo) List(ctx context.Context, p ListParams) (items []ProgramCard, tota
       programs.tuition_amount, programs.tuition_currency::text,
       programs.has_scholarship, programs.scholarship_type, programs.scholarship_percent_min, programs.scholarship_percent_max,
       universities.name, universities.country_code, universities.city, universities.qs_rank, universities.the_rank, 
-      programs.university_id
+      programs.university_id, ` + tuitionSQL + `::float8
     FROM programs
     JOIN universities ON universities.id = programs.university_id
     WHERE ` + whereSQL + `
@@ -124,8 +147,10 @@ func (r Repo) List(ctx context.Context, p ListParams) (items []ProgramCard, tota
       &it.TuitionAmount, &it.TuitionCurrency,
       &it.HasScholarship, &it.ScholarshipType, &it.ScholarshipPercentMin, &it.ScholarshipPercentMax,
       &it.UniversityName, &it.CountryCode, &it.City, &it.QSRank, &it.THERank, &it.UniversityID,
+      &it.TuitionConverted,
     )
     if err != nil { return nil, 0, err }
+    if p.DisplayCurrency != "" { it.DisplayCurrency = p.DisplayCurrency } else { it.TuitionConverted = nil }
     items = append(items, it)
   }
   return items, total, rows.Err()
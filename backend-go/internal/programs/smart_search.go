@@ -3,29 +3,44 @@ package programs
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/jackc/pgx/v5"
+
+	"unichance-backend-go/internal/admission"
+	"unichance-backend-go/internal/livingcost"
 	"unichance-backend-go/internal/scoring"
 )
 
 // FinancialResultInfo for JSON serialization
 type FinancialResultInfo struct {
 	CoveredByBudget         bool     `json:"covered_by_budget"`
-	AnnualCostUSD           float64  `json:"annual_cost_usd"`
-	BudgetUSD               float64  `json:"budget_usd"`
-	ShortfallUSD            float64  `json:"shortfall_usd"`
+	Currency                string   `json:"currency"` // of the amounts below
+	AnnualCost              float64  `json:"annual_cost"`
+	Budget                  float64  `json:"budget"`
+	Shortfall               float64  `json:"shortfall"`
 	BestScholarshipCoverage *float64 `json:"best_scholarship_coverage"`
 	NeedsScholarship        bool     `json:"needs_scholarship"`
+	// annual_cost is the total cost of attendance; this is its breakdown
+	CostOfAttendance *scoring.CostOfAttendance `json:"cost_of_attendance"`
+
+	// Deprecated: same values as annual_cost, budget and shortfall, in
+	// currency despite the name; kept until clients move over.
+	AnnualCostUSD float64 `json:"annual_cost_usd"`
+	BudgetUSD     float64 `json:"budget_usd"`
+	ShortfallUSD  float64 `json:"shortfall_usd"`
 }
 
 // ImprovementPathResult for JSON serialization
 type ImprovementPathResult struct {
-	TargetScore         int      `json:"target_score"`
-	GapPoints           int      `json:"gap_points"`
-	Next3Steps          []string `json:"next_3_steps"`
-	GpaImpactPercent    int      `json:"gpa_impact_percent"`
-	SatImpactPercent    int      `json:"sat_impact_percent"`
-	AchievImpactPercent int      `json:"achieve_impact_percent"`
+	TargetScore         int            `json:"target_score"`
+	GapPoints           int            `json:"gap_points"`
+	Next3Steps          []string       `json:"next_3_steps"` // StepCodes rendered in Russian
+	StepCodes           []scoring.Step `json:"step_codes"`
+	GpaImpactPercent    int            `json:"gpa_impact_percent"`
+	SatImpactPercent    int            `json:"sat_impact_percent"`
+	AchievImpactPercent int            `json:"achieve_impact_percent"`
 }
 
 // SmartSearchResult is a program scored and ranked for a specific student
@@ -34,10 +49,17 @@ type SmartSearchResult struct {
 	Score           int                   `json:"score"`
 	Category        string                `json:"category"` // reach, target, safety
 	Breakdown       *scoring.Breakdown    `json:"breakdown"`
-	Reasons         []string              `json:"reasons"`
+	Reasons         []string              `json:"reasons"` // ReasonCodes rendered in Russian
+	ReasonCodes     []scoring.Reason      `json:"reason_codes"`
 	Advice          string                `json:"advice"`
+	AdviceCode      string                `json:"advice_code"` // apply | realistic | improve | long_shot
+	AdviceStep      *scoring.Step         `json:"advice_step"` // the step advice_code improve recommends
 	FinancialInfo   FinancialResultInfo   `json:"financial_info"`
 	ImprovementPath ImprovementPathResult `json:"improvement_path"`
+	Confidence      string                `json:"confidence"` // profile completeness: high | medium | low
+	// estimated admit probability with a 90% interval; the category is
+	// defined on it. null without admission data for the program.
+	Probability *scoring.Probability `json:"admit_probability"`
 }
 
 // SmartSearchParams defines filters for smart search
@@ -45,8 +67,11 @@ type SmartSearchParams struct {
 	Countries    []string
 	Fields       []string
 	DegreeLevels []string
-	MaxTuition   *float64
-	Take         int // How many results to return (max 50)
+	MaxTuition   *float64 // in DisplayCurrency
+	ProgramIDs   []string // only these programs
+	Take         int      // How many results to return (max 50)
+
+	DisplayCurrency string // tuition and budget are compared in this currency
 }
 
 // SmartSearchResponse groups programs by category
@@ -55,6 +80,12 @@ type SmartSearchResponse struct {
 	Target []SmartSearchResult `json:"target"`
 	Safety []SmartSearchResult `json:"safety"`
 	Total  int                 `json:"total"`
+	// tuition and budget amounts are in this currency
+	Currency string `json:"currency"`
+	// how reliable these scores are given the profile's completeness
+	Confidence string `json:"confidence"`
+	// the scoring engine behind every score above
+	ScorerVersion string `json:"scorer_version"`
 }
 
 // EnrichedProgramData contains all data needed for smart matching
@@ -68,12 +99,22 @@ type EnrichedProgramData struct {
 	AvgIELTS             *float64
 	AvgTOEFL             *int
 	AvgSAT               *int
+	AvgACT               *int
+	AvgGRE               *int
+	AvgGMAT              *int
+	AvgDuolingo          *int
+	AvgPTE               *int
+	AvgTestDaF           *float64
 	TuitionAmount        *float64
 	TuitionCurrency      *string
+	LivingCosts          *scoring.LivingCosts // yearly, in TuitionCurrency after convertToDisplay
+	LivingCurrency       string
 	HasScholarship       bool
 	ScholarshipTypes     []string
 	ScholarshipCoverages []float64
 	EligibleCountries    []string
+	Model                *scoring.AdmissionModel // fitted by cmd/fit_admission; nil = prior
+	Requirements         scoring.Requirements
 }
 
 // ListEnrichedForSmartSearch returns programs with all matching context
@@ -100,8 +141,17 @@ func (r Repo) ListEnrichedForSmartSearch(
 		args = append(args, params.DegreeLevels)
 		where = append(where, fmt.Sprintf("p.degree_level::text = ANY($%d)", len(args)))
 	}
+	if len(params.ProgramIDs) > 0 {
+		args = append(args, params.ProgramIDs)
+		where = append(where, fmt.Sprintf("p.id::text = ANY($%d)", len(args)))
+	}
 	if params.MaxTuition != nil {
-		add("p.tuition_amount <= $%d", *params.MaxTuition)
+		if params.DisplayCurrency != "" {
+			args = append(args, *params.MaxTuition, params.DisplayCurrency)
+			where = append(where, fmt.Sprintf("fx_convert(p.tuition_amount, p.tuition_currency::text, $%d) <= $%d", len(args), len(args)-1))
+		} else {
+			add("p.tuition_amount <= $%d", *params.MaxTuition)
+		}
 	}
 
 	whereSQL := strings.Join(where, " AND ")
@@ -126,11 +176,21 @@ func (r Repo) ListEnrichedForSmartSearch(
       COALESCE(admission.avg_ielts, NULL),
       COALESCE(admission.avg_toefl, NULL),
       COALESCE(admission.avg_sat, NULL),
-      p.university_id
+      admission.avg_act, admission.avg_gre, admission.avg_gmat,
+      admission.avg_duolingo, admission.avg_pte, admission.avg_testdaf,
+      p.university_id,
+      ` + livingcost.Columns + `,
+      ` + admission.Columns + `,
+      req.min_gpa, req.min_ielts, req.min_toefl, req.min_sat,
+      req.min_act, req.min_gre, req.min_gmat, req.min_duolingo, req.min_pte, req.min_testdaf
     FROM programs p
     JOIN universities u ON u.id = p.university_id
+    ` + livingcost.LateralSQL + `
+    ` + admission.JoinSQL + `
+    LEFT JOIN requirements req ON req.program_id = p.id
     LEFT JOIN LATERAL (
-      SELECT acceptance_rate, avg_gpa, avg_ielts, avg_toefl, avg_sat
+      SELECT acceptance_rate, avg_gpa, avg_ielts, avg_toefl, avg_sat,
+             avg_act, avg_gre, avg_gmat, avg_duolingo, avg_pte, avg_testdaf
       FROM admission_stats ads
       WHERE ads.program_id = p.id
       ORDER BY year DESC
@@ -152,8 +212,10 @@ func (r Repo) ListEnrichedForSmartSearch(
 	for rows.Next() {
 		var epd EnrichedProgramData
 		var pc ProgramCard
+		var living livingcost.Scanned
+		var model admission.Scanned
 
-		err := rows.Scan(
+		dest := []any{
 			&pc.ID, &pc.Title, &pc.DegreeLevel, &pc.Field, &pc.Language,
 			&pc.TuitionAmount, &pc.TuitionCurrency,
 			&pc.HasScholarship,
@@ -164,11 +226,22 @@ func (r Repo) ListEnrichedForSmartSearch(
 			&epd.AvgIELTS,
 			&epd.AvgTOEFL,
 			&epd.AvgSAT,
+			&epd.AvgACT, &epd.AvgGRE, &epd.AvgGMAT,
+			&epd.AvgDuolingo, &epd.AvgPTE, &epd.AvgTestDaF,
 			&pc.UniversityID,
-		)
-		if err != nil {
+		}
+		dest = append(dest, living.Dest()...)
+		dest = append(dest, model.Dest()...)
+		req := &epd.Requirements
+		dest = append(dest, &req.MinGPA, &req.MinIELTS, &req.MinTOEFL, &req.MinSAT,
+			&req.MinACT, &req.MinGRE, &req.MinGMAT, &req.MinDuolingo, &req.MinPTE, &req.MinTestDaF)
+		if err := rows.Scan(dest...); err != nil {
 			return nil, err
 		}
+		epd.Model = model.Model()
+		if l, cur, ok := living.Yearly(); ok {
+			epd.LivingCosts, epd.LivingCurrency = &l, cur
+		}
 
 		pc.CountryCode = epd.CountryCode
 		pc.UniversityName = epd.UniversityName
@@ -183,60 +256,84 @@ func (r Repo) ListEnrichedForSmartSearch(
 	return results, rows.Err()
 }
 
-// PerformSmartSearch matches student profile to programs and returns ranked results
+// context is the scorer input for the program.
+func (epd EnrichedProgramData) context() scoring.ProgramContext {
+	return scoring.ProgramContext{
+		ID:                epd.Program.ID,
+		UniversityID:      epd.Program.UniversityID,
+		UniversityName:    epd.UniversityName,
+		CountryCode:       epd.CountryCode,
+		Title:             epd.Program.Title,
+		DegreeLevel:       epd.Program.DegreeLevel,
+		Field:             epd.Program.Field,
+		Language:          epd.Program.Language,
+		TuitionAmount:     epd.TuitionAmount,
+		TuitionCurrency:   epd.TuitionCurrency,
+		HasScholarship:    epd.HasScholarship,
+		CompetitiveFactor: epd.CompetitiveFactor,
+		AcceptanceRate:    epd.AcceptanceRate,
+		AvgGPA:            epd.AvgGPA,
+		AvgIELTS:          epd.AvgIELTS,
+		AvgTOEFL:          epd.AvgTOEFL,
+		AvgSAT:            epd.AvgSAT,
+		AvgACT:            epd.AvgACT,
+		AvgGRE:            epd.AvgGRE,
+		AvgGMAT:           epd.AvgGMAT,
+		AvgDuolingo:       epd.AvgDuolingo,
+		AvgPTE:            epd.AvgPTE,
+		AvgTestDaF:        epd.AvgTestDaF,
+		LivingCosts:       epd.LivingCosts,
+		Model:             epd.Model,
+		Requirements:      epd.Requirements,
+	}
+}
+
+// PerformSmartSearch scores the programs for the student and returns them
+// ranked per category
 func (r Repo) PerformSmartSearch(
 	ctx context.Context,
+	scorer scoring.Scorer,
 	enrichedPrograms []EnrichedProgramData,
 	studentProfile scoring.EnrichedStudentProfile,
 ) SmartSearchResponse {
 	response := SmartSearchResponse{
-		Reach:  []SmartSearchResult{},
-		Target: []SmartSearchResult{},
-		Safety: []SmartSearchResult{},
+		Reach:         []SmartSearchResult{},
+		Target:        []SmartSearchResult{},
+		Safety:        []SmartSearchResult{},
+		ScorerVersion: scorer.Version(),
 	}
+	confidence := scoring.AssessCompleteness(studentProfile).Confidence
+	response.Confidence = confidence
 
 	// Score each program for this student
 	allScores := []SmartSearchResult{}
 
 	for _, epd := range enrichedPrograms {
-		// Build ProgramContext for matching
-		pc := scoring.ProgramContext{
-			ID:                epd.Program.ID,
-			UniversityID:      epd.Program.UniversityID,
-			UniversityName:    epd.UniversityName,
-			CountryCode:       epd.CountryCode,
-			Title:             epd.Program.Title,
-			DegreeLevel:       epd.Program.DegreeLevel,
-			Field:             epd.Program.Field,
-			TuitionAmount:     epd.TuitionAmount,
-			TuitionCurrency:   epd.TuitionCurrency,
-			HasScholarship:    epd.HasScholarship,
-			CompetitiveFactor: epd.CompetitiveFactor,
-			AcceptanceRate:    epd.AcceptanceRate,
-			AvgGPA:            epd.AvgGPA,
-			AvgIELTS:          epd.AvgIELTS,
-			AvgTOEFL:          epd.AvgTOEFL,
-			AvgSAT:            epd.AvgSAT,
-		}
+		pc := epd.context()
 
 		// Perform matching
-		match := scoring.ComputeMatch(studentProfile, pc)
+		match := scorer.Score(studentProfile, pc)
+		response.ScorerVersion = match.ScorerVersion // with the weights version
 
 		// Convert financial status to result type
 		finInfo := FinancialResultInfo{
 			CoveredByBudget:         match.FinancialStatus.CoveredByBudget,
-			AnnualCostUSD:           match.FinancialStatus.AnnualCostUSD,
-			BudgetUSD:               match.FinancialStatus.BudgetUSD,
-			ShortfallUSD:            match.FinancialStatus.ShortfallUSD,
+			Currency:                match.FinancialStatus.Currency,
+			AnnualCost:              match.FinancialStatus.AnnualCostUSD,
+			Budget:                  match.FinancialStatus.BudgetUSD,
+			Shortfall:               match.FinancialStatus.ShortfallUSD,
 			BestScholarshipCoverage: match.FinancialStatus.BestScholarshipCoverage,
 			NeedsScholarship:        match.FinancialStatus.NeedsScholarship,
+			CostOfAttendance:        match.FinancialStatus.CostOfAttendance,
 		}
+		finInfo.AnnualCostUSD, finInfo.BudgetUSD, finInfo.ShortfallUSD = finInfo.AnnualCost, finInfo.Budget, finInfo.Shortfall
 
 		// Convert improvement path to result type
 		improvPath := ImprovementPathResult{
 			TargetScore:         match.ImprovementPath.TargetScore,
 			GapPoints:           match.ImprovementPath.GapPoints,
-			Next3Steps:          match.ImprovementPath.Next3Steps,
+			Next3Steps:          scoring.RenderSteps(match.ImprovementPath.Next3Steps),
+			StepCodes:           match.ImprovementPath.Next3Steps,
 			GpaImpactPercent:    match.ImprovementPath.GpaImpactPercent,
 			SatImpactPercent:    match.ImprovementPath.SatImpactPercent,
 			AchievImpactPercent: match.ImprovementPath.AchievImpactPercent,
@@ -248,10 +345,15 @@ func (r Repo) PerformSmartSearch(
 			Score:           match.OverallScore,
 			Category:        match.Category,
 			Breakdown:       match.BreakdownScore,
-			Reasons:         match.Reasons,
+			Reasons:         scoring.RenderReasons(match.Reasons),
+			ReasonCodes:     match.Reasons,
 			Advice:          match.Advice,
+			AdviceCode:      match.AdviceCode,
+			AdviceStep:      match.AdviceStep,
 			FinancialInfo:   finInfo,
 			ImprovementPath: improvPath,
+			Confidence:      confidence,
+			Probability:     match.Probability,
 		}
 
 		allScores = append(allScores, result)
@@ -287,3 +389,120 @@ func (r Repo) PerformSmartSearch(
 	response.Total = len(response.Reach) + len(response.Target) + len(response.Safety)
 	return response
 }
+
+// SaveMatchHistory records a smart search answer in match_history, one row
+// per program, with the scorer version that produced it. A program whose
+// latest row has the same score, category and version is skipped, so
+// repeating a search doesn't pile up identical rows.
+func (r Repo) SaveMatchHistory(ctx context.Context, userID string, res SmartSearchResponse) error {
+	batch := &pgx.Batch{}
+	for _, bucket := range [][]SmartSearchResult{res.Reach, res.Target, res.Safety} {
+		for _, it := range bucket {
+			var p *float64
+			if it.Probability != nil {
+				p = &it.Probability.P
+			}
+			batch.Queue(`
+        INSERT INTO match_history (user_id, program_id, match_score, category, breakdown, admit_probability, scorer_version)
+        SELECT $1, $2::uuid, $3, $4, $5, $6, $7
+        WHERE NOT EXISTS (
+          SELECT 1 FROM (
+            SELECT match_score, category, scorer_version FROM match_history
+            WHERE user_id = $1 AND program_id = $2::uuid
+            ORDER BY created_at DESC LIMIT 1
+          ) last
+          WHERE last.match_score = $3 AND last.category = $4 AND last.scorer_version = $7
+        )
+      `, userID, it.Program.ID, it.Score, it.Category, it.Breakdown, p, res.ScorerVersion)
+		}
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+	return r.DB.SendBatch(ctx, batch).Close()
+}
+
+// BucketCounts is how many programs landed in each category.
+type BucketCounts struct {
+	Reach  int `json:"reach"`
+	Target int `json:"target"`
+	Safety int `json:"safety"`
+}
+
+// BucketShift is one program scored differently under a scenario.
+type BucketShift struct {
+	Program      ProgramCard `json:"program"`
+	FromCategory string      `json:"from_category"`
+	ToCategory   string      `json:"to_category"`
+	FromScore    int         `json:"from_score"`
+	ToScore      int         `json:"to_score"`
+	Delta        int         `json:"delta"`
+}
+
+// ScenarioComparison is the answer of GET /programs/smart-search/compare.
+type ScenarioComparison struct {
+	ScenarioID string        `json:"scenario_id"`
+	Base       BucketCounts  `json:"base"`
+	Scenario   BucketCounts  `json:"scenario"`
+	Moved      []BucketShift `json:"moved"`   // category changed
+	Changed    []BucketShift `json:"changed"` // same category, different score
+}
+
+// CompareSmartSearch diffs two smart search runs over the same programs.
+// Moved and Changed are ordered by the size of the score change.
+func CompareSmartSearch(base, scenario SmartSearchResponse) ScenarioComparison {
+	cmp := ScenarioComparison{
+		Base:     countBuckets(base),
+		Scenario: countBuckets(scenario),
+		Moved:    []BucketShift{},
+		Changed:  []BucketShift{},
+	}
+
+	before := map[string]SmartSearchResult{}
+	for _, r := range allResults(base) {
+		before[r.Program.ID] = r
+	}
+	for _, r := range allResults(scenario) {
+		b, ok := before[r.Program.ID]
+		if !ok || (b.Category == r.Category && b.Score == r.Score) {
+			continue
+		}
+		shift := BucketShift{
+			Program:      r.Program,
+			FromCategory: b.Category,
+			ToCategory:   r.Category,
+			FromScore:    b.Score,
+			ToScore:      r.Score,
+			Delta:        r.Score - b.Score,
+		}
+		if b.Category != r.Category {
+			cmp.Moved = append(cmp.Moved, shift)
+		} else {
+			cmp.Changed = append(cmp.Changed, shift)
+		}
+	}
+
+	byDelta := func(s []BucketShift) {
+		sort.SliceStable(s, func(i, j int) bool { return abs(s[i].Delta) > abs(s[j].Delta) })
+	}
+	byDelta(cmp.Moved)
+	byDelta(cmp.Changed)
+	return cmp
+}
+
+func countBuckets(r SmartSearchResponse) BucketCounts {
+	return BucketCounts{Reach: len(r.Reach), Target: len(r.Target), Safety: len(r.Safety)}
+}
+
+func allResults(r SmartSearchResponse) []SmartSearchResult {
+	out := append([]SmartSearchResult{}, r.Reach...)
+	out = append(out, r.Target...)
+	return append(out, r.Safety...)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -300,3 +300,72 @@ func Float64Ptr(v float64) *float64 {
 func IntPtr(v int) *int {
 	return &v
 }
+
+// TestCompareSmartSearch checks bucket counts and moves between two runs
+func TestCompareSmartSearch(t *testing.T) {
+	res := func(id, category string, score int) SmartSearchResult {
+		return SmartSearchResult{Program: ProgramCard{ID: id}, Category: category, Score: score}
+	}
+	base := SmartSearchResponse{
+		Reach:  []SmartSearchResult{res("a", "reach", 35), res("b", "reach", 30)},
+		Target: []SmartSearchResult{res("c", "target", 50)},
+	}
+	scenario := SmartSearchResponse{
+		Reach:  []SmartSearchResult{res("b", "reach", 30)},
+		Target: []SmartSearchResult{res("a", "target", 45)},
+		Safety: []SmartSearchResult{res("c", "safety", 72)},
+	}
+
+	cmp := CompareSmartSearch(base, scenario)
+	if cmp.Base != (BucketCounts{Reach: 2, Target: 1}) || cmp.Scenario != (BucketCounts{Reach: 1, Target: 1, Safety: 1}) {
+		t.Errorf("counts: base %+v, scenario %+v", cmp.Base, cmp.Scenario)
+	}
+	if len(cmp.Moved) != 2 || len(cmp.Changed) != 0 {
+		t.Fatalf("moved %+v, changed %+v", cmp.Moved, cmp.Changed)
+	}
+	// biggest change first
+	if m := cmp.Moved[0]; m.Program.ID != "c" || m.FromCategory != "target" || m.ToCategory != "safety" || m.Delta != 22 {
+		t.Errorf("moved[0] = %+v", m)
+	}
+}
+
+// TestSaveMatchHistorySkipsRepeats: an unchanged result is not written again.
+func TestSaveMatchHistorySkipsRepeats(t *testing.T) {
+	connStr := os.Getenv("DATABASE_URL")
+	if connStr == "" {
+		t.Skip("DATABASE_URL not set, skipping database tests")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	var programID, userID string
+	if err := pool.QueryRow(ctx, `SELECT id::text FROM programs LIMIT 1`).Scan(&programID); err != nil {
+		t.Skip("no programs seeded")
+	}
+	err = pool.QueryRow(ctx, `INSERT INTO users(email, password_hash) VALUES ($1, '') RETURNING id`,
+		"history-"+programID[:8]+"@example.com").Scan(&userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Exec(context.Background(), `DELETE FROM users WHERE id=$1`, userID)
+
+	r := Repo{DB: pool}
+	res := SmartSearchResponse{ScorerVersion: "match-v1@test", Target: []SmartSearchResult{
+		{Program: ProgramCard{ID: programID}, Score: 60, Category: "target"},
+	}}
+	for _, score := range []int{60, 60, 72} {
+		res.Target[0].Score = score
+		if err := r.SaveMatchHistory(ctx, userID, res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var rows int
+	pool.QueryRow(ctx, `SELECT count(*) FROM match_history WHERE user_id=$1`, userID).Scan(&rows)
+	if rows != 2 {
+		t.Errorf("rows = %d, want 2 (the repeat skipped)", rows)
+	}
+}
@@ -0,0 +1,87 @@
+package scoring
+
+import "sort"
+
+// Confidence levels of a match score, driven by how complete the profile is.
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// MissingField is a profile gap that lowers match accuracy. Any one of
+// Fields fills it (e.g. IELTS or TOEFL for the language test).
+type MissingField struct {
+	Key     string   `json:"key"`
+	Fields  []string `json:"fields"`
+	Weight  int      `json:"weight"` // completeness points it is worth
+	Message string   `json:"message"`
+}
+
+// Completeness rates how much of what ComputeMatch uses the profile has.
+type Completeness struct {
+	Percent    int            `json:"percent"`
+	Confidence string         `json:"confidence"`
+	Missing    []MissingField `json:"missing"` // biggest impact first
+}
+
+// completenessItem weights follow ComputeMatch: GPA drives both the academic
+// and the competitive component, language and tests the rest of academic,
+// budget the financial one. Weights add up to 100.
+type completenessItem struct {
+	MissingField
+	has func(EnrichedStudentProfile) bool
+}
+
+var completenessItems = []completenessItem{
+	{MissingField{Key: "gpa", Fields: []string{"gpa"}, Weight: 35,
+		Message: "Укажите GPA: без него академическая и конкурсная оценки приблизительны"},
+		func(s EnrichedStudentProfile) bool { _, ok := GPAUS4(s.GPA, s.GradingSystem, s.GPAScale); return ok }},
+	{MissingField{Key: "language_test", Fields: []string{"ielts", "toefl", "pte", "duolingo", "testdaf"}, Weight: 20,
+		Message: "Добавьте результат языкового теста (IELTS, TOEFL, PTE, Duolingo или TestDaF)"},
+		func(s EnrichedStudentProfile) bool {
+			return s.TestDaF != nil || len(englishResults(s.IELTS, s.TOEFL, s.PTE, s.Duolingo)) > 0
+		}},
+	{MissingField{Key: "budget", Fields: []string{"budget_year", "budget_currency"}, Weight: 15,
+		Message: "Укажите годовой бюджет и валюту, чтобы оценить финансовую доступность"},
+		func(s EnrichedStudentProfile) bool { return s.BudgetYear != nil && s.BudgetCurrency != nil }},
+	{MissingField{Key: "standardized_test", Fields: []string{"sat", "act", "gre_verbal", "gre_quant", "gmat"}, Weight: 15,
+		Message: "Добавьте SAT/ACT (бакалавриат) или GRE/GMAT (магистратура)"},
+		func(s EnrichedStudentProfile) bool { return s.SAT != nil || s.ACT != nil || hasGRE(s) || s.GMAT != nil }},
+	{MissingField{Key: "achievements", Fields: []string{"achievements"}, Weight: 10,
+		Message: "Добавьте достижения: олимпиады, лидерство, спорт, волонтёрство"},
+		func(s EnrichedStudentProfile) bool { return s.Achievements != AchievementCounts{} }},
+	{MissingField{Key: "citizenship", Fields: []string{"citizenship_code"}, Weight: 3,
+		Message: "Укажите гражданство: от него зависят некоторые стипендии"},
+		func(s EnrichedStudentProfile) bool { return s.Citizenship != "" }},
+	{MissingField{Key: "graduation_year", Fields: []string{"graduation_year"}, Weight: 2,
+		Message: "Укажите год окончания учёбы"},
+		func(s EnrichedStudentProfile) bool { return s.GraduationYear != nil }},
+}
+
+// AssessCompleteness returns the weighted completeness of a profile and the
+// confidence of the scores computed from it. Without a GPA confidence is
+// low whatever else is filled; high needs GPA, a language test and 80%.
+func AssessCompleteness(s EnrichedStudentProfile) Completeness {
+	c := Completeness{Missing: []MissingField{}}
+	present := map[string]bool{}
+	for _, it := range completenessItems {
+		if it.has(s) {
+			c.Percent += it.Weight
+			present[it.Key] = true
+		} else {
+			c.Missing = append(c.Missing, it.MissingField)
+		}
+	}
+	sort.SliceStable(c.Missing, func(i, j int) bool { return c.Missing[i].Weight > c.Missing[j].Weight })
+
+	switch {
+	case !present["gpa"] || c.Percent < 50:
+		c.Confidence = ConfidenceLow
+	case present["language_test"] && c.Percent >= 80:
+		c.Confidence = ConfidenceHigh
+	default:
+		c.Confidence = ConfidenceMedium
+	}
+	return c
+}
@@ -0,0 +1,53 @@
+package scoring
+
+import "testing"
+
+func TestAssessCompleteness(t *testing.T) {
+	gpa, ielts, budget, cur := 3.6, 7.0, 20000.0, "USD"
+	sat, year := 1400, 2026
+
+	full := EnrichedStudentProfile{
+		GPA: &gpa, IELTS: &ielts, SAT: &sat, BudgetYear: &budget, BudgetCurrency: &cur,
+		Citizenship: "KZ", GraduationYear: &year, Achievements: AchievementCounts{Olympiads: 1},
+	}
+	noGPA := full
+	noGPA.GPA = nil
+	noLang := full
+	noLang.IELTS = nil
+	testDaF := 4
+	german := noLang
+	german.TestDaF = &testDaF
+
+	tests := []struct {
+		name       string
+		s          EnrichedStudentProfile
+		percent    int
+		confidence string
+		first      string
+	}{
+		{"empty", EnrichedStudentProfile{}, 0, ConfidenceLow, "gpa"},
+		{"full", full, 100, ConfidenceHigh, ""},
+		{"no gpa", noGPA, 65, ConfidenceLow, "gpa"},
+		{"no language test", noLang, 80, ConfidenceMedium, "language_test"},
+		{"testdaf counts", german, 100, ConfidenceHigh, ""},
+		{"gpa and ielts only", EnrichedStudentProfile{GPA: &gpa, IELTS: &ielts}, 55, ConfidenceMedium, "budget"},
+	}
+	for _, tt := range tests {
+		got := AssessCompleteness(tt.s)
+		if got.Percent != tt.percent || got.Confidence != tt.confidence {
+			t.Errorf("%s: %d%% %s, want %d%% %s", tt.name, got.Percent, got.Confidence, tt.percent, tt.confidence)
+		}
+		first := ""
+		if len(got.Missing) > 0 {
+			first = got.Missing[0].Key
+		}
+		if first != tt.first {
+			t.Errorf("%s: first missing %q, want %q", tt.name, first, tt.first)
+		}
+		for i := 1; i < len(got.Missing); i++ {
+			if got.Missing[i].Weight > got.Missing[i-1].Weight {
+				t.Errorf("%s: missing fields not ranked by weight", tt.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package scoring
+
+// Official concordance tables. A student's result on one test is converted to
+// the scale the program reference is stored in only when there is no
+// like-for-like pair (see ComputeMatch).
+
+// step maps every score >= From to To; tables are sorted by From descending.
+type step struct {
+	From int
+	To   float64
+}
+
+func lookupStep(table []step, v int) (float64, bool) {
+	for _, s := range table {
+		if v >= s.From {
+			return s.To, true
+		}
+	}
+	return 0, false
+}
+
+// actToSAT is the ACT/College Board 2018 concordance, ACT composite -> SAT total.
+var actToSAT = map[int]int{
+	36: 1590, 35: 1540, 34: 1500, 33: 1460, 32: 1430, 31: 1400, 30: 1370,
+	29: 1340, 28: 1310, 27: 1280, 26: 1240, 25: 1210, 24: 1180, 23: 1140,
+	22: 1110, 21: 1080, 20: 1040, 19: 1010, 18: 970, 17: 930, 16: 890,
+	15: 850, 14: 800, 13: 760, 12: 710, 11: 670, 10: 630, 9: 590,
+}
+
+// satToACT is the same concordance read the other way: SAT total ranges -> ACT.
+var satToACT = []step{
+	{1570, 36}, {1530, 35}, {1490, 34}, {1450, 33}, {1420, 32}, {1390, 31},
+	{1360, 30}, {1330, 29}, {1300, 28}, {1260, 27}, {1230, 26}, {1200, 25},
+	{1160, 24}, {1130, 23}, {1100, 22}, {1060, 21}, {1030, 20}, {990, 19},
+	{960, 18}, {920, 17}, {880, 16}, {830, 15}, {780, 14}, {730, 13},
+	{690, 12}, {650, 11}, {620, 10}, {590, 9},
+}
+
+// toeflToIELTS is the ETS TOEFL iBT / IELTS comparison table.
+var toeflToIELTS = []step{
+	{118, 9.0}, {115, 8.5}, {110, 8.0}, {102, 7.5}, {94, 7.0}, {79, 6.5},
+	{60, 6.0}, {46, 5.5}, {35, 5.0}, {32, 4.5}, {0, 4.0},
+}
+
+// duolingoToIELTS is the Duolingo English Test / IELTS concordance.
+var duolingoToIELTS = []step{
+	{160, 8.5}, {155, 8.0}, {145, 7.5}, {135, 7.0}, {125, 6.5}, {115, 6.0},
+	{100, 5.5}, {90, 5.0}, {80, 4.5}, {65, 4.0},
+}
+
+// pteToIELTS is Pearson's 2023 PTE Academic / IELTS concordance.
+var pteToIELTS = []step{
+	{89, 8.5}, {84, 8.0}, {76, 7.5}, {66, 7.0}, {56, 6.5}, {46, 6.0},
+	{36, 5.5}, {29, 5.0}, {23, 4.5},
+}
+
+// ACTToSAT converts an ACT composite (1-36) to its SAT total equivalent.
+func ACTToSAT(act int) (int, bool) {
+	if act > 36 {
+		return 0, false
+	}
+	sat, ok := actToSAT[act]
+	return sat, ok
+}
+
+// SATToACT converts an SAT total (400-1600) to its ACT composite equivalent.
+func SATToACT(sat int) (int, bool) {
+	if sat > 1600 {
+		return 0, false
+	}
+	act, ok := lookupStep(satToACT, sat)
+	return int(act), ok
+}
+
+// TOEFLToIELTS converts a TOEFL iBT total (0-120) to an IELTS band.
+func TOEFLToIELTS(toefl int) (float64, bool) {
+	if toefl < 0 || toefl > 120 {
+		return 0, false
+	}
+	return lookupStep(toeflToIELTS, toefl)
+}
+
+// DuolingoToIELTS converts a Duolingo English Test score (10-160) to an IELTS band.
+func DuolingoToIELTS(det int) (float64, bool) {
+	if det > 160 {
+		return 0, false
+	}
+	return lookupStep(duolingoToIELTS, det)
+}
+
+// PTEToIELTS converts a PTE Academic overall score (10-90) to an IELTS band.
+func PTEToIELTS(pte int) (float64, bool) {
+	if pte > 90 {
+		return 0, false
+	}
+	return lookupStep(pteToIELTS, pte)
+}
+
+// englishResult is one English test result with its IELTS equivalent.
+type englishResult struct {
+	Test  string // "IELTS", "TOEFL", "Duolingo", "PTE"
+	Score float64
+	IELTS float64
+}
+
+// englishResults lists the given English test results in preference order
+// (IELTS, TOEFL, PTE, Duolingo), skipping unset or unconvertible ones.
+func englishResults(ielts *float64, toefl, pte, duolingo *int) []englishResult {
+	var out []englishResult
+	if ielts != nil {
+		out = append(out, englishResult{"IELTS", *ielts, *ielts})
+	}
+	add := func(test string, v *int, conv func(int) (float64, bool)) {
+		if v == nil {
+			return
+		}
+		if band, ok := conv(*v); ok {
+			out = append(out, englishResult{test, float64(*v), band})
+		}
+	}
+	add("TOEFL", toefl, TOEFLToIELTS)
+	add("PTE", pte, PTEToIELTS)
+	add("Duolingo", duolingo, DuolingoToIELTS)
+	return out
+}
+
+// satEquivalent returns the SAT total, or the ACT composite converted to SAT.
+func satEquivalent(sat, act *int) (int, bool) {
+	if sat != nil {
+		return *sat, true
+	}
+	if act != nil {
+		return ACTToSAT(*act)
+	}
+	return 0, false
+}
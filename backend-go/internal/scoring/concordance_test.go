@@ -0,0 +1,137 @@
+package scoring
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestACTSATConcordance(t *testing.T) {
+	for act, sat := range map[int]int{36: 1590, 30: 1370, 24: 1180, 9: 590} {
+		if got, ok := ACTToSAT(act); !ok || got != sat {
+			t.Errorf("ACTToSAT(%d) = %d, %v; want %d", act, got, ok, sat)
+		}
+		// the table is consistent both ways
+		if back, ok := SATToACT(sat); !ok || back != act {
+			t.Errorf("SATToACT(%d) = %d, %v; want %d", sat, back, ok, act)
+		}
+	}
+	if got, _ := SATToACT(1410); got != 31 {
+		t.Errorf("SATToACT(1410) = %d, want 31", got)
+	}
+	if _, ok := ACTToSAT(5); ok {
+		t.Error("ACT 5 is below the concordance table")
+	}
+	if _, ok := SATToACT(1700); ok {
+		t.Error("SAT 1700 is out of range")
+	}
+}
+
+func TestEnglishConcordance(t *testing.T) {
+	tests := []struct {
+		name string
+		conv func(int) (float64, bool)
+		in   int
+		want float64
+	}{
+		{"toefl", TOEFLToIELTS, 120, 9.0},
+		{"toefl", TOEFLToIELTS, 100, 7.0},
+		{"toefl", TOEFLToIELTS, 79, 6.5},
+		{"duolingo", DuolingoToIELTS, 160, 8.5},
+		{"duolingo", DuolingoToIELTS, 120, 6.0},
+		{"duolingo", DuolingoToIELTS, 125, 6.5},
+		{"pte", PTEToIELTS, 90, 8.5},
+		{"pte", PTEToIELTS, 65, 6.5},
+		{"pte", PTEToIELTS, 66, 7.0},
+	}
+	for _, tt := range tests {
+		got, ok := tt.conv(tt.in)
+		if !ok || got != tt.want {
+			t.Errorf("%s %d -> IELTS %.1f, %v; want %.1f", tt.name, tt.in, got, ok, tt.want)
+		}
+	}
+	if _, ok := DuolingoToIELTS(50); ok {
+		t.Error("Duolingo 50 is below the concordance table")
+	}
+}
+
+func TestMatchComparesLikeForLike(t *testing.T) {
+	hasReason := func(m MatchScore, prefix string) bool {
+		for _, r := range m.Reasons {
+			if strings.HasPrefix(r.Text(), prefix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Duolingo vs a program IELTS average: compared in IELTS equivalents
+	m := ComputeMatch(
+		EnrichedStudentProfile{Duolingo: intPtr(135)},
+		ProgramContext{Language: "English", AvgIELTS: f64Ptr(7.0)},
+	)
+	if m.BreakdownScore.Language != 16 || !hasReason(m, "Duolingo (в пересчёте на IELTS) соответствует") {
+		t.Errorf("duolingo: language %d, reasons %v", m.BreakdownScore.Language, m.Reasons)
+	}
+
+	// TOEFL vs a TOEFL average wins over the IELTS conversion
+	m = ComputeMatch(
+		EnrichedStudentProfile{IELTS: f64Ptr(6.0), TOEFL: intPtr(105)},
+		ProgramContext{Language: "English", AvgTOEFL: intPtr(100)},
+	)
+	if m.BreakdownScore.Language != 16 || !hasReason(m, "TOEFL соответствует") {
+		t.Errorf("toefl: language %d, reasons %v", m.BreakdownScore.Language, m.Reasons)
+	}
+
+	// German-taught: TestDaF counts, English tests don't
+	m = ComputeMatch(
+		EnrichedStudentProfile{IELTS: f64Ptr(8.5)},
+		ProgramContext{Language: "German"},
+	)
+	if m.BreakdownScore.Language != 0 || !hasReason(m, "Программа на немецком") {
+		t.Errorf("german without testdaf: language %d, reasons %v", m.BreakdownScore.Language, m.Reasons)
+	}
+	m = ComputeMatch(
+		EnrichedStudentProfile{TestDaF: intPtr(5)},
+		ProgramContext{Language: "German", AvgTestDaF: f64Ptr(4)},
+	)
+	if m.BreakdownScore.Language != 20 {
+		t.Errorf("testdaf 5 vs 4: language %d, want 20", m.BreakdownScore.Language)
+	}
+
+	// master programs use GRE, not SAT
+	m = ComputeMatch(
+		EnrichedStudentProfile{SAT: intPtr(1600), GREVerbal: intPtr(160), GREQuant: intPtr(165)},
+		ProgramContext{DegreeLevel: "Master", AvgSAT: intPtr(1400), AvgGRE: intPtr(320)},
+	)
+	if m.BreakdownScore.Tests != 12 || !hasReason(m, "GRE соответствует") {
+		t.Errorf("gre: tests %d, reasons %v", m.BreakdownScore.Tests, m.Reasons)
+	}
+
+	// ACT against an SAT average goes through the concordance
+	m = ComputeMatch(
+		EnrichedStudentProfile{ACT: intPtr(33)},
+		ProgramContext{DegreeLevel: "Bachelor", AvgSAT: intPtr(1350)},
+	)
+	if m.BreakdownScore.Tests != 15 || !hasReason(m, "ACT (в пересчёте на SAT) выше") {
+		t.Errorf("act: tests %d, reasons %v", m.BreakdownScore.Tests, m.Reasons)
+	}
+}
+
+func TestComputeUsesProgramTests(t *testing.T) {
+	res := Compute(Profile{GMAT: intPtr(650)}, Requirements{MinGMAT: intPtr(600)})
+	if res.Breakdown.Tests != 15 {
+		t.Errorf("gmat 650: tests %d, want 15", res.Breakdown.Tests)
+	}
+	res = Compute(Profile{SAT: intPtr(1500)}, Requirements{MinGRE: intPtr(310)})
+	if res.Breakdown.Tests != 0 {
+		t.Errorf("SAT must not count for a GRE program, tests %d", res.Breakdown.Tests)
+	}
+	res = Compute(Profile{ACT: intPtr(30)}, Requirements{MinACT: intPtr(32)})
+	found := false
+	for _, r := range res.Reasons {
+		found = found || r.Text() == "ACT ниже минимальных требований"
+	}
+	if !found || res.Breakdown.Tests != 17 {
+		t.Errorf("act 30: tests %d, reasons %v", res.Breakdown.Tests, res.Reasons)
+	}
+}
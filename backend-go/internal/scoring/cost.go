@@ -0,0 +1,59 @@
+package scoring
+
+import "math"
+
+// LivingCosts are the yearly non-tuition costs at a program's location
+// (living_costs row), in the program's tuition currency.
+type LivingCosts struct {
+	Housing   float64 // rent, 12 months
+	Food      float64
+	Other     float64 // transport, utilities, phone
+	Insurance float64
+	Visa      float64 // visa / residence permit fees
+}
+
+// YearlyLivingCosts turns monthly housing/food/other plus yearly insurance
+// and visa fees into LivingCosts.
+func YearlyLivingCosts(housingMonth, foodMonth, otherMonth, insuranceYear, visaFee float64) LivingCosts {
+	return LivingCosts{
+		Housing:   round2(housingMonth * 12),
+		Food:      round2(foodMonth * 12),
+		Other:     round2(otherMonth * 12),
+		Insurance: round2(insuranceYear),
+		Visa:      round2(visaFee),
+	}
+}
+
+// Total of the living costs.
+func (l LivingCosts) Total() float64 {
+	return round2(l.Housing + l.Food + l.Other + l.Insurance + l.Visa)
+}
+
+// CostOfAttendance is a full year at a program: tuition plus living costs.
+type CostOfAttendance struct {
+	Tuition   float64 `json:"tuition"`
+	Housing   float64 `json:"housing"`
+	Food      float64 `json:"food"`
+	Other     float64 `json:"other"`
+	Insurance float64 `json:"insurance"`
+	Visa      float64 `json:"visa"`
+	Total     float64 `json:"total"`
+	// LivingIncluded is false when there is no living-cost data for the
+	// location and Total is tuition only.
+	LivingIncluded bool `json:"living_included"`
+}
+
+// TotalCostOfAttendance is the yearly cost of a program: tuition plus the
+// living costs of its city (nil = unknown, tuition only).
+func TotalCostOfAttendance(tuition float64, living *LivingCosts) CostOfAttendance {
+	c := CostOfAttendance{Tuition: round2(tuition), Total: round2(tuition)}
+	if living != nil {
+		c.Housing, c.Food, c.Other = living.Housing, living.Food, living.Other
+		c.Insurance, c.Visa = living.Insurance, living.Visa
+		c.Total = round2(tuition + living.Total())
+		c.LivingIncluded = true
+	}
+	return c
+}
+
+func round2(v float64) float64 { return math.Round(v*100) / 100 }
@@ -0,0 +1,41 @@
+package scoring
+
+import "testing"
+
+func TestTotalCostOfAttendance(t *testing.T) {
+	munich := YearlyLivingCosts(850, 300, 150, 1300, 75)
+	if got := munich.Total(); got != 16975 {
+		t.Errorf("Munich living total = %v, want 16975", got)
+	}
+
+	coa := TotalCostOfAttendance(3000, &munich)
+	if coa.Total != 19975 || !coa.LivingIncluded || coa.Housing != 10200 {
+		t.Errorf("with living costs: %+v", coa)
+	}
+	coa = TotalCostOfAttendance(3000, nil)
+	if coa.Total != 3000 || coa.LivingIncluded {
+		t.Errorf("tuition only: %+v", coa)
+	}
+}
+
+// TestFinancialUsesCostOfAttendance: a budget that covers tuition but not
+// rent in the city is no longer "fully covered"
+func TestFinancialUsesCostOfAttendance(t *testing.T) {
+	eur := "EUR"
+	student := EnrichedStudentProfile{BudgetYear: f64Ptr(12000), BudgetCurrency: &eur}
+	munich := YearlyLivingCosts(850, 300, 150, 1300, 75)
+	program := ProgramContext{TuitionAmount: f64Ptr(3000), TuitionCurrency: &eur}
+
+	if got := ComputeMatch(student, program); got.FinancialScore != 20 {
+		t.Errorf("tuition only: financial score %d, want 20", got.FinancialScore)
+	}
+
+	program.LivingCosts = &munich
+	got := ComputeMatch(student, program)
+	if got.FinancialStatus.CoveredByBudget || got.FinancialScore >= 20 {
+		t.Errorf("with Munich living costs: score %d, covered=%v", got.FinancialScore, got.FinancialStatus.CoveredByBudget)
+	}
+	if got.FinancialStatus.AnnualCostUSD != 19975 || got.FinancialStatus.ShortfallUSD != 7975 {
+		t.Errorf("annual cost %v, shortfall %v", got.FinancialStatus.AnnualCostUSD, got.FinancialStatus.ShortfallUSD)
+	}
+}
@@ -0,0 +1,127 @@
+package scoring
+
+import (
+	"math"
+	"sort"
+)
+
+// GradingSystem converts grades of one national/international system to a
+// normalized 0..1 value (position within the scale, 1 = best) and to a US 4.0
+// equivalent, which is what program averages (AvgGPA, MinGPA) are stored in.
+type GradingSystem struct {
+	Code     string  `json:"code"`
+	Name     string  `json:"name"`
+	Min      float64 `json:"min"`      // worst valid grade
+	Max      float64 `json:"max"`      // best valid grade (numerically smallest when Inverted)
+	Inverted bool    `json:"inverted"` // smaller is better (German 1.0-5.0)
+
+	// us4 maps grade -> US 4.0 by linear interpolation; points sorted by grade
+	us4 [][2]float64
+}
+
+// Normalize returns the grade's position in the scale, 0 (worst) .. 1 (best).
+func (g GradingSystem) Normalize(v float64) float64 {
+	lo, hi := g.Min, g.Max
+	if g.Inverted {
+		lo, hi = g.Max, g.Min
+		return clamp01((hi - v) / (hi - lo))
+	}
+	return clamp01((v - lo) / (hi - lo))
+}
+
+// ToUS4 converts a grade to its US 4.0 equivalent.
+func (g GradingSystem) ToUS4(v float64) float64 {
+	if len(g.us4) == 0 {
+		return 4 * g.Normalize(v)
+	}
+	pts := g.us4
+	if v <= pts[0][0] {
+		return pts[0][1]
+	}
+	for i := 1; i < len(pts); i++ {
+		if v <= pts[i][0] {
+			x0, y0, x1, y1 := pts[i-1][0], pts[i-1][1], pts[i][0], pts[i][1]
+			return y0 + (y1-y0)*(v-x0)/(x1-x0)
+		}
+	}
+	return pts[len(pts)-1][1]
+}
+
+// Valid reports whether v is inside the system's range.
+func (g GradingSystem) Valid(v float64) bool {
+	lo, hi := g.Min, g.Max
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	return v >= lo && v <= hi
+}
+
+var gradingSystems = map[string]GradingSystem{
+	"us_4": {
+		Code: "us_4", Name: "US GPA (0-4.0)", Min: 0, Max: 4,
+	},
+	"percent": {
+		// common US admissions conversion of percentage marks
+		Code: "percent", Name: "Percentage (0-100)", Min: 0, Max: 100,
+		us4: [][2]float64{{0, 0}, {64, 0}, {65, 1.0}, {67, 1.3}, {70, 1.7}, {73, 2.0}, {77, 2.3}, {80, 2.7}, {83, 3.0}, {87, 3.3}, {90, 3.7}, {93, 4.0}, {100, 4.0}},
+	},
+	"kz_5": {
+		// Kazakhstan school marks: 5 отлично, 4 хорошо, 3 удовлетворительно, 2 fail
+		Code: "kz_5", Name: "Kazakhstan 5-point", Min: 2, Max: 5,
+		us4: [][2]float64{{2, 0}, {3, 2.0}, {4, 3.0}, {5, 4.0}},
+	},
+	"ib_45": {
+		// IB Diploma total points, 24 is the pass mark
+		Code: "ib_45", Name: "IB Diploma (0-45)", Min: 0, Max: 45,
+		us4: [][2]float64{{0, 0}, {23, 0}, {24, 2.0}, {28, 2.7}, {32, 3.3}, {36, 3.7}, {40, 4.0}, {45, 4.0}},
+	},
+	"a_level": {
+		// average A-level grade as points: A*=6, A=5, B=4, C=3, D=2, E=1
+		Code: "a_level", Name: "A-levels (E=1 .. A*=6)", Min: 0, Max: 6,
+		us4: [][2]float64{{0, 0}, {1, 1.0}, {2, 1.7}, {3, 2.3}, {4, 3.0}, {5, 3.7}, {6, 4.0}},
+	},
+	"de_5": {
+		// German 1.0 (sehr gut) .. 4.0 (ausreichend), 5.0 fail;
+		// modified Bavarian formula between 1.0 and 4.0
+		Code: "de_5", Name: "German (1.0 best - 5.0)", Min: 5, Max: 1, Inverted: true,
+		us4: [][2]float64{{1, 4.0}, {4, 1.0}, {4.01, 0}, {5, 0}},
+	},
+}
+
+// LookupGradingSystem finds a system by code.
+func LookupGradingSystem(code string) (GradingSystem, bool) {
+	g, ok := gradingSystems[code]
+	return g, ok
+}
+
+// GradingSystems lists the registry, sorted by code.
+func GradingSystems() []GradingSystem {
+	out := make([]GradingSystem, 0, len(gradingSystems))
+	for _, g := range gradingSystems {
+		out = append(out, g)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Code < out[j].Code })
+	return out
+}
+
+// ResolveGradingSystem picks the profile's system; profiles without one (or an
+// unknown code) fall back to a linear 0..scale system, scale defaulting to 4.0.
+func ResolveGradingSystem(code string, scale *float64) GradingSystem {
+	if g, ok := gradingSystems[code]; ok {
+		return g
+	}
+	max := 4.0
+	if scale != nil && *scale > 0 {
+		max = *scale
+	}
+	return GradingSystem{Code: "", Name: "Linear", Min: 0, Max: max}
+}
+
+// GPAUS4 converts a profile GPA to its US 4.0 equivalent; false when no GPA.
+func GPAUS4(gpa *float64, system string, scale *float64) (float64, bool) {
+	if gpa == nil {
+		return 0, false
+	}
+	g := ResolveGradingSystem(system, scale)
+	return math.Round(g.ToUS4(*gpa)*100) / 100, true
+}
@@ -0,0 +1,79 @@
+package scoring
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGradingSystemConversions(t *testing.T) {
+	tests := []struct {
+		system   string
+		grade    float64
+		wantUS4  float64
+		wantNorm float64
+	}{
+		{"us_4", 3.6, 3.6, 0.9},
+		{"percent", 95, 4.0, 0.95},
+		{"percent", 85, 3.15, 0.85},
+		{"percent", 50, 0, 0.5},
+		{"kz_5", 5, 4.0, 1},
+		{"kz_5", 4.5, 3.5, 5.0 / 6},
+		{"kz_5", 3, 2.0, 1.0 / 3},
+		{"ib_45", 38, 3.85, 38.0 / 45},
+		{"ib_45", 20, 0, 20.0 / 45},
+		{"a_level", 5, 3.7, 5.0 / 6}, // straight A
+		{"de_5", 1.0, 4.0, 1},
+		{"de_5", 2.5, 2.5, 0.625},
+		{"de_5", 4.0, 1.0, 0.25},
+		{"de_5", 5.0, 0, 0},
+	}
+	for _, tt := range tests {
+		g, ok := LookupGradingSystem(tt.system)
+		if !ok {
+			t.Fatalf("unknown system %s", tt.system)
+		}
+		if got := g.ToUS4(tt.grade); math.Abs(got-tt.wantUS4) > 0.01 {
+			t.Errorf("%s %.2f: ToUS4 = %.3f, want %.3f", tt.system, tt.grade, got, tt.wantUS4)
+		}
+		if got := g.Normalize(tt.grade); math.Abs(got-tt.wantNorm) > 0.001 {
+			t.Errorf("%s %.2f: Normalize = %.3f, want %.3f", tt.system, tt.grade, got, tt.wantNorm)
+		}
+	}
+}
+
+func TestGermanScaleIsInverted(t *testing.T) {
+	g, _ := LookupGradingSystem("de_5")
+	if g.ToUS4(1.3) <= g.ToUS4(2.0) {
+		t.Fatal("1.3 must convert higher than 2.0")
+	}
+	if !g.Valid(1.0) || !g.Valid(5.0) || g.Valid(0.7) || g.Valid(6) {
+		t.Fatal("de_5 range should be 1.0..5.0")
+	}
+}
+
+func TestResolveGradingSystemLegacyScale(t *testing.T) {
+	scale := 5.0
+	if got, _ := GPAUS4(f64Ptr(4.5), "", &scale); got != 3.6 {
+		t.Errorf("linear 4.5/5 = %.2f, want 3.60", got)
+	}
+	if got, _ := GPAUS4(f64Ptr(3.2), "", nil); got != 3.2 {
+		t.Errorf("no scale defaults to 4.0, got %.2f", got)
+	}
+	if _, ok := GPAUS4(nil, "percent", nil); ok {
+		t.Error("nil GPA must report !ok")
+	}
+}
+
+// Same student, different systems: the matcher should see comparable GPAs.
+func TestMatcherUsesGradingSystem(t *testing.T) {
+	program := ProgramContext{AvgGPA: f64Ptr(3.5)}
+	kz := ComputeMatch(EnrichedStudentProfile{GPA: f64Ptr(4.8), GradingSystem: "kz_5"}, program)
+	de := ComputeMatch(EnrichedStudentProfile{GPA: f64Ptr(1.3), GradingSystem: "de_5"}, program)
+	us := ComputeMatch(EnrichedStudentProfile{GPA: f64Ptr(3.8), GPAScale: f64Ptr(4)}, program)
+
+	for name, r := range map[string]MatchScore{"kz_5": kz, "de_5": de, "us": us} {
+		if r.BreakdownScore.GPA != 25 {
+			t.Errorf("%s: GPA points = %d, want 25", name, r.BreakdownScore.GPA)
+		}
+	}
+}
@@ -2,8 +2,12 @@ package scoring
 
 import (
 	"math"
+	"strings"
 )
 
+// gpaEpsilon absorbs float noise in band edges (3.7+0.1 != 3.8)
+const gpaEpsilon = 1e-9
+
 // ProgramContext contains all information needed to evaluate a program for a student
 type ProgramContext struct {
 	ID                   string
@@ -13,6 +17,7 @@ type ProgramContext struct {
 	Title                string
 	DegreeLevel          string
 	Field                string
+	Language             string // teaching language, e.g. "English", "German"
 	TuitionAmount        *float64
 	TuitionCurrency      *string
 	HasScholarship       bool
@@ -22,8 +27,17 @@ type ProgramContext struct {
 	AvgIELTS             *float64
 	AvgTOEFL             *int
 	AvgSAT               *int
-	ScholarshipCoverages []float64 // e.g., [50, 100] for partial and full
-	EligibleCitizenships []string  // e.g., ["KZ", "RU"] or empty for all
+	AvgACT               *int
+	AvgGRE               *int // verbal + quant, 260-340
+	AvgGMAT              *int
+	AvgDuolingo          *int
+	AvgPTE               *int
+	AvgTestDaF           *float64        // TDN 3-5
+	LivingCosts          *LivingCosts    // yearly, in TuitionCurrency; nil = unknown
+	Model                *AdmissionModel // fitted admission model; nil = prior from AcceptanceRate
+	Requirements         Requirements    // program minimums (requirements table), used by points-v1
+	ScholarshipCoverages []float64       // e.g., [50, 100] for partial and full
+	EligibleCitizenships []string        // e.g., ["KZ", "RU"] or empty for all
 	RequiresPortfolio    bool
 	MinWorkExperienceYrs *int
 }
@@ -32,20 +46,34 @@ type ProgramContext struct {
 type EnrichedStudentProfile struct {
 	GPA            *float64
 	GPAScale       *float64
+	GradingSystem  string // registry code (see grading.go); "" = linear GPA/GPAScale
 	IELTS          *float64
 	TOEFL          *int
 	SAT            *int
+	ACT            *int
+	GREVerbal      *int
+	GREQuant       *int
+	GMAT           *int
+	Duolingo       *int
+	PTE            *int
+	TestDaF        *int // lowest TDN section level, 3-5
 	BudgetYear     *float64
 	BudgetCurrency *string
 	Citizenship    string // Country code, e.g., "KZ"
 	GraduationYear *int   // For timeline validation
-	Achievements   struct {
-		Olympiads    int // Weight: 3x
-		Leadership   int // Weight: 2x
-		Sports       int // Weight: 1.5x
-		Volunteering int // Weight: 1x
-		Other        int // Weight: 1x
-	}
+	Achievements   AchievementCounts
+	// free-text awards or achievements summary, beyond the structured list
+	HasAchievementNotes bool
+}
+
+// AchievementCounts are level-weighted achievement counters (see
+// profile.AggregateAchievements); one national olympiad counts as 2 olympiads.
+type AchievementCounts struct {
+	Olympiads    int // Weight: 3x
+	Leadership   int // Weight: 2x
+	Sports       int // Weight: 1.5x
+	Volunteering int // Weight: 1x
+	Other        int // Weight: 1x
 }
 
 // MatchScore is the output of program-student matching
@@ -60,6 +88,9 @@ type MatchScore struct {
 	// Overall evaluation
 	OverallScore int    // 0-100
 	Category     string // "impossible" | "reach" | "target" | "safety"
+	// estimated admit probability; Category follows it when set. nil when
+	// the program has no acceptance rate nor a fitted model.
+	Probability *Probability
 
 	// Recommendations for improvement
 	ImprovementPath struct {
@@ -71,41 +102,52 @@ type MatchScore struct {
 		GpaImpactPercent    int      // +X% if GPA improved
 		SatImpactPercent    int      // +X% if SAT added
 		AchievImpactPercent int      // +X% if achievements added
-		Next3Steps          []string // e.g., ["Raise GPA by 0.3", "Add SAT score"]
+		Next3Steps          []Step   // coded steps; RenderSteps for the text
 	}
 
 	// Context for user understanding
-	Reasons []string // "Why this score"
-	Advice  string   // Actionable advice
-
-	// Financial details
-	FinancialStatus struct {
-		CoveredByBudget         bool
-		AnnualCostUSD           float64
-		BudgetUSD               float64
-		ShortfallUSD            float64
-		BestScholarshipCoverage *float64
-		NeedsScholarship        bool
-	}
+	Reasons    []Reason // "Why this score"; RenderReasons for the text
+	Advice     string   // Actionable advice (AdviceText of AdviceCode and AdviceStep)
+	AdviceCode string   // apply | realistic | improve | long_shot
+	AdviceStep *Step    // the step AdviceImprove recommends, nil otherwise
+
+	// Financial details. Amounts are in Currency (the caller converts tuition
+	// and budget to one currency first); the USD suffix is historical.
+	FinancialStatus FinancialStatus
+
+	// Scorer that produced this score (set by Scorer.Score, "" when
+	// ComputeMatch is called directly)
+	ScorerVersion string
 }
 
-// Step in improvement path
-type Next3Steps struct {
-	Action       string
-	ImpactPoints int
+type FinancialStatus struct {
+	CoveredByBudget         bool
+	Currency                string
+	AnnualCostUSD           float64
+	BudgetUSD               float64
+	ShortfallUSD            float64
+	BestScholarshipCoverage *float64
+	NeedsScholarship        bool
+	CostOfAttendance        *CostOfAttendance // AnnualCostUSD broken down
 }
 
-// Compute performs intelligent matching of student to program
+// ComputeMatch performs intelligent matching of student to program with
+// the built-in weights.
 func ComputeMatch(student EnrichedStudentProfile, program ProgramContext) MatchScore {
+	return ComputeMatchWith(builtinWeights, student, program)
+}
+
+// ComputeMatchWith is ComputeMatch with the given (validated) weights.
+func ComputeMatchWith(w Weights, student EnrichedStudentProfile, program ProgramContext) MatchScore {
 	score := 0
-	reasons := []string{}
+	reasons := []Reason{}
+
+	// GPA in US 4.0 equivalents, the scale program averages are stored in
+	studentGPA, hasGPA := GPAUS4(student.GPA, student.GradingSystem, student.GPAScale)
 
 	// ===== PHASE 1: IMPOSSIBLE FILTER =====
-	if student.GPA != nil && student.GPAScale != nil && *student.GPAScale > 0 {
-		normalizedGPA := (*student.GPA) / (*student.GPAScale)
-		if program.AvgGPA != nil && normalizedGPA < (*program.AvgGPA-0.5) {
-			// Can still try, but very unlikely
-		}
+	if hasGPA && program.AvgGPA != nil && studentGPA < (*program.AvgGPA-0.5) {
+		// Can still try, but very unlikely
 	}
 
 	// Citizenship check for country-specific scholarships
@@ -118,7 +160,7 @@ func ComputeMatch(student EnrichedStudentProfile, program ProgramContext) MatchS
 			}
 		}
 		if !found {
-			reasons = append(reasons, "Стипендия доступна только для определённых стран")
+			reasons = append(reasons, newReason(ComponentFinancial, "financial_scholarship_citizenships", SeverityNegative))
 		}
 	}
 
@@ -128,104 +170,72 @@ func ComputeMatch(student EnrichedStudentProfile, program ProgramContext) MatchS
 	academicScore := 0
 
 	// GPA component (0-25 points)
-	if student.GPA != nil && student.GPAScale != nil && *student.GPAScale > 0 {
-		normalizedGPA := (*student.GPA) / (*student.GPAScale)
+	if hasGPA {
 		var gpaScore int
+		band := w.Academic.GPA
 
 		if program.AvgGPA != nil {
 			avgGPA := *program.AvgGPA
-			if normalizedGPA >= avgGPA+0.1 {
-				gpaScore = 25
-				reasons = append(reasons, "GPA выше средней по программе")
-			} else if normalizedGPA >= avgGPA {
-				gpaScore = 20
-				reasons = append(reasons, "GPA соответствует среднему показателю")
-			} else if normalizedGPA >= avgGPA-0.3 {
-				gpaScore = 12
-				reasons = append(reasons, "GPA ниже среднего, но близко")
+			gpaReason := func(code, severity string) Reason {
+				return newReason(ComponentGPA, code, severity, "value", round3(studentGPA), "program_avg", avgGPA)
+			}
+			if studentGPA >= avgGPA+band.AboveMargin-gpaEpsilon {
+				gpaScore = band.Above
+				reasons = append(reasons, gpaReason("gpa_above_average", SeverityPositive))
+			} else if studentGPA >= avgGPA-gpaEpsilon {
+				gpaScore = band.At
+				reasons = append(reasons, gpaReason("gpa_at_average", SeverityPositive))
+			} else if studentGPA >= avgGPA-band.CloseMargin-gpaEpsilon {
+				gpaScore = band.Close
+				reasons = append(reasons, gpaReason("gpa_below_average_close", SeverityNeutral))
 			} else {
-				gpaScore = int(math.Max(0, float64(normalizedGPA/avgGPA*20)))
-				reasons = append(reasons, "GPA существенно ниже требуемого")
+				// stays below the "close" band: ratio < 1 so at most Close
+				gpaScore = int(math.Max(0, studentGPA/avgGPA*float64(band.Close)))
+				reasons = append(reasons, gpaReason("gpa_well_below_average", SeverityNegative))
 			}
 		} else {
-			// No reference data, use normalized 0-25
-			gpaScore = int(math.Round(25 * clamp01(normalizedGPA)))
+			// No reference data, use normalized 0-Above
+			gpaScore = int(math.Round(float64(band.Above) * clamp01(studentGPA/4.0)))
 		}
 
 		academicScore += gpaScore
 		breakdown.GPA = gpaScore
 	} else {
 		breakdown.GPA = 0
-		reasons = append(reasons, "GPA не указан, точность оценки снижена")
+		reasons = append(reasons, newReason(ComponentGPA, "gpa_missing", SeverityNeutral))
 	}
 
 	// Language component (0-20 points)
 	langScore := 0
-	if student.IELTS != nil {
-		if program.AvgIELTS != nil {
-			avgIELTS := *program.AvgIELTS
-			if *student.IELTS >= avgIELTS+0.5 {
-				langScore = 20
-				reasons = append(reasons, "IELTS выше среднего показателя")
-			} else if *student.IELTS >= avgIELTS {
-				langScore = 16
-				reasons = append(reasons, "IELTS соответствует требованиям")
-			} else if *student.IELTS >= avgIELTS-0.5 {
-				langScore = 10
-				reasons = append(reasons, "IELTS ниже среднего, но близко")
-			} else {
-				langScore = int(math.Max(0, (*student.IELTS/avgIELTS)*10))
-				reasons = append(reasons, "IELTS значительно ниже требуемого")
+	if isGermanTaught(program.Language) {
+		// German-taught: TestDaF, English tests don't count
+		if student.TestDaF != nil {
+			ref := 4.0 // TDN 4 in every section is the usual admission level
+			if program.AvgTestDaF != nil {
+				ref = *program.AvgTestDaF
 			}
+			var reason Reason
+			langScore, reason = bandScore(float64(*student.TestDaF), ref, w.Academic.Language["testdaf"], languageReason("TestDaF"))
+			reasons = append(reasons, reason)
 		} else {
-			langScore = int(math.Round(20 * clamp01(*student.IELTS/9.0)))
+			reasons = append(reasons, newReason(ComponentLanguage, "language_testdaf_missing", SeverityNegative))
 		}
-	} else if student.TOEFL != nil {
-		if program.AvgTOEFL != nil {
-			avgTOEFL := *program.AvgTOEFL
-			if *student.TOEFL >= avgTOEFL+10 {
-				langScore = 20
-				reasons = append(reasons, "TOEFL выше среднего показателя")
-			} else if *student.TOEFL >= avgTOEFL {
-				langScore = 16
-				reasons = append(reasons, "TOEFL соответствует требованиям")
-			} else if *student.TOEFL >= avgTOEFL-10 {
-				langScore = 10
-				reasons = append(reasons, "TOEFL ниже среднего, но близко")
-			} else {
-				langScore = int(math.Max(0, (float64(*student.TOEFL)/float64(avgTOEFL))*10))
-				reasons = append(reasons, "TOEFL значительно ниже требуемого")
-			}
-		} else {
-			langScore = int(math.Round(20 * clamp01(float64(*student.TOEFL)/120.0)))
+	} else if len(englishResults(student.IELTS, student.TOEFL, student.PTE, student.Duolingo)) > 0 {
+		var reason Reason
+		langScore, reason = englishScore(w, student, program)
+		if reason.Code != "" {
+			reasons = append(reasons, reason)
 		}
 	} else {
-		reasons = append(reasons, "Языковой тест не указан (IELTS/TOEFL)")
+		reasons = append(reasons, newReason(ComponentLanguage, "language_missing", SeverityNegative))
 	}
 	academicScore += langScore
 	breakdown.Language = langScore
 
-	// Standardized tests component (0-15 points - SAT/GRE)
-	testScore := 0
-	if student.SAT != nil {
-		if program.AvgSAT != nil {
-			avgSAT := *program.AvgSAT
-			if *student.SAT >= avgSAT+100 {
-				testScore = 15
-				reasons = append(reasons, "SAT выше среднего показателя")
-			} else if *student.SAT >= avgSAT {
-				testScore = 12
-				reasons = append(reasons, "SAT соответствует требованиям")
-			} else if *student.SAT >= avgSAT-100 {
-				testScore = 7
-				reasons = append(reasons, "SAT ниже среднего, но близко")
-			} else {
-				testScore = int(math.Max(0, (float64(*student.SAT)/float64(avgSAT))*7))
-				reasons = append(reasons, "SAT значительно ниже требуемого")
-			}
-		} else {
-			testScore = int(math.Round(15 * clamp01(float64(*student.SAT)/1600.0)))
-		}
+	// Standardized tests component (0-15 points): SAT/ACT, GRE/GMAT for master programs
+	testScore, testReason := testsScore(w, student, program)
+	if testReason.Code != "" {
+		reasons = append(reasons, testReason)
 	}
 	academicScore += testScore
 	breakdown.Tests = testScore
@@ -233,120 +243,116 @@ func ComputeMatch(student EnrichedStudentProfile, program ProgramContext) MatchS
 	// ===== PHASE 3: COMPETITIVE SCORING (0-30 points) =====
 	competitiveScore := 0
 
-	if program.AcceptanceRate != nil && program.AvgGPA != nil && student.GPA != nil && student.GPAScale != nil {
+	if program.AcceptanceRate != nil && program.AvgGPA != nil && hasGPA {
 		acceptanceRate := *program.AcceptanceRate
-		normalizedStudentGPA := (*student.GPA) / (*student.GPAScale)
 		avgCompetitorGPA := *program.AvgGPA
 
 		// Calculate how student ranks vs average admitted
-		studentVsAvg := (normalizedStudentGPA - avgCompetitorGPA) / avgCompetitorGPA
+		studentVsAvg := (studentGPA - avgCompetitorGPA) / avgCompetitorGPA
 
 		// Adjust based on competition level
 		competitionMultiplier := program.CompetitiveFactor // 0.8 - 1.4
 
-		if studentVsAvg >= 0.1 {
-			// Student is above average
-			competitiveScore = int(30 * (1 - (acceptanceRate / 100.0)) * 1.2 / competitionMultiplier)
-		} else if studentVsAvg >= 0 {
-			// Student is at average
-			competitiveScore = int(30 * (1 - (acceptanceRate / 100.0)) / competitionMultiplier)
-		} else if studentVsAvg >= -0.1 {
-			// Student is slightly below
-			competitiveScore = int(30 * (1 - (acceptanceRate / 100.0)) * 0.7 / competitionMultiplier)
-		} else {
-			// Student is well below average
-			competitiveScore = int(30 * (1 - (acceptanceRate / 100.0)) * 0.3 / competitionMultiplier)
-		}
+		// above average gets more, well below much less (weights tiers)
+		competitiveScore = int(float64(w.Competitive.Max) * (1 - (acceptanceRate / 100.0)) *
+			w.Competitive.factor(studentVsAvg) / competitionMultiplier)
 
-		if acceptanceRate > 30 {
-			reasons = append(reasons, "Низкий уровень конкуренции при поступлении")
-		} else if acceptanceRate > 10 {
-			reasons = append(reasons, "Средний уровень конкуренции")
+		if acceptanceRate > w.Competitive.LowCompetitionRate {
+			reasons = append(reasons, newReason(ComponentCompetition, "competition_low", SeverityPositive, "acceptance_rate", acceptanceRate))
+		} else if acceptanceRate > w.Competitive.MediumCompetitionRate {
+			reasons = append(reasons, newReason(ComponentCompetition, "competition_medium", SeverityNeutral, "acceptance_rate", acceptanceRate))
 		} else {
-			reasons = append(reasons, "Высокий уровень конкуренции")
+			reasons = append(reasons, newReason(ComponentCompetition, "competition_high", SeverityNegative, "acceptance_rate", acceptanceRate))
 		}
 	} else {
-		competitiveScore = 15 // Default middle value
+		competitiveScore = w.Competitive.Default
 	}
-	competitiveScore = int(math.Max(0, math.Min(30, float64(competitiveScore))))
+	competitiveScore = int(math.Max(0, math.Min(float64(w.Competitive.Max), float64(competitiveScore))))
 
 	// ===== PHASE 4: FINANCIAL SCORING (0-20 points) =====
 	financialScore := 0
-	financialStatus := struct {
-		CoveredByBudget         bool
-		AnnualCostUSD           float64
-		BudgetUSD               float64
-		ShortfallUSD            float64
-		BestScholarshipCoverage *float64
-		NeedsScholarship        bool
-	}{}
-
-	if program.TuitionAmount != nil && student.BudgetYear != nil {
-		annualCost := *program.TuitionAmount
+	financialStatus := FinancialStatus{}
+
+	// budget and tuition only compare in the same currency
+	comparable := program.TuitionAmount != nil && student.BudgetYear != nil
+	if comparable && !sameCurrency(program.TuitionCurrency, student.BudgetCurrency) {
+		comparable = false
+		reasons = append(reasons, newReason(ComponentFinancial, "financial_no_fx_rate", SeverityNeutral))
+	}
+
+	if comparable {
+		// full yearly cost: tuition + living costs at the program's city
+		coa := TotalCostOfAttendance(*program.TuitionAmount, program.LivingCosts)
+		financialStatus.CostOfAttendance = &coa
+		if !coa.LivingIncluded {
+			reasons = append(reasons, newReason(ComponentFinancial, "financial_no_living_costs", SeverityNeutral))
+		}
+		annualCost := coa.Total
 		budget := *student.BudgetYear
+		finReason := func(code, severity string) Reason {
+			return newReason(ComponentFinancial, code, severity, "budget", budget, "cost", annualCost,
+				"currency", currencyCode(program.TuitionCurrency, student.BudgetCurrency))
+		}
 
 		// Simple budget coverage percentage
 		coverage := budget / annualCost
-		financialScore = int(math.Round(20 * clamp01(coverage)))
+		fw := w.Financial
 
 		if coverage >= 1.0 {
-			financialScore = 20
-			reasons = append(reasons, "Бюджет полностью покрывает обучение")
+			financialScore = fw.Max
+			reasons = append(reasons, finReason("financial_budget_covers", SeverityPositive))
 			financialStatus.CoveredByBudget = true
-		} else if coverage >= 0.7 {
-			financialScore = 14
-			reasons = append(reasons, "Бюджет покрывает основную часть, возможен кредит")
+		} else if coverage >= fw.PartialCoverage {
+			financialScore = fw.Partial
+			reasons = append(reasons, finReason("financial_budget_mostly_covers", SeverityNeutral))
 		} else if program.HasScholarship && len(program.ScholarshipCoverages) > 0 {
 			maxCoverage := program.ScholarshipCoverages[len(program.ScholarshipCoverages)-1]
-			scholarshipAmount := annualCost * (maxCoverage / 100.0)
+			// scholarships cover tuition, not living costs
+			scholarshipAmount := coa.Tuition * (maxCoverage / 100.0)
 			totalAvailable := budget + scholarshipAmount
-			if totalAvailable >= annualCost*0.8 {
-				financialScore = 16
-				reasons = append(reasons, "Стипендия + бюджет могут покрыть обучение")
+			if totalAvailable >= annualCost*fw.ScholarshipCoverage {
+				financialScore = fw.WithScholarship
+				reasons = append(reasons, finReason("financial_scholarship_covers", SeverityPositive).with("scholarship_coverage", maxCoverage))
 				financialStatus.BestScholarshipCoverage = &maxCoverage
 				financialStatus.NeedsScholarship = true
 			} else {
-				financialScore = 6
-				reasons = append(reasons, "Даже со стипендией требуется дополнительное финансирование")
+				financialScore = fw.Short
+				reasons = append(reasons, finReason("financial_scholarship_short", SeverityNegative).with("scholarship_coverage", maxCoverage))
 				financialStatus.NeedsScholarship = true
 			}
 		} else {
-			financialScore = 6
-			reasons = append(reasons, "Бюджет недостаточен для обучения")
+			financialScore = fw.Short
+			reasons = append(reasons, finReason("financial_budget_short", SeverityNegative))
 		}
 
+		financialStatus.Currency = currencyCode(program.TuitionCurrency, student.BudgetCurrency)
 		financialStatus.AnnualCostUSD = annualCost
 		financialStatus.BudgetUSD = budget
 		if coverage < 1.0 {
 			financialStatus.ShortfallUSD = annualCost - budget
 		}
 	} else if program.HasScholarship {
-		financialScore = 12
-		reasons = append(reasons, "Программа предоставляет стипендии")
+		financialScore = w.Financial.ScholarshipUnknownPrice
+		reasons = append(reasons, newReason(ComponentFinancial, "financial_scholarships_available", SeverityPositive))
 		financialStatus.NeedsScholarship = true
 	}
 
 	// ===== PHASE 5: SPECIAL FACTORS (0-10 points) =====
-	extraScore := 0
-
-	// Calculate weighted achievements
-	achievementWeight := student.Achievements.Olympiads*3 +
-		student.Achievements.Leadership*2 +
-		student.Achievements.Sports + int(float64(student.Achievements.Volunteering)*0.8) +
-		student.Achievements.Other
-
-	if achievementWeight >= 5 {
-		extraScore = 10
-		reasons = append(reasons, "Сильный набор достижений (олимпиады, лидерство, спорт)")
-	} else if achievementWeight >= 3 {
-		extraScore = 7
-		reasons = append(reasons, "Хороший набор достижений")
-	} else if achievementWeight >= 1 {
-		extraScore = 4
-		reasons = append(reasons, "Есть достижения, можно добавить")
-	} else {
-		extraScore = 0
-		reasons = append(reasons, "Рекомендуется добавить достижения для повышения шансов")
+	// Calculate weighted achievements; the reason follows the tier reached
+	achievementWeight, tier, extraScore := w.Achievements.points(student.Achievements)
+	tiers := w.Achievements.Tiers
+	extras := func(code, severity string) Reason {
+		return newReason(ComponentExtras, code, severity, "value", achievementWeight)
+	}
+	switch {
+	case tier == 0:
+		reasons = append(reasons, extras("extras_strong", SeverityPositive))
+	case tier > 0 && tier < len(tiers)-1:
+		reasons = append(reasons, extras("extras_good", SeverityPositive))
+	case tier > 0:
+		reasons = append(reasons, extras("extras_some", SeverityNeutral))
+	default:
+		reasons = append(reasons, extras("extras_missing", SeverityNegative))
 	}
 
 	breakdown.Extras = extraScore
@@ -360,12 +366,19 @@ func ComputeMatch(student EnrichedStudentProfile, program ProgramContext) MatchS
 		score = 0
 	}
 
-	// Categorize
-	category := "target"
-	if score >= 70 {
-		category = "safety"
-	} else if score < 40 {
-		category = "reach"
+	// Categorize: on the admit probability when there is admission data,
+	// otherwise on the point score
+	probability := AdmitProbability(student, program)
+	category := w.Categories.ForScore(score)
+	if probability != nil {
+		category = w.Categories.ForProbability(probability.P)
+		severity := map[string]string{"safety": SeverityPositive, "target": SeverityNeutral}[category]
+		if severity == "" {
+			severity = SeverityNegative
+		}
+		percent := func(p float64) int { return int(math.Round(p * 100)) }
+		reasons = append(reasons, newReason(ComponentCompetition, "admit_probability", severity,
+			"percent", percent(probability.P), "low_percent", percent(probability.Low), "high_percent", percent(probability.High)))
 	}
 
 	// ===== PHASE 7: RECOMMENDATIONS =====
@@ -378,57 +391,65 @@ func ComputeMatch(student EnrichedStudentProfile, program ProgramContext) MatchS
 		GpaImpactPercent    int
 		SatImpactPercent    int
 		AchievImpactPercent int
-		Next3Steps          []string
+		Next3Steps          []Step
 	}{
-		TargetScore:  70, // Default: aim for safety
+		TargetScore:  w.Categories.SafetyScore, // Default: aim for safety
 		CurrentScore: score,
-		Next3Steps:   []string{},
+		Next3Steps:   []Step{},
 	}
 
-	if score < 70 {
-		improvementPath.GapPoints = 70 - score
+	if score < w.Categories.SafetyScore {
+		improvementPath.GapPoints = w.Categories.SafetyScore - score
 
 		// GPA improvement
-		if student.GPA != nil && student.GPAScale != nil && program.AvgGPA != nil {
-			delta := *program.AvgGPA - (*student.GPA)/(*student.GPAScale)
+		if hasGPA && program.AvgGPA != nil {
+			delta := *program.AvgGPA - studentGPA // US 4.0 points
 			if delta > 0 && delta <= 0.5 {
 				improvementPath.RecommendedGPA = program.AvgGPA
 				improvementPath.GpaImpactPercent = int(delta * 30) // Each 0.1 = 3%
 				improvementPath.Next3Steps = append(improvementPath.Next3Steps,
-					"Повысить GPA на +0."+string(rune(int(delta*10))))
+					newStep(StepRaiseGPA, "delta", math.Round(delta*10)/10, "target", *program.AvgGPA))
 			}
 		}
 
 		// SAT improvement
-		if student.SAT == nil && program.AvgSAT != nil {
+		if isMaster(program.DegreeLevel) {
+			if student.GMAT == nil && !hasGRE(student) && (program.AvgGRE != nil || program.AvgGMAT != nil) {
+				improvementPath.Next3Steps = append(improvementPath.Next3Steps, newStep(StepTakeGREOrGMAT))
+			}
+		} else if student.SAT == nil && student.ACT == nil && program.AvgSAT != nil {
 			improvementPath.RecommendedSAT = program.AvgSAT
 			improvementPath.SatImpactPercent = 15
 			improvementPath.Next3Steps = append(improvementPath.Next3Steps,
-				"Сдать SAT (средний показатель в программе увеличит шансы на +15%)")
+				newStep(StepTakeSAT, "impact_percent", improvementPath.SatImpactPercent, "program_avg", *program.AvgSAT))
+		}
+
+		// German-taught programs need TestDaF
+		if isGermanTaught(program.Language) && student.TestDaF == nil {
+			improvementPath.Next3Steps = append(improvementPath.Next3Steps, newStep(StepTakeTestDaF))
 		}
 
 		// Achievements
-		if achievementWeight < 3 {
+		if len(tiers) > 1 && achievementWeight < tiers[1].Min {
 			improvementPath.AchievImpactPercent = 8
 			improvementPath.Next3Steps = append(improvementPath.Next3Steps,
-				"Добавить 2-3 достижения (олимпиада, лидерство, спорт) = +8-10%")
+				newStep(StepAddAchievements, "impact_percent", improvementPath.AchievImpactPercent, "impact_percent_max", 10))
 		}
 	}
 
 	// ===== CONSTRUCT ADVICE =====
-	advice := ""
-	if score >= 70 {
-		advice = "Хороший шанс поступления. Подавайте заявку!"
-	} else if score >= 40 {
-		advice = "Реалистичный вариант. Есть вероятность поступления. " +
-			"Убедитесь, что ваш профиль полный и все документы в порядке."
-	} else if score >= 20 {
-		advice = "Сложный вариант, но не невозможен. "
+	adviceCode := AdviceLongShot
+	var adviceStep *Step
+	if category == "safety" {
+		adviceCode = AdviceApply
+	} else if category == "target" {
+		adviceCode = AdviceRealistic
+	} else if score >= w.Categories.LongShotScore {
+		adviceCode = AdviceImprove
 		if len(improvementPath.Next3Steps) > 0 {
-			advice += "Рекомендуется: " + improvementPath.Next3Steps[0] + " Можно попробовать."
+			step := improvementPath.Next3Steps[0]
+			adviceStep = &step
 		}
-	} else {
-		advice = "Очень сложный вариант. Рекомендуется сосредоточиться на других программах."
 	}
 
 	return MatchScore{
@@ -438,10 +459,148 @@ func ComputeMatch(student EnrichedStudentProfile, program ProgramContext) MatchS
 		SpecialScore:     extraScore,
 		OverallScore:     score,
 		Category:         category,
+		Probability:      probability,
 		BreakdownScore:   &breakdown,
 		Reasons:          reasons,
-		Advice:           advice,
+		Advice:           AdviceText(adviceCode, adviceStep),
+		AdviceCode:       adviceCode,
+		AdviceStep:       adviceStep,
 		FinancialStatus:  financialStatus,
 		ImprovementPath:  improvementPath,
 	}
 }
+
+// bandScore scores v against a program average: well above
+// (avg+AboveMargin), at, close below (avg-CloseMargin) or far below (ratio
+// of the close points). r names the component and test; the code and the
+// values are filled in.
+func bandScore(v, avg float64, b Band, r Reason) (int, Reason) {
+	reason := func(code, severity string) Reason {
+		r.Code, r.Severity = r.Component+code, severity
+		return r.with("value", round3(v)).with("program_avg", avg)
+	}
+	switch {
+	case v >= avg+b.AboveMargin:
+		return b.Above, reason("_above_average", SeverityPositive)
+	case v >= avg:
+		return b.At, reason("_at_average", SeverityPositive)
+	case v >= avg-b.CloseMargin:
+		return b.Close, reason("_below_average_close", SeverityNeutral)
+	}
+	return int(math.Max(0, v/avg*float64(b.Close))), reason("_well_below_average", SeverityNegative)
+}
+
+// englishScore compares the student's English test with the program average
+// on the same test when both exist, otherwise both in IELTS equivalents
+// (concordance.go). The reason is empty when the program has no reference.
+func englishScore(w Weights, student EnrichedStudentProfile, program ProgramContext) (int, Reason) {
+	lang := w.Academic.Language
+	results := englishResults(student.IELTS, student.TOEFL, student.PTE, student.Duolingo)
+	for _, r := range results {
+		switch {
+		case r.Test == "IELTS" && program.AvgIELTS != nil:
+			return bandScore(r.Score, *program.AvgIELTS, lang["ielts"], languageReason("IELTS"))
+		case r.Test == "TOEFL" && program.AvgTOEFL != nil:
+			return bandScore(r.Score, float64(*program.AvgTOEFL), lang["toefl"], languageReason("TOEFL"))
+		case r.Test == "PTE" && program.AvgPTE != nil:
+			return bandScore(r.Score, float64(*program.AvgPTE), lang["pte"], languageReason("PTE"))
+		case r.Test == "Duolingo" && program.AvgDuolingo != nil:
+			return bandScore(r.Score, float64(*program.AvgDuolingo), lang["duolingo"], languageReason("Duolingo"))
+		}
+	}
+
+	best := results[0]
+	if refs := englishResults(program.AvgIELTS, program.AvgTOEFL, program.AvgPTE, program.AvgDuolingo); len(refs) > 0 {
+		r := languageReason(best.Test)
+		if best.Test != "IELTS" || refs[0].Test != "IELTS" {
+			r = r.with("compared_as", "IELTS")
+		}
+		return bandScore(best.IELTS, refs[0].IELTS, lang["ielts"], r)
+	}
+	// No reference data, use normalized 0-Above
+	if best.Test == "TOEFL" {
+		return int(math.Round(float64(lang["toefl"].Above) * clamp01(best.Score/120.0))), Reason{}
+	}
+	return int(math.Round(float64(lang["ielts"].Above) * clamp01(best.IELTS/9.0))), Reason{}
+}
+
+// testsScore compares admission tests like-for-like: GMAT or GRE for master
+// programs, SAT or ACT otherwise, falling back to the ACT/SAT concordance.
+func testsScore(w Weights, student EnrichedStudentProfile, program ProgramContext) (int, Reason) {
+	tests := w.Academic.Tests
+	if isMaster(program.DegreeLevel) {
+		gre, okGRE := greTotal(student.GREVerbal, student.GREQuant)
+		switch {
+		case student.GMAT != nil && program.AvgGMAT != nil:
+			return bandScore(float64(*student.GMAT), float64(*program.AvgGMAT), tests["gmat"], testsReason("GMAT"))
+		case okGRE && program.AvgGRE != nil:
+			return bandScore(float64(gre), float64(*program.AvgGRE), tests["gre"], testsReason("GRE"))
+		case okGRE:
+			return int(math.Round(float64(tests["gre"].Above) * clamp01(float64(gre-260)/80.0))), Reason{}
+		case student.GMAT != nil:
+			return int(math.Round(float64(tests["gmat"].Above) * clamp01(float64(*student.GMAT-200)/600.0))), Reason{}
+		}
+		return 0, Reason{}
+	}
+
+	if student.SAT != nil && program.AvgSAT != nil {
+		return bandScore(float64(*student.SAT), float64(*program.AvgSAT), tests["sat"], testsReason("SAT"))
+	}
+	if student.ACT != nil && program.AvgACT != nil {
+		return bandScore(float64(*student.ACT), float64(*program.AvgACT), tests["act"], testsReason("ACT"))
+	}
+	sat, ok := satEquivalent(student.SAT, student.ACT)
+	if !ok {
+		return 0, Reason{}
+	}
+	if ref, ok := satEquivalent(program.AvgSAT, program.AvgACT); ok {
+		r := testsReason("SAT")
+		if student.SAT == nil {
+			r = testsReason("ACT").with("compared_as", "SAT")
+		} else if program.AvgSAT == nil {
+			r = r.with("program_test", "ACT")
+		}
+		return bandScore(float64(sat), float64(ref), tests["sat"], r)
+	}
+	return int(math.Round(float64(tests["sat"].Above) * clamp01(float64(sat)/1600.0))), Reason{}
+}
+
+// greTotal is verbal + quant (260-340), the scale program GRE averages use.
+func greTotal(verbal, quant *int) (int, bool) {
+	if verbal == nil || quant == nil {
+		return 0, false
+	}
+	return *verbal + *quant, true
+}
+
+func hasGRE(s EnrichedStudentProfile) bool {
+	_, ok := greTotal(s.GREVerbal, s.GREQuant)
+	return ok
+}
+
+func isMaster(degreeLevel string) bool {
+	return strings.HasPrefix(strings.ToLower(degreeLevel), "master")
+}
+
+// isGermanTaught matches programs.language values like "German", "de", "Deutsch".
+func isGermanTaught(language string) bool {
+	l := strings.ToLower(strings.TrimSpace(language))
+	return l == "de" || strings.Contains(l, "german") || strings.Contains(l, "deutsch")
+}
+
+// sameCurrency: a missing currency is taken to match (legacy rows without one).
+func sameCurrency(a, b *string) bool {
+	if a == nil || b == nil || *a == "" || *b == "" {
+		return true
+	}
+	return strings.EqualFold(*a, *b)
+}
+
+func currencyCode(codes ...*string) string {
+	for _, c := range codes {
+		if c != nil && *c != "" {
+			return strings.ToUpper(*c)
+		}
+	}
+	return ""
+}
@@ -35,7 +35,9 @@ func TestBasicMatching(t *testing.T) {
 			},
 			expectedMin:    60,
 			expectedMax:    100,
-			expectedStatus: "safety",
+			// a strong fit, but a 3% acceptance rate keeps the admit
+			// probability well below the safety threshold
+			expectedStatus: "reach",
 		},
 		{
 			name: "Weak student to selective program",
@@ -174,6 +176,49 @@ func TestFinancialScoring(t *testing.T) {
 	}
 }
 
+// TestFinancialCurrencyMismatch: budget and tuition in different currencies
+// are never compared as plain numbers
+func TestFinancialCurrencyMismatch(t *testing.T) {
+	kzt, eur := "KZT", "EUR"
+	student := EnrichedStudentProfile{BudgetYear: f64Ptr(10_000_000), BudgetCurrency: &kzt}
+	program := ProgramContext{TuitionAmount: f64Ptr(20000), TuitionCurrency: &eur}
+
+	result := ComputeMatch(student, program)
+	if result.FinancialScore != 0 || result.FinancialStatus.CoveredByBudget {
+		t.Errorf("KZT budget vs EUR tuition scored %d, covered=%v", result.FinancialScore, result.FinancialStatus.CoveredByBudget)
+	}
+
+	student.BudgetYear, student.BudgetCurrency = f64Ptr(21000), &eur
+	result = ComputeMatch(student, program)
+	if result.FinancialScore != 20 || result.FinancialStatus.Currency != "EUR" {
+		t.Errorf("same currency: score %d, currency %q", result.FinancialScore, result.FinancialStatus.Currency)
+	}
+}
+
+// TestScholarshipCitizenship: a country-restricted scholarship is flagged
+// unless the student's citizenship is among the eligible ones
+func TestScholarshipCitizenship(t *testing.T) {
+	program := ProgramContext{HasScholarship: true, EligibleCitizenships: []string{"KZ", "UZ"}}
+	flagged := func(citizenship string) bool {
+		for _, r := range ComputeMatch(EnrichedStudentProfile{Citizenship: citizenship}, program).Reasons {
+			if r.Code == "financial_scholarship_citizenships" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if flagged("KZ") {
+		t.Error("KZ is eligible, should not be flagged")
+	}
+	if !flagged("US") {
+		t.Error("US is not eligible, should be flagged")
+	}
+	if !flagged("") {
+		t.Error("unknown citizenship should be flagged")
+	}
+}
+
 // TestAcademicScoring tests GPA and language accuracy
 func TestAcademicScoring(t *testing.T) {
 	tests := []struct {
@@ -235,6 +280,31 @@ func TestAcademicScoring(t *testing.T) {
 	}
 }
 
+// TestGPAWellBelowAverage pins the points for a GPA far below the program
+// average: GPA/avg * the "close" points on the 4.0 scale, falling with the
+// distance and never reaching the "close" band.
+func TestGPAWellBelowAverage(t *testing.T) {
+	program := ProgramContext{AvgGPA: f64Ptr(3.6)}
+	score := func(gpa float64) (int, string) {
+		m := ComputeMatch(EnrichedStudentProfile{GPA: f64Ptr(gpa), GPAScale: f64Ptr(4.0)}, program)
+		for _, r := range m.Reasons {
+			if r.Component == ComponentGPA {
+				return m.BreakdownScore.GPA, r.Code
+			}
+		}
+		return m.BreakdownScore.GPA, ""
+	}
+
+	near, _ := score(3.3)
+	far, code := score(2.5)
+	if far != 8 || code != "gpa_well_below_average" {
+		t.Errorf("GPA 2.5 vs 3.6: %d points (%s), want 8 (gpa_well_below_average)", far, code)
+	}
+	if farther, _ := score(1.8); !(farther < far && far < near) {
+		t.Errorf("points should fall with the distance: 3.3=%d, 2.5=%d, 1.8=%d", near, far, farther)
+	}
+}
+
 // TestCompetitiveScoring tests acceptance rate impact
 func TestCompetitiveScoring(t *testing.T) {
 	student := EnrichedStudentProfile{
@@ -0,0 +1,318 @@
+package scoring
+
+import "math"
+
+// Admission probability: a logistic model on how far the applicant is from
+// the program's admitted averages,
+//
+//	P(admit) = sigmoid(b0 + b1*zGPA + b2*zTest + b3*zLang)
+//
+// where each z is (applicant - program average) / typical spread. The prior
+// intercept comes from the program's acceptance rate; FitAdmissionModel
+// refines all coefficients per program from reported outcomes. Categories
+// are defined on the resulting probability (CategoryWeights.ForProbability).
+
+const (
+	nCoef = 4 // intercept, GPA, test, language
+
+	// An applicant at the admitted averages is above the typical applicant,
+	// so their odds beat the plain acceptance rate.
+	atAverageLift = 1.0
+
+	ciZ = 1.645 // 90% interval
+)
+
+// typical spread of each feature among admitted students
+const (
+	gpaSpread     = 0.3 // US 4.0
+	satSpread     = 120 // SAT (ACT via concordance)
+	greSpread     = 8   // verbal + quant
+	gmatSpread    = 70  //
+	ieltsSpread   = 0.5 // English tests via IELTS concordance
+	testDaFSpread = 0.6 // TDN
+	zLimit        = 3.0 // clamp outliers
+)
+
+var (
+	priorSlopes   = [nCoef]float64{0, 1.2, 0.8, 0.6}
+	priorVariance = [nCoef]float64{0.35 * 0.35, 0.4 * 0.4, 0.4 * 0.4, 0.4 * 0.4}
+)
+
+// AdmissionModel holds the coefficients and their covariance for one program.
+type AdmissionModel struct {
+	Coef     [nCoef]float64
+	Cov      [nCoef][nCoef]float64
+	Outcomes int // reported outcomes behind the fit; 0 = prior only
+}
+
+// ApplicantFeatures are the test results the model uses, on common scales.
+// They are also what an admission outcome report snapshots.
+type ApplicantFeatures struct {
+	GPA     *float64 `json:"gpa_us4"`
+	SAT     *float64 `json:"sat"` // SAT or ACT via concordance
+	GRE     *float64 `json:"gre"` // verbal + quant
+	GMAT    *float64 `json:"gmat"`
+	IELTS   *float64 `json:"ielts"` // best English test as IELTS band
+	TestDaF *float64 `json:"testdaf"`
+}
+
+// Outcome is a reported admission decision.
+type Outcome struct {
+	Features ApplicantFeatures
+	Admitted bool
+}
+
+// Probability is an estimated admit probability with a 90% interval.
+type Probability struct {
+	P        float64 `json:"p"`
+	Low      float64 `json:"low"`
+	High     float64 `json:"high"`
+	Outcomes int     `json:"outcomes"` // reported outcomes the estimate is fitted on
+}
+
+// FeaturesOf extracts the model inputs from a student profile.
+func FeaturesOf(s EnrichedStudentProfile) ApplicantFeatures {
+	var f ApplicantFeatures
+	if v, ok := GPAUS4(s.GPA, s.GradingSystem, s.GPAScale); ok {
+		f.GPA = &v
+	}
+	if v, ok := satEquivalent(s.SAT, s.ACT); ok {
+		f.SAT = fptr(float64(v))
+	}
+	if v, ok := greTotal(s.GREVerbal, s.GREQuant); ok {
+		f.GRE = fptr(float64(v))
+	}
+	if s.GMAT != nil {
+		f.GMAT = fptr(float64(*s.GMAT))
+	}
+	if rs := englishResults(s.IELTS, s.TOEFL, s.PTE, s.Duolingo); len(rs) > 0 {
+		best := rs[0].IELTS
+		for _, r := range rs[1:] {
+			best = math.Max(best, r.IELTS)
+		}
+		f.IELTS = &best
+	}
+	if s.TestDaF != nil {
+		f.TestDaF = fptr(float64(*s.TestDaF))
+	}
+	return f
+}
+
+// PriorAdmissionModel is the model before any outcomes: the intercept from
+// the acceptance rate (percent), textbook slopes.
+func PriorAdmissionModel(acceptanceRate float64) AdmissionModel {
+	rate := math.Min(math.Max(acceptanceRate/100, 0.005), 0.99)
+	m := AdmissionModel{Coef: priorSlopes}
+	m.Coef[0] = math.Log(rate/(1-rate)) + atAverageLift
+	for i := range priorVariance {
+		m.Cov[i][i] = priorVariance[i]
+	}
+	return m
+}
+
+// design is the model input row for an applicant at a program. missing
+// lists features the program has a reference for but the applicant lacks.
+func design(f ApplicantFeatures, p ProgramContext) (x [nCoef]float64, missing []int) {
+	x[0] = 1
+	z := func(i int, v *float64, ref *float64, spread float64) {
+		if ref == nil {
+			return
+		}
+		if v == nil {
+			missing = append(missing, i)
+			return
+		}
+		x[i] = math.Max(-zLimit, math.Min(zLimit, (*v-*ref)/spread))
+	}
+
+	z(1, f.GPA, p.AvgGPA, gpaSpread)
+
+	if isMaster(p.DegreeLevel) {
+		// the test the applicant took, else whichever the program reports
+		switch {
+		case f.GMAT != nil && p.AvgGMAT != nil:
+			z(2, f.GMAT, intRef(p.AvgGMAT), gmatSpread)
+		case f.GRE != nil && p.AvgGRE != nil:
+			z(2, f.GRE, intRef(p.AvgGRE), greSpread)
+		case p.AvgGMAT != nil:
+			z(2, f.GMAT, intRef(p.AvgGMAT), gmatSpread)
+		case p.AvgGRE != nil:
+			z(2, f.GRE, intRef(p.AvgGRE), greSpread)
+		}
+	} else if ref, ok := satEquivalent(p.AvgSAT, p.AvgACT); ok {
+		z(2, f.SAT, fptr(float64(ref)), satSpread)
+	}
+
+	if isGermanTaught(p.Language) {
+		ref := 4.0 // TDN 4 in every section is the usual admission level
+		if p.AvgTestDaF != nil {
+			ref = *p.AvgTestDaF
+		}
+		z(3, f.TestDaF, &ref, testDaFSpread)
+	} else if refs := englishResults(p.AvgIELTS, p.AvgTOEFL, p.AvgPTE, p.AvgDuolingo); len(refs) > 0 {
+		z(3, f.IELTS, &refs[0].IELTS, ieltsSpread)
+	}
+	return x, missing
+}
+
+// AdmitProbability estimates the applicant's chance at the program, using
+// program.Model when fitted, otherwise the prior from the acceptance rate.
+// nil when the program has neither. A missing test the program looks at
+// widens the interval instead of counting as a bad score.
+func AdmitProbability(s EnrichedStudentProfile, program ProgramContext) *Probability {
+	var m AdmissionModel
+	switch {
+	case program.Model != nil:
+		m = *program.Model
+	case program.AcceptanceRate != nil:
+		m = PriorAdmissionModel(*program.AcceptanceRate)
+	default:
+		return nil
+	}
+
+	x, missing := design(FeaturesOf(s), program)
+	eta, variance := 0.0, 0.0
+	for i := 0; i < nCoef; i++ {
+		eta += m.Coef[i] * x[i]
+		for j := 0; j < nCoef; j++ {
+			variance += x[i] * m.Cov[i][j] * x[j]
+		}
+	}
+	for _, i := range missing {
+		// unknown z ~ N(0,1)
+		variance += m.Coef[i]*m.Coef[i] + m.Cov[i][i]
+	}
+	sd := math.Sqrt(variance)
+	return &Probability{
+		P:        round3(sigmoid(eta)),
+		Low:      round3(sigmoid(eta - ciZ*sd)),
+		High:     round3(sigmoid(eta + ciZ*sd)),
+		Outcomes: m.Outcomes,
+	}
+}
+
+// FitAdmissionModel refines prior with outcomes: the maximum a posteriori
+// logistic regression (Gaussian prior = ridge towards the prior) by Newton's
+// method, with the Laplace approximation for the covariance. Waitlisted
+// applicants must be left out by the caller. Outcomes missing a feature the
+// program looks at are left out too: their zero would read as scoring
+// exactly the program average.
+func FitAdmissionModel(prior AdmissionModel, program ProgramContext, outcomes []Outcome) AdmissionModel {
+	var rows [][nCoef]float64
+	var admitted []bool
+	for _, o := range outcomes {
+		if x, missing := design(o.Features, program); len(missing) == 0 {
+			rows = append(rows, x)
+			admitted = append(admitted, o.Admitted)
+		}
+	}
+	if len(rows) == 0 {
+		return prior
+	}
+	priorPrec, ok := invert(prior.Cov)
+	if !ok {
+		return prior
+	}
+
+	beta := prior.Coef
+	var hess [nCoef][nCoef]float64
+	for iter := 0; iter < 50; iter++ {
+		var grad [nCoef]float64
+		hess = priorPrec
+		for i := 0; i < nCoef; i++ {
+			for j := 0; j < nCoef; j++ {
+				grad[i] -= priorPrec[i][j] * (beta[j] - prior.Coef[j])
+			}
+		}
+		for k, x := range rows {
+			eta := 0.0
+			for i := range x {
+				eta += beta[i] * x[i]
+			}
+			p := sigmoid(eta)
+			y := 0.0
+			if admitted[k] {
+				y = 1
+			}
+			w := p * (1 - p)
+			for i := range x {
+				grad[i] += (y - p) * x[i]
+				for j := range x {
+					hess[i][j] += w * x[i] * x[j]
+				}
+			}
+		}
+		inv, ok := invert(hess)
+		if !ok {
+			break
+		}
+		step := 0.0
+		for i := 0; i < nCoef; i++ {
+			d := 0.0
+			for j := 0; j < nCoef; j++ {
+				d += inv[i][j] * grad[j]
+			}
+			beta[i] += d
+			step = math.Max(step, math.Abs(d))
+		}
+		if step < 1e-8 {
+			break
+		}
+	}
+
+	cov, ok := invert(hess)
+	if !ok {
+		return prior
+	}
+	return AdmissionModel{Coef: beta, Cov: cov, Outcomes: len(rows)}
+}
+
+// invert is Gauss-Jordan with partial pivoting for the small model matrices.
+func invert(m [nCoef][nCoef]float64) ([nCoef][nCoef]float64, bool) {
+	var inv [nCoef][nCoef]float64
+	for i := range inv {
+		inv[i][i] = 1
+	}
+	for col := 0; col < nCoef; col++ {
+		pivot := col
+		for r := col + 1; r < nCoef; r++ {
+			if math.Abs(m[r][col]) > math.Abs(m[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-12 {
+			return inv, false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		inv[col], inv[pivot] = inv[pivot], inv[col]
+		d := m[col][col]
+		for j := 0; j < nCoef; j++ {
+			m[col][j] /= d
+			inv[col][j] /= d
+		}
+		for r := 0; r < nCoef; r++ {
+			if r == col || m[r][col] == 0 {
+				continue
+			}
+			f := m[r][col]
+			for j := 0; j < nCoef; j++ {
+				m[r][j] -= f * m[col][j]
+				inv[r][j] -= f * inv[col][j]
+			}
+		}
+	}
+	return inv, true
+}
+
+func sigmoid(x float64) float64 { return 1 / (1 + math.Exp(-x)) }
+
+func round3(v float64) float64 { return math.Round(v*1000) / 1000 }
+
+func fptr(v float64) *float64 { return &v }
+
+func intRef(v *int) *float64 {
+	if v == nil {
+		return nil
+	}
+	return fptr(float64(*v))
+}
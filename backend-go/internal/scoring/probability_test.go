@@ -0,0 +1,121 @@
+package scoring
+
+import (
+	"math"
+	"testing"
+)
+
+func admissionProgram() ProgramContext {
+	return ProgramContext{
+		DegreeLevel:    "Bachelor",
+		AvgGPA:         f64Ptr(3.6),
+		AvgSAT:         intPtr(1400),
+		AvgIELTS:       f64Ptr(7.0),
+		AcceptanceRate: f64Ptr(20),
+	}
+}
+
+func TestAdmitProbabilityPrior(t *testing.T) {
+	program := admissionProgram()
+	atAverages := EnrichedStudentProfile{GPA: f64Ptr(3.6), GPAScale: f64Ptr(4.0), SAT: intPtr(1400), IELTS: f64Ptr(7.0)}
+
+	p := AdmitProbability(atAverages, program)
+	if p == nil {
+		t.Fatal("expected a probability from the acceptance rate")
+	}
+	want := round3(sigmoid(math.Log(0.2/0.8) + atAverageLift))
+	if p.P != want {
+		t.Errorf("at averages: P = %v, want %v", p.P, want)
+	}
+	if !(p.Low < p.P && p.P < p.High) {
+		t.Errorf("interval [%v, %v] doesn't contain %v", p.Low, p.High, p.P)
+	}
+	if p.Outcomes != 0 {
+		t.Errorf("prior outcomes = %d", p.Outcomes)
+	}
+
+	stronger := atAverages
+	stronger.GPA, stronger.SAT = f64Ptr(3.95), intPtr(1550)
+	if s := AdmitProbability(stronger, program); s.P <= p.P {
+		t.Errorf("stronger applicant: P = %v, not above %v", s.P, p.P)
+	}
+
+	// a missing test is uncertainty, not a zero
+	noSAT := atAverages
+	noSAT.SAT = nil
+	m := AdmitProbability(noSAT, program)
+	if m.P != p.P || m.High-m.Low <= p.High-p.Low {
+		t.Errorf("missing SAT: %+v, at averages %+v", m, p)
+	}
+
+	program.AcceptanceRate = nil
+	if got := AdmitProbability(atAverages, program); got != nil {
+		t.Errorf("no admission data: got %+v", got)
+	}
+}
+
+func TestFitAdmissionModel(t *testing.T) {
+	program := admissionProgram()
+	prior := PriorAdmissionModel(*program.AcceptanceRate)
+
+	// admitted above 3.6, rejected below, everything else at the averages
+	outcomes := func(n int) []Outcome {
+		var list []Outcome
+		for i := 0; i < n; i++ {
+			gpa := 3.2 + 0.8*float64(i)/float64(n-1)
+			list = append(list, Outcome{
+				Features: ApplicantFeatures{GPA: fptr(gpa), SAT: fptr(1400), IELTS: fptr(7.0)},
+				Admitted: gpa > 3.6,
+			})
+		}
+		return list
+	}
+
+	few := FitAdmissionModel(prior, program, outcomes(10))
+	many := FitAdmissionModel(prior, program, outcomes(200))
+	if few.Outcomes != 10 || many.Outcomes != 200 {
+		t.Fatalf("outcome counts: %d, %d", few.Outcomes, many.Outcomes)
+	}
+	if !(many.Coef[1] > few.Coef[1] && few.Coef[1] > prior.Coef[1]) {
+		t.Errorf("GPA slope should grow with the evidence: prior %v, 10 %v, 200 %v",
+			prior.Coef[1], few.Coef[1], many.Coef[1])
+	}
+	if !(many.Cov[1][1] < few.Cov[1][1] && few.Cov[1][1] < prior.Cov[1][1]) {
+		t.Errorf("GPA variance should shrink: prior %v, 10 %v, 200 %v",
+			prior.Cov[1][1], few.Cov[1][1], many.Cov[1][1])
+	}
+
+	student := EnrichedStudentProfile{GPA: f64Ptr(3.9), GPAScale: f64Ptr(4.0), SAT: intPtr(1400), IELTS: f64Ptr(7.0)}
+	program.Model = &many
+	fitted := AdmitProbability(student, program)
+	program.Model = nil
+	priorP := AdmitProbability(student, program)
+	if fitted.Outcomes != 200 || fitted.P <= priorP.P || fitted.High-fitted.Low >= priorP.High-priorP.Low {
+		t.Errorf("fitted %+v vs prior %+v", fitted, priorP)
+	}
+
+	if got := FitAdmissionModel(prior, program, nil); got != prior {
+		t.Errorf("no outcomes should keep the prior, got %+v", got)
+	}
+
+	// rejections without a SAT must not read as rejections at the SAT average
+	noSAT := outcomes(200)
+	for i := 0; i < 50; i++ {
+		noSAT = append(noSAT, Outcome{Features: ApplicantFeatures{GPA: fptr(3.9), IELTS: fptr(7.0)}})
+	}
+	if got := FitAdmissionModel(prior, program, noSAT); got != many {
+		t.Errorf("outcomes missing a feature should be left out: %d used", got.Outcomes)
+	}
+}
+
+func TestCategoryForProbability(t *testing.T) {
+	cases := map[float64]string{
+		0.05: "reach", 0.249: "reach", 0.25: "target", 0.59: "target", 0.6: "safety", 0.95: "safety",
+	}
+	k := DefaultWeights().Categories
+	for p, want := range cases {
+		if got := k.ForProbability(p); got != want {
+			t.Errorf("ForProbability(%v) = %s, want %s", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package scoring
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Reason is one "why this score" item. Code is stable (clients filter,
+// highlight and translate on it); Params carry the values it is about:
+//
+//	test          the student's test ("IELTS", "SAT")
+//	compared_as   scale the result was converted to ("IELTS" for Duolingo)
+//	program_test  the program's test when it differs (ACT average for SAT)
+//	value         the student's result, on the compared scale
+//	program_avg   the program average / program_min its minimum
+//
+// Text renders the Russian wording the API has always returned.
+type Reason struct {
+	Code      string         `json:"code"`      // "gpa_above_average"
+	Severity  string         `json:"severity"`  // positive | neutral | negative
+	Component string         `json:"component"` // gpa | language | tests | financial | competition | extras
+	Params    map[string]any `json:"params,omitempty"`
+}
+
+const (
+	SeverityPositive = "positive"
+	SeverityNeutral  = "neutral"
+	SeverityNegative = "negative"
+)
+
+const (
+	ComponentGPA         = "gpa"
+	ComponentLanguage    = "language"
+	ComponentTests       = "tests"
+	ComponentFinancial   = "financial"
+	ComponentCompetition = "competition"
+	ComponentExtras      = "extras"
+)
+
+// newReason builds a Reason; kv are Params as key, value pairs.
+func newReason(component, code, severity string, kv ...any) Reason {
+	r := Reason{Code: code, Severity: severity, Component: component}
+	for i := 0; i+1 < len(kv); i += 2 {
+		r = r.with(kv[i].(string), kv[i+1])
+	}
+	return r
+}
+
+// with returns r with one more param (Params is copied, not shared).
+func (r Reason) with(key string, v any) Reason {
+	params := make(map[string]any, len(r.Params)+1)
+	for k, p := range r.Params {
+		params[k] = p
+	}
+	params[key] = v
+	r.Params = params
+	return r
+}
+
+// languageReason and testsReason name the test a reason is about; the code
+// is set by bandScore, minReason or scored.
+func languageReason(test string) Reason {
+	return newReason(ComponentLanguage, "", "", "test", test)
+}
+
+func testsReason(test string) Reason {
+	return newReason(ComponentTests, "", "", "test", test)
+}
+
+// scored sets the code and severity of r, with the student's result.
+func (r Reason) scored(code, severity string, v float64) Reason {
+	r.Code, r.Severity = code, severity
+	return r.with("value", v)
+}
+
+// reasonTexts are the Russian texts by code. {label} is the test with its
+// conversion ("Duolingo (в пересчёте на IELTS)"), other {names} are params.
+var reasonTexts = map[string]string{
+	"gpa_above_average":       "GPA выше средней по программе",
+	"gpa_at_average":          "GPA соответствует среднему показателю",
+	"gpa_below_average_close": "GPA ниже среднего, но близко",
+	"gpa_well_below_average":  "GPA существенно ниже требуемого",
+	"gpa_meets_minimum":       "GPA соответствует требованиям программы",
+	"gpa_below_minimum":       "GPA ниже минимальных требований программы",
+	"gpa_no_reference":        "GPA соответствует средним показателям",
+	"gpa_missing":             "GPA не указан, точность оценки снижена",
+
+	"language_above_average":       "{label} выше среднего показателя",
+	"language_at_average":          "{label} соответствует требованиям",
+	"language_below_average_close": "{label} ниже среднего, но близко",
+	"language_well_below_average":  "{label} значительно ниже требуемого",
+	"language_meets_minimum":       "{label} соответствует требованиям",
+	"language_below_minimum":       "{label} ниже минимальных требований",
+	"language_good":                "Хороший уровень английского ({test})",
+	"language_average":             "{test} на среднем уровне",
+	"language_counted":             "Языковой сертификат {test} учтён",
+	"language_missing":             "Языковой тест не указан (IELTS/TOEFL/PTE/Duolingo)",
+	"language_testdaf_missing":     "Программа на немецком языке, TestDaF не указан",
+	"language_required_missing":    "{test} не указан, но требуется для программы",
+
+	"tests_above_average":       "{label} выше среднего показателя",
+	"tests_at_average":          "{label} соответствует требованиям",
+	"tests_below_average_close": "{label} ниже среднего, но близко",
+	"tests_well_below_average":  "{label} значительно ниже требуемого",
+	"tests_meets_minimum":       "{label} соответствует требованиям",
+	"tests_below_minimum":       "{label} ниже минимальных требований",
+	"tests_good":                "Хороший результат {test}",
+	"tests_average":             "{test} на среднем уровне",
+	"tests_other_test":          "Указан {test}, программа ориентируется на {program_test}",
+	"tests_required_missing":    "{test} не указан, но требуется для программы",
+
+	"competition_low":    "Низкий уровень конкуренции при поступлении",
+	"competition_medium": "Средний уровень конкуренции",
+	"competition_high":   "Высокий уровень конкуренции",
+	"admit_probability":  "Вероятность поступления около {percent}% (90%-интервал {low_percent}–{high_percent}%)",
+
+	"financial_no_fx_rate":               "Нет курса валют для сравнения бюджета со стоимостью обучения",
+	"financial_no_living_costs":          "Нет данных о стоимости проживания, учтена только плата за обучение",
+	"financial_budget_covers":            "Бюджет полностью покрывает обучение",
+	"financial_budget_mostly_covers":     "Бюджет покрывает основную часть, возможен кредит",
+	"financial_scholarship_covers":       "Стипендия + бюджет могут покрыть обучение",
+	"financial_scholarship_short":        "Даже со стипендией требуется дополнительное финансирование",
+	"financial_budget_short":             "Бюджет недостаточен для обучения",
+	"financial_scholarships_available":   "Программа предоставляет стипендии",
+	"financial_scholarship_citizenships": "Стипендия доступна только для определённых стран",
+
+	"extras_strong":  "Сильный набор достижений (олимпиады, лидерство, спорт)",
+	"extras_good":    "Хороший набор достижений",
+	"extras_some":    "Есть достижения, можно добавить",
+	"extras_present": "Есть дополнительные достижения",
+	"extras_missing": "Рекомендуется добавить достижения для повышения шансов",
+}
+
+// Text renders r in Russian; an unknown code renders as itself.
+func (r Reason) Text() string {
+	tmpl, ok := reasonTexts[r.Code]
+	if !ok {
+		return r.Code
+	}
+	return render(tmpl, func(name string) string {
+		if name == "label" {
+			return r.label()
+		}
+		return formatParam(r.Params[name])
+	})
+}
+
+// render fills the {name} placeholders of tmpl.
+func render(tmpl string, param func(name string) string) string {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(tmpl, '{')
+		if i < 0 {
+			b.WriteString(tmpl)
+			return b.String()
+		}
+		j := i + strings.IndexByte(tmpl[i:], '}')
+		b.WriteString(tmpl[:i])
+		b.WriteString(param(tmpl[i+1 : j]))
+		tmpl = tmpl[j+1:]
+	}
+}
+
+// label is the test name with the scale it was compared on.
+func (r Reason) label() string {
+	test := formatParam(r.Params["test"])
+	if as, ok := r.Params["compared_as"].(string); ok {
+		return test + " (в пересчёте на " + as + ")"
+	}
+	if from, ok := r.Params["program_test"].(string); ok && from != test {
+		return test + " (в пересчёте с " + from + ")"
+	}
+	return test
+}
+
+func formatParam(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// RenderReasons renders reasons in order.
+func RenderReasons(reasons []Reason) []string {
+	out := make([]string, len(reasons))
+	for i, r := range reasons {
+		out[i] = r.Text()
+	}
+	return out
+}
+
+// Step is one improvement step of MatchScore.ImprovementPath, coded like
+// Reason: Params carry the numbers (delta and target for raise_gpa,
+// impact_percent where the step has an estimate).
+type Step struct {
+	Code   string         `json:"code"` // "raise_gpa"
+	Params map[string]any `json:"params,omitempty"`
+}
+
+const (
+	StepRaiseGPA        = "raise_gpa"
+	StepTakeGREOrGMAT   = "take_gre_or_gmat"
+	StepTakeSAT         = "take_sat"
+	StepTakeTestDaF     = "take_testdaf"
+	StepAddAchievements = "add_achievements"
+)
+
+// newStep builds a Step; kv are Params as key, value pairs.
+func newStep(code string, kv ...any) Step {
+	s := Step{Code: code}
+	for i := 0; i+1 < len(kv); i += 2 {
+		if s.Params == nil {
+			s.Params = map[string]any{}
+		}
+		s.Params[kv[i].(string)] = kv[i+1]
+	}
+	return s
+}
+
+// stepTexts are the Russian texts by step code, with {param} placeholders.
+var stepTexts = map[string]string{
+	StepRaiseGPA:        "Повысить GPA на +{delta} (по шкале 4.0)",
+	StepTakeGREOrGMAT:   "Сдать GRE или GMAT (программа учитывает результаты тестов)",
+	StepTakeSAT:         "Сдать SAT (средний показатель в программе увеличит шансы на +{impact_percent}%)",
+	StepTakeTestDaF:     "Сдать TestDaF (обычно требуется TDN 4 во всех частях)",
+	StepAddAchievements: "Добавить 2-3 достижения (олимпиада, лидерство, спорт) = +{impact_percent}-{impact_percent_max}%",
+}
+
+// Text renders s in Russian; an unknown code renders as itself.
+func (s Step) Text() string {
+	tmpl, ok := stepTexts[s.Code]
+	if !ok {
+		return s.Code
+	}
+	return render(tmpl, func(name string) string { return formatParam(s.Params[name]) })
+}
+
+// RenderSteps renders steps in order.
+func RenderSteps(steps []Step) []string {
+	out := make([]string, len(steps))
+	for i, s := range steps {
+		out[i] = s.Text()
+	}
+	return out
+}
+
+// Advice codes of MatchScore.AdviceCode, by category.
+const (
+	AdviceApply     = "apply"     // safety
+	AdviceRealistic = "realistic" // target
+	AdviceImprove   = "improve"   // reach, worth trying after the next steps
+	AdviceLongShot  = "long_shot" // reach, better spend effort elsewhere
+)
+
+// adviceTexts are the Russian texts by advice code; {step} is the rendered
+// MatchScore.AdviceStep. AdviceImprove without a step uses its "_no_step"
+// variant.
+var adviceTexts = map[string]string{
+	AdviceApply: "Хороший шанс поступления. Подавайте заявку!",
+	AdviceRealistic: "Реалистичный вариант. Есть вероятность поступления. " +
+		"Убедитесь, что ваш профиль полный и все документы в порядке.",
+	AdviceImprove:              "Сложный вариант, но не невозможен. Рекомендуется: {step} Можно попробовать.",
+	AdviceImprove + "_no_step": "Сложный вариант, но не невозможен. ",
+	AdviceLongShot:             "Очень сложный вариант. Рекомендуется сосредоточиться на других программах.",
+}
+
+// AdviceText renders an advice code in Russian; step is the first
+// improvement step (AdviceImprove only, may be nil).
+func AdviceText(code string, step *Step) string {
+	if code == AdviceImprove && step == nil {
+		code += "_no_step"
+	}
+	return render(adviceTexts[code], func(name string) string {
+		if name == "step" && step != nil {
+			return step.Text()
+		}
+		return ""
+	})
+}
@@ -0,0 +1,149 @@
+package scoring
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReasonTextsWellFormed(t *testing.T) {
+	components := map[string]bool{
+		ComponentGPA: true, ComponentLanguage: true, ComponentTests: true,
+		ComponentFinancial: true, ComponentCompetition: true, ComponentExtras: true,
+	}
+	for code, tmpl := range reasonTexts {
+		if strings.Count(tmpl, "{") != strings.Count(tmpl, "}") {
+			t.Errorf("%s: unbalanced braces in %q", code, tmpl)
+		}
+		if c, _, _ := strings.Cut(code, "_"); !components[c] && code != "admit_probability" {
+			t.Errorf("%s: code does not start with a component", code)
+		}
+	}
+}
+
+func TestReasonText(t *testing.T) {
+	cases := []struct {
+		r    Reason
+		want string
+	}{
+		{newReason(ComponentGPA, "gpa_above_average", SeverityPositive, "value", 3.8, "program_avg", 3.6),
+			"GPA выше средней по программе"},
+		{languageReason("Duolingo").with("compared_as", "IELTS").scored("language_at_average", SeverityPositive, 7),
+			"Duolingo (в пересчёте на IELTS) соответствует требованиям"},
+		{testsReason("SAT").with("program_test", "ACT").scored("tests_above_average", SeverityPositive, 1500),
+			"SAT (в пересчёте с ACT) выше среднего показателя"},
+		{newReason(ComponentTests, "tests_other_test", SeverityNeutral, "test", "GMAT", "program_test", "GRE"),
+			"Указан GMAT, программа ориентируется на GRE"},
+		{newReason(ComponentCompetition, "admit_probability", SeverityNeutral, "percent", 42, "low_percent", 30, "high_percent", 55),
+			"Вероятность поступления около 42% (90%-интервал 30–55%)"},
+		{Reason{Code: "no_such_code"}, "no_such_code"},
+	}
+	for _, c := range cases {
+		if got := c.r.Text(); got != c.want {
+			t.Errorf("%s: %q, want %q", c.r.Code, got, c.want)
+		}
+	}
+}
+
+func TestMatchReasonsAreCoded(t *testing.T) {
+	students := []EnrichedStudentProfile{
+		{},
+		{GPA: f64Ptr(3.9), GPAScale: f64Ptr(4), IELTS: f64Ptr(7.5), SAT: intPtr(1500), BudgetYear: f64Ptr(80000),
+			Achievements: AchievementCounts{Olympiads: 2}},
+		{GPA: f64Ptr(2.5), GPAScale: f64Ptr(4), Duolingo: intPtr(100), ACT: intPtr(20), BudgetYear: f64Ptr(5000)},
+		{GPA: f64Ptr(3.4), GPAScale: f64Ptr(4), TOEFL: intPtr(95), GREVerbal: intPtr(155), GREQuant: intPtr(160)},
+	}
+	programs := []ProgramContext{
+		{},
+		{AvgGPA: f64Ptr(3.6), AvgIELTS: f64Ptr(7), AvgSAT: intPtr(1400), AcceptanceRate: f64Ptr(15),
+			TuitionAmount: f64Ptr(40000), HasScholarship: true, ScholarshipCoverages: []float64{50}, CompetitiveFactor: 1},
+		{DegreeLevel: "Master", AvgGPA: f64Ptr(3.5), AvgTOEFL: intPtr(100), AvgGRE: intPtr(320), CompetitiveFactor: 1},
+		{Language: "German", AvgGPA: f64Ptr(3.0), AcceptanceRate: f64Ptr(60), HasScholarship: true, CompetitiveFactor: 1},
+	}
+	severities := map[string]bool{SeverityPositive: true, SeverityNeutral: true, SeverityNegative: true}
+	for _, s := range students {
+		for _, p := range programs {
+			m := ComputeMatch(s, p)
+			for _, r := range m.Reasons {
+				if _, ok := reasonTexts[r.Code]; !ok || !severities[r.Severity] || r.Component == "" {
+					t.Errorf("uncoded reason %+v", r)
+				}
+				if strings.Contains(r.Text(), "{") {
+					t.Errorf("%s: unrendered placeholder in %q", r.Code, r.Text())
+				}
+			}
+			if AdviceText(m.AdviceCode, m.AdviceStep) == "" || strings.Contains(m.Advice, "{") {
+				t.Errorf("advice code %q renders as %q", m.AdviceCode, m.Advice)
+			}
+			for _, step := range m.ImprovementPath.Next3Steps {
+				if _, ok := stepTexts[step.Code]; !ok || strings.Contains(step.Text(), "{") {
+					t.Errorf("uncoded step %+v", step)
+				}
+			}
+		}
+		for _, r := range Compute(Profile{GPA: s.GPA, GPAScale: s.GPAScale, IELTS: s.IELTS, TOEFL: s.TOEFL, SAT: s.SAT, ACT: s.ACT},
+			Requirements{MinGPA: f64Ptr(3), MinIELTS: f64Ptr(6.5), MinSAT: intPtr(1300)}).Reasons {
+			if _, ok := reasonTexts[r.Code]; !ok || !severities[r.Severity] {
+				t.Errorf("Compute: uncoded reason %+v", r)
+			}
+		}
+	}
+}
+
+func TestStepsAndAdviceText(t *testing.T) {
+	raise := newStep(StepRaiseGPA, "delta", 0.3, "target", 3.6)
+	if got := raise.Text(); got != "Повысить GPA на +0.3 (по шкале 4.0)" {
+		t.Errorf("step text %q", got)
+	}
+	if got := AdviceText(AdviceImprove, &raise); got != "Сложный вариант, но не невозможен. Рекомендуется: Повысить GPA на +0.3 (по шкале 4.0) Можно попробовать." {
+		t.Errorf("advice text %q", got)
+	}
+	if got := AdviceText(AdviceImprove, nil); got != "Сложный вариант, но не невозможен. " {
+		t.Errorf("advice without a step %q", got)
+	}
+
+	// a reach program a GPA step away: the advice points at the coded step
+	m := ComputeMatch(
+		EnrichedStudentProfile{GPA: f64Ptr(3.4), GPAScale: f64Ptr(4), SAT: intPtr(1400)},
+		ProgramContext{AvgGPA: f64Ptr(3.7), AvgSAT: intPtr(1450), CompetitiveFactor: 1},
+	)
+	if len(m.ImprovementPath.Next3Steps) == 0 || m.ImprovementPath.Next3Steps[0].Code != StepRaiseGPA {
+		t.Fatalf("steps = %+v", m.ImprovementPath.Next3Steps)
+	}
+	want := map[string]any{"delta": 0.3, "target": 3.7}
+	if step := m.ImprovementPath.Next3Steps[0]; !reflect.DeepEqual(step.Params, want) {
+		t.Errorf("raise_gpa params = %v, want %v", step.Params, want)
+	}
+	if m.AdviceCode != AdviceImprove || m.AdviceStep == nil || m.AdviceStep.Code != StepRaiseGPA {
+		t.Errorf("advice %s, step %+v", m.AdviceCode, m.AdviceStep)
+	}
+}
+
+func TestReasonParams(t *testing.T) {
+	m := ComputeMatch(
+		EnrichedStudentProfile{ACT: intPtr(33)},
+		ProgramContext{DegreeLevel: "Bachelor", AvgSAT: intPtr(1350)},
+	)
+	var got *Reason
+	for i := range m.Reasons {
+		if m.Reasons[i].Component == ComponentTests {
+			got = &m.Reasons[i]
+		}
+	}
+	if got == nil {
+		t.Fatalf("no tests reason in %+v", m.Reasons)
+	}
+	want := Reason{
+		Code: "tests_above_average", Severity: SeverityPositive, Component: ComponentTests,
+		Params: map[string]any{"test": "ACT", "compared_as": "SAT", "value": 1460.0, "program_avg": 1350.0},
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("reason = %+v, want %+v", *got, want)
+	}
+
+	b, err := json.Marshal(got)
+	if err != nil || !strings.Contains(string(b), `"code":"tests_above_average"`) {
+		t.Errorf("json: %s, %v", b, err)
+	}
+}
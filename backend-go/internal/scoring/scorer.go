@@ -0,0 +1,142 @@
+package scoring
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Scorer rates a student against a program. Every screen scores through the
+// configured Scorer, so a program gets the same score everywhere; the
+// version ("match-v1") is stamped on each MatchScore and stored with every
+// persisted score, so old scores stay attributable after the engine changes.
+type Scorer interface {
+	Version() string
+	Score(student EnrichedStudentProfile, program ProgramContext) MatchScore
+}
+
+// DefaultScorer is used when SCORER is unset: the newest "match" version.
+const DefaultScorer = "match"
+
+type scorerFunc struct {
+	version string
+	score   func(EnrichedStudentProfile, ProgramContext) MatchScore
+}
+
+func (s scorerFunc) Version() string { return s.version }
+
+func (s scorerFunc) Score(student EnrichedStudentProfile, program ProgramContext) MatchScore {
+	m := s.score(student, program)
+	m.ScorerVersion = s.version
+	return m
+}
+
+// ScorerFunc makes a Scorer of a function. version is "<name>-v<n>".
+func ScorerFunc(version string, score func(EnrichedStudentProfile, ProgramContext) MatchScore) Scorer {
+	return scorerFunc{version: version, score: score}
+}
+
+// weightedScorer scores with the weights current in a store; the stamped
+// version names them too ("match-v1@2024-06"), as a reload changes scores.
+type weightedScorer struct {
+	version string
+	weights *WeightsStore
+	score   func(Weights, EnrichedStudentProfile, ProgramContext) MatchScore
+}
+
+func (s weightedScorer) Version() string { return s.version }
+
+func (s weightedScorer) Score(student EnrichedStudentProfile, program ProgramContext) MatchScore {
+	w := s.weights.Get().Weights
+	m := s.score(w, student, program)
+	m.ScorerVersion = s.version + "@" + w.Version
+	return m
+}
+
+var scorers = map[string]Scorer{}
+
+// Register adds a scorer to the registry. A version, once released, must
+// keep producing the same scores: change the engine under a new version.
+func Register(s Scorer) {
+	v := s.Version()
+	if _, _, ok := splitVersion(v); !ok {
+		panic("scoring: bad scorer version " + strconv.Quote(v))
+	}
+	if _, dup := scorers[v]; dup {
+		panic("scoring: scorer " + v + " registered twice")
+	}
+	scorers[v] = s
+}
+
+func init() {
+	// smart search engine: academic 40, competitive 30, financial 20, special 10
+	Register(weightedScorer{version: "match-v1", weights: ActiveWeights, score: ComputeMatchWith})
+	// the former POST /score engine: GPA 40, language 30, tests 20, extras 10
+	Register(ScorerFunc("points-v1", ComputePoints))
+}
+
+// LookupScorer finds a scorer by exact version ("match-v1") or by name
+// ("match"), which picks the newest version of that name.
+func LookupScorer(id string) (Scorer, error) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if s, ok := scorers[id]; ok {
+		return s, nil
+	}
+	var best Scorer
+	bestN := 0
+	for v, s := range scorers {
+		if name, n, _ := splitVersion(v); name == id && n > bestN {
+			best, bestN = s, n
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("unknown scorer %q (have %s)", id, strings.Join(ScorerVersions(), ", "))
+	}
+	return best, nil
+}
+
+// ScorerVersions lists the registered versions.
+func ScorerVersions() []string {
+	out := make([]string, 0, len(scorers))
+	for v := range scorers {
+		out = append(out, v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// splitVersion splits "match-v2" into "match" and 2.
+func splitVersion(v string) (string, int, bool) {
+	i := strings.LastIndex(v, "-v")
+	if i <= 0 {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(v[i+2:])
+	if err != nil || n <= 0 {
+		return "", 0, false
+	}
+	return v[:i], n, true
+}
+
+// ComputePoints runs Compute (program minimums, 40/30/20/10 points) on the
+// matcher inputs.
+func ComputePoints(student EnrichedStudentProfile, program ProgramContext) MatchScore {
+	res := Compute(Profile{
+		GPA: student.GPA, GPAScale: student.GPAScale, GradingSystem: student.GradingSystem,
+		IELTS: student.IELTS, TOEFL: student.TOEFL, SAT: student.SAT, ACT: student.ACT,
+		GREVerbal: student.GREVerbal, GREQuant: student.GREQuant, GMAT: student.GMAT,
+		Duolingo: student.Duolingo, PTE: student.PTE, TestDaF: student.TestDaF,
+		BudgetYear:      student.BudgetYear,
+		HasAchievements: student.Achievements != (AchievementCounts{}) || student.HasAchievementNotes,
+	}, program.Requirements)
+	breakdown := res.Breakdown
+	return MatchScore{
+		AcademicScore:  breakdown.GPA + breakdown.Language + breakdown.Tests,
+		SpecialScore:   breakdown.Extras,
+		BreakdownScore: &breakdown,
+		OverallScore:   res.Score,
+		Category:       res.Category,
+		Reasons:        res.Reasons,
+	}
+}
@@ -0,0 +1,94 @@
+package scoring
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookupScorer(t *testing.T) {
+	s, err := LookupScorer("match-v1")
+	if err != nil || s.Version() != "match-v1" {
+		t.Fatalf("exact version: %v, %v", s, err)
+	}
+	if s, err := LookupScorer(" Points "); err != nil || s.Version() != "points-v1" {
+		t.Fatalf("by name: %v, %v", s, err)
+	}
+	if s, err := LookupScorer(DefaultScorer); err != nil || s.Version() != "match-v1" {
+		t.Fatalf("default: %v, %v", s, err)
+	}
+	if _, err := LookupScorer("magic"); err == nil {
+		t.Error("unknown scorer: expected an error")
+	}
+	if _, err := LookupScorer("match-v9"); err == nil {
+		t.Error("unknown version: expected an error")
+	}
+}
+
+func TestLookupScorerNewestVersion(t *testing.T) {
+	defer func(saved map[string]Scorer) { scorers = saved }(scorers)
+	scorers = map[string]Scorer{}
+	for _, v := range []string{"test-v2", "test-v10", "test-v1", "testing-v99"} {
+		Register(ScorerFunc(v, ComputeMatch))
+	}
+	if s, err := LookupScorer("test"); err != nil || s.Version() != "test-v10" {
+		t.Errorf("newest: %v, %v", s, err)
+	}
+	if got := ScorerVersions(); !reflect.DeepEqual(got, []string{"test-v1", "test-v10", "test-v2", "testing-v99"}) {
+		t.Errorf("ScorerVersions = %v", got)
+	}
+}
+
+func TestRegisterRejects(t *testing.T) {
+	defer func(saved map[string]Scorer) { scorers = saved }(scorers)
+	scorers = map[string]Scorer{}
+	mustPanic := func(name string, f func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: expected a panic", name)
+			}
+		}()
+		f()
+	}
+	Register(ScorerFunc("match-v1", ComputeMatch))
+	mustPanic("duplicate", func() { Register(ScorerFunc("match-v1", ComputeMatch)) })
+	mustPanic("no version", func() { Register(ScorerFunc("match", ComputeMatch)) })
+	mustPanic("bad version", func() { Register(ScorerFunc("match-v0", ComputeMatch)) })
+}
+
+func TestScorerStampsVersion(t *testing.T) {
+	student := EnrichedStudentProfile{GPA: f64Ptr(3.6), GPAScale: f64Ptr(4), IELTS: f64Ptr(7)}
+	program := ProgramContext{AvgGPA: f64Ptr(3.5), AvgIELTS: f64Ptr(6.5), AcceptanceRate: f64Ptr(30)}
+
+	s, _ := LookupScorer("match-v1")
+	got := s.Score(student, program)
+	want := ComputeMatch(student, program)
+	if got.ScorerVersion != "match-v1@default" || got.OverallScore != want.OverallScore || got.Category != want.Category {
+		t.Errorf("match-v1: %+v, ComputeMatch %+v", got, want)
+	}
+}
+
+func TestComputePointsMatchesCompute(t *testing.T) {
+	student := EnrichedStudentProfile{
+		GPA: f64Ptr(3.6), GPAScale: f64Ptr(4), IELTS: f64Ptr(6.5), SAT: intPtr(1350),
+		HasAchievementNotes: true,
+	}
+	program := ProgramContext{Requirements: Requirements{MinGPA: f64Ptr(3.0), MinIELTS: f64Ptr(7.0)}}
+
+	got := ComputePoints(student, program)
+	want := Compute(Profile{
+		GPA: student.GPA, GPAScale: student.GPAScale, IELTS: student.IELTS, SAT: student.SAT,
+		HasAchievements: true,
+	}, program.Requirements)
+	if got.OverallScore != want.Score || got.Category != want.Category ||
+		!reflect.DeepEqual(got.Reasons, want.Reasons) || *got.BreakdownScore != want.Breakdown {
+		t.Errorf("ComputePoints = %+v, Compute = %+v", got, want)
+	}
+
+	// structured achievements count as well
+	student.HasAchievementNotes = false
+	student.Achievements.Sports = 1
+	if got := ComputePoints(student, program); got.BreakdownScore.Extras != 10 {
+		t.Errorf("achievements: extras = %d", got.BreakdownScore.Extras)
+	}
+}
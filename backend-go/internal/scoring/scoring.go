@@ -5,9 +5,17 @@ import "math"
 type Profile struct {
   GPA *float64
   GPAScale *float64
+  GradingSystem string // registry code (grading.go); "" = linear GPA/GPAScale
   IELTS *float64
   TOEFL *int
   SAT *int
+  ACT *int
+  GREVerbal *int
+  GREQuant *int
+  GMAT *int
+  Duolingo *int
+  PTE *int
+  TestDaF *int // lowest TDN section level, 3-5
   BudgetYear *float64
   HasAchievements bool // achievements_summary or awards present
 }
@@ -17,6 +25,12 @@ type Requirements struct {
   MinIELTS *float64
   MinTOEFL *int
   MinSAT *int
+  MinACT *int
+  MinGRE *int // verbal + quant, 260-340
+  MinGMAT *int
+  MinDuolingo *int
+  MinPTE *int
+  MinTestDaF *int // set for German-taught programs
 }
 
 type Breakdown struct {
@@ -30,99 +44,129 @@ type Result struct {
   Score int `json:"score"`           // 0-100
   Category string `json:"category"`  // "reach" | "target" | "safety"
   Breakdown Breakdown `json:"breakdown"`
-  Reasons []string `json:"reasons"`
+  Reasons []Reason `json:"reasons"`
 }
 
 func Compute(p Profile, r Requirements) Result {
   score := 0
-  reasons := []string{}
+  reasons := []Reason{}
   breakdown := Breakdown{}
 
   // GPA (0-40 points)
   gpaScore := 0
-  if p.GPA != nil && p.GPAScale != nil && *p.GPAScale > 0 {
-    gpaNorm := (*p.GPA) / (*p.GPAScale) // 0..1
-    gpaScore = int(math.Round(40 * clamp01(gpaNorm)))
+  if gpa, ok := GPAUS4(p.GPA, p.GradingSystem, p.GPAScale); ok {
+    gpaScore = int(math.Round(40 * clamp01(gpa/4.0))) // US 4.0 equivalent
     score += gpaScore
     
     if r.MinGPA != nil {
-      if *p.GPA < *r.MinGPA {
-        reasons = append(reasons, "GPA ниже минимальных требований программы")
-      } else if *p.GPA >= *r.MinGPA {
-        reasons = append(reasons, "GPA соответствует требованиям программы")
-      }
+      reasons = append(reasons, minReason(newReason(ComponentGPA, "", ""), round3(gpa), *r.MinGPA))
     } else {
-      reasons = append(reasons, "GPA соответствует средним показателям")
+      reasons = append(reasons, newReason(ComponentGPA, "gpa_no_reference", SeverityNeutral, "value", round3(gpa)))
     }
   } else {
-    reasons = append(reasons, "GPA не указан, точность оценки снижена")
+    reasons = append(reasons, newReason(ComponentGPA, "gpa_missing", SeverityNeutral))
   }
   breakdown.GPA = gpaScore
 
   // Language (0-30 points)
   langScore := 0
-  if p.IELTS != nil {
+  if r.MinTestDaF != nil {
+    // German-taught program: TDN 3/4/5 -> 10/20/30 points
+    if p.TestDaF != nil {
+      langScore = int(math.Round(30 * clamp01(float64(*p.TestDaF-2)/3.0)))
+      score += langScore
+      reasons = append(reasons, minReason(languageReason("TestDaF"), float64(*p.TestDaF), float64(*r.MinTestDaF)))
+    } else {
+      reasons = append(reasons, newReason(ComponentLanguage, "language_required_missing", SeverityNegative, "test", "TestDaF"))
+    }
+  } else if p.IELTS != nil {
     langScore = int(math.Round(30 * clamp01(*p.IELTS/9.0)))
     score += langScore
     
     if r.MinIELTS != nil {
-      if *p.IELTS < *r.MinIELTS {
-        reasons = append(reasons, "IELTS ниже среднего по программе")
-      } else if *p.IELTS >= *r.MinIELTS {
-        reasons = append(reasons, "IELTS соответствует требованиям")
-      }
+      reasons = append(reasons, minReason(languageReason("IELTS"), *p.IELTS, *r.MinIELTS))
+    } else if *p.IELTS >= 7.0 {
+      reasons = append(reasons, languageReason("IELTS").scored("language_good", SeverityPositive, *p.IELTS))
     } else {
-      if *p.IELTS >= 7.0 {
-        reasons = append(reasons, "Хороший уровень английского (IELTS)")
-      } else {
-        reasons = append(reasons, "IELTS на среднем уровне")
-      }
+      reasons = append(reasons, languageReason("IELTS").scored("language_average", SeverityNeutral, *p.IELTS))
     }
   } else if p.TOEFL != nil {
     langScore = int(math.Round(30 * clamp01(float64(*p.TOEFL)/120.0)))
     score += langScore
     
     if r.MinTOEFL != nil {
-      if *p.TOEFL < *r.MinTOEFL {
-        reasons = append(reasons, "TOEFL ниже среднего по программе")
-      } else {
-        reasons = append(reasons, "TOEFL соответствует требованиям")
-      }
+      reasons = append(reasons, minReason(languageReason("TOEFL"), float64(*p.TOEFL), float64(*r.MinTOEFL)))
+    } else if *p.TOEFL >= 100 {
+      reasons = append(reasons, languageReason("TOEFL").scored("language_good", SeverityPositive, float64(*p.TOEFL)))
     } else {
-      if *p.TOEFL >= 100 {
-        reasons = append(reasons, "Хороший уровень английского (TOEFL)")
-      } else {
-        reasons = append(reasons, "TOEFL на среднем уровне")
-      }
+      reasons = append(reasons, languageReason("TOEFL").scored("language_average", SeverityNeutral, float64(*p.TOEFL)))
+    }
+  } else if eng := englishResults(nil, nil, p.PTE, p.Duolingo); len(eng) > 0 {
+    // PTE / Duolingo: points in IELTS equivalents, requirement on the same
+    // test if the program has one, otherwise in IELTS equivalents too
+    e := eng[0]
+    langScore = int(math.Round(30 * clamp01(e.IELTS/9.0)))
+    score += langScore
+
+    same := map[string]*int{"PTE": r.MinPTE, "Duolingo": r.MinDuolingo}[e.Test]
+    if same != nil {
+      reasons = append(reasons, minReason(languageReason(e.Test), e.Score, float64(*same)))
+    } else if refs := englishResults(r.MinIELTS, r.MinTOEFL, r.MinPTE, r.MinDuolingo); len(refs) > 0 {
+      reasons = append(reasons, minReason(languageReason(e.Test).with("compared_as", "IELTS"), e.IELTS, refs[0].IELTS))
+    } else {
+      reasons = append(reasons, languageReason(e.Test).scored("language_counted", SeverityNeutral, e.Score))
     }
   } else {
-    reasons = append(reasons, "Языковой сертификат не указан (IELTS/TOEFL)")
+    reasons = append(reasons, newReason(ComponentLanguage, "language_missing", SeverityNegative))
   }
   breakdown.Language = langScore
 
-  // Tests (0-20 points) - SAT/GRE
+  // Tests (0-20 points) - GRE/GMAT when the program asks for them, else SAT/ACT
   testScore := 0
-  if p.SAT != nil {
-    testScore = int(math.Round(20 * clamp01(float64(*p.SAT)/1600.0)))
-    score += testScore
-    
-    if r.MinSAT != nil {
-      if *p.SAT < *r.MinSAT {
-        reasons = append(reasons, "SAT ниже минимальных требований")
+  if r.MinGRE != nil || r.MinGMAT != nil {
+    gre, hasGRE := greTotal(p.GREVerbal, p.GREQuant)
+    switch {
+    case p.GMAT != nil && (r.MinGMAT != nil || !hasGRE):
+      testScore = int(math.Round(20 * clamp01(float64(*p.GMAT-200)/600.0)))
+      score += testScore
+      if r.MinGMAT != nil {
+        reasons = append(reasons, minReason(testsReason("GMAT"), float64(*p.GMAT), float64(*r.MinGMAT)))
       } else {
-        reasons = append(reasons, "SAT соответствует требованиям")
+        reasons = append(reasons, newReason(ComponentTests, "tests_other_test", SeverityNeutral, "test", "GMAT", "program_test", "GRE"))
       }
-    } else {
-      if *p.SAT >= 1400 {
-        reasons = append(reasons, "Хороший результат SAT")
+    case hasGRE:
+      testScore = int(math.Round(20 * clamp01(float64(gre-260)/80.0)))
+      score += testScore
+      if r.MinGRE != nil {
+        reasons = append(reasons, minReason(testsReason("GRE"), float64(gre), float64(*r.MinGRE)))
       } else {
-        reasons = append(reasons, "SAT на среднем уровне")
+        reasons = append(reasons, newReason(ComponentTests, "tests_other_test", SeverityNeutral, "test", "GRE", "program_test", "GMAT"))
       }
+    default:
+      reasons = append(reasons, newReason(ComponentTests, "tests_required_missing", SeverityNegative, "test", "GRE/GMAT"))
+    }
+  } else if sat, ok := satEquivalent(p.SAT, p.ACT); ok {
+    // ACT counts through the ACT/SAT concordance
+    testScore = int(math.Round(20 * clamp01(float64(sat)/1600.0)))
+    score += testScore
+
+    label := "SAT"
+    if p.SAT == nil { label = "ACT" }
+    minSAT, hasMin := satEquivalent(r.MinSAT, r.MinACT)
+    switch {
+    case p.SAT == nil && r.MinACT != nil:
+      reasons = append(reasons, minReason(testsReason("ACT"), float64(*p.ACT), float64(*r.MinACT)))
+    case hasMin:
+      reasons = append(reasons, minReason(testsReason(label), float64(sat), float64(minSAT)))
+    case sat >= 1400:
+      reasons = append(reasons, testsReason(label).scored("tests_good", SeverityPositive, float64(sat)))
+    default:
+      reasons = append(reasons, testsReason(label).scored("tests_average", SeverityNeutral, float64(sat)))
     }
   } else {
     // SAT не обязателен для всех программ, но если требуется - это проблема
-    if r.MinSAT != nil {
-      reasons = append(reasons, "SAT не указан, но требуется для программы")
+    if r.MinSAT != nil || r.MinACT != nil {
+      reasons = append(reasons, newReason(ComponentTests, "tests_required_missing", SeverityNegative, "test", "SAT/ACT"))
     }
   }
   breakdown.Tests = testScore
@@ -132,9 +176,9 @@ func Compute(p Profile, r Requirements) Result {
   if p.HasAchievements {
     extraScore = 10
     score += extraScore
-    reasons = append(reasons, "Есть дополнительные достижения")
+    reasons = append(reasons, newReason(ComponentExtras, "extras_present", SeverityPositive))
   } else {
-    reasons = append(reasons, "Рекомендуется добавить достижения для повышения шансов")
+    reasons = append(reasons, newReason(ComponentExtras, "extras_missing", SeverityNegative))
   }
   breakdown.Extras = extraScore
 
@@ -159,6 +203,19 @@ func Compute(p Profile, r Requirements) Result {
   }
 }
 
+// minReason compares a result with the program minimum on the same scale;
+// r names the component and test.
+func minReason(r Reason, v, min float64) Reason {
+  r = r.with("value", v).with("program_min", min)
+  if v < min {
+    r.Code, r.Severity = r.Component+"_below_minimum", SeverityNegative
+  } else {
+    r.Code, r.Severity = r.Component+"_meets_minimum", SeverityPositive
+  }
+  return r
+}
+
+
 func clamp01(x float64) float64 {
   if x < 0 { return 0 }
   if x > 1 { return 1 }
@@ -0,0 +1,336 @@
+package scoring
+
+import (
+	"fmt"
+	"sort"
+
+	"unichance-backend-go/internal/validation"
+)
+
+// Weights are the tunable points and thresholds of ComputeMatch. The match
+// scorer reads them from ActiveWeights, which SCORING_WEIGHTS can load from
+// a JSON file (hot-reloaded, see WeightsStore); DefaultWeights are the
+// built-in values. The components can add up to more than 100; the score
+// is capped at 100.
+type Weights struct {
+	// Version names this set of weights; it is stored with every score as
+	// part of the scorer version ("match-v1@2024-06").
+	Version string `json:"version"`
+
+	Academic     AcademicWeights    `json:"academic"`
+	Competitive  CompetitiveWeights `json:"competitive"`
+	Financial    FinancialWeights   `json:"financial"`
+	Achievements AchievementWeights `json:"achievements"`
+	Categories   CategoryWeights    `json:"categories"`
+}
+
+// Band scores a result against the program average: at least
+// avg+above_margin gets Above, at least avg gets At, at least
+// avg-close_margin gets Close, lower a share of Close (result/avg).
+// Without a program average the result is scaled to Above.
+type Band struct {
+	Above       int     `json:"above"`
+	At          int     `json:"at"`
+	Close       int     `json:"close"`
+	AboveMargin float64 `json:"above_margin"`
+	CloseMargin float64 `json:"close_margin"`
+}
+
+type AcademicWeights struct {
+	GPA Band `json:"gpa"` // US 4.0 scale
+	// ielts, toefl, pte, duolingo, testdaf (TDN)
+	Language map[string]Band `json:"language"`
+	// sat, act, gre (verbal + quant), gmat
+	Tests map[string]Band `json:"tests"`
+}
+
+// CompetitiveWeights: Max * (1 - acceptance rate) * tier factor /
+// competitive factor, the tier picked by how far the student's GPA is above
+// the admitted average (relative, 0.1 = 10%).
+type CompetitiveWeights struct {
+	Max     int               `json:"max"`
+	Default int               `json:"default"` // no acceptance rate or GPA
+	Tiers   []CompetitiveTier `json:"tiers"`   // by MinVsAvg, highest first; the last has none
+	// acceptance rates (percent) above which competition is low / medium
+	LowCompetitionRate    float64 `json:"low_competition_rate"`
+	MediumCompetitionRate float64 `json:"medium_competition_rate"`
+}
+
+type CompetitiveTier struct {
+	MinVsAvg *float64 `json:"min_vs_avg"` // nil: everyone below the previous tiers
+	Factor   float64  `json:"factor"`
+}
+
+// FinancialWeights: budget against the total cost of attendance.
+type FinancialWeights struct {
+	Max                     int     `json:"max"`                  // budget covers the cost
+	PartialCoverage         float64 `json:"partial_coverage"`     // budget share for Partial
+	Partial                 int     `json:"partial"`              // loan range
+	ScholarshipCoverage     float64 `json:"scholarship_coverage"` // budget + scholarship share for WithScholarship
+	WithScholarship         int     `json:"with_scholarship"`
+	Short                   int     `json:"short"`                     // not enough even with a scholarship
+	ScholarshipUnknownPrice int     `json:"scholarship_unknown_price"` // no comparable cost, has scholarships
+}
+
+// AchievementWeights: the weighted sum of the achievement counters picks the
+// first tier it reaches.
+type AchievementWeights struct {
+	// olympiad, leadership, sports, volunteering, other
+	Weights map[string]float64 `json:"weights"`
+	Tiers   []AchievementTier  `json:"tiers"` // by Min, highest first
+}
+
+type AchievementTier struct {
+	Min    int `json:"min"`
+	Points int `json:"points"`
+}
+
+// CategoryWeights: reach/target/safety on the admit probability when there
+// is admission data, otherwise on the score.
+type CategoryWeights struct {
+	SafetyProbability float64 `json:"safety_probability"` // P >= safety
+	TargetProbability float64 `json:"target_probability"` // target <= P < safety, below: reach
+	SafetyScore       int     `json:"safety_score"`
+	ReachScore        int     `json:"reach_score"`     // score < reach_score: reach
+	LongShotScore     int     `json:"long_shot_score"` // below: advise other programs
+}
+
+var (
+	languageTests    = []string{"ielts", "toefl", "pte", "duolingo", "testdaf"}
+	admissionTests   = []string{"sat", "act", "gre", "gmat"}
+	achievementKinds = []string{"olympiad", "leadership", "sports", "volunteering", "other"}
+)
+
+// DefaultWeights are the built-in weights.
+func DefaultWeights() Weights {
+	tier := func(v float64) *float64 { return &v }
+	return Weights{
+		Version: "default",
+		Academic: AcademicWeights{
+			GPA: Band{Above: 25, At: 20, Close: 12, AboveMargin: 0.1, CloseMargin: 0.3},
+			Language: map[string]Band{
+				"ielts":    {Above: 20, At: 16, Close: 10, AboveMargin: 0.5, CloseMargin: 0.5},
+				"toefl":    {Above: 20, At: 16, Close: 10, AboveMargin: 10, CloseMargin: 10},
+				"pte":      {Above: 20, At: 16, Close: 10, AboveMargin: 7, CloseMargin: 7},
+				"duolingo": {Above: 20, At: 16, Close: 10, AboveMargin: 10, CloseMargin: 10},
+				"testdaf":  {Above: 20, At: 16, Close: 10, AboveMargin: 1, CloseMargin: 1},
+			},
+			Tests: map[string]Band{
+				"sat":  {Above: 15, At: 12, Close: 7, AboveMargin: 100, CloseMargin: 100},
+				"act":  {Above: 15, At: 12, Close: 7, AboveMargin: 3, CloseMargin: 3},
+				"gre":  {Above: 15, At: 12, Close: 7, AboveMargin: 10, CloseMargin: 10},
+				"gmat": {Above: 15, At: 12, Close: 7, AboveMargin: 50, CloseMargin: 50},
+			},
+		},
+		Competitive: CompetitiveWeights{
+			Max:     30,
+			Default: 15,
+			Tiers: []CompetitiveTier{
+				{MinVsAvg: tier(0.1), Factor: 1.2},
+				{MinVsAvg: tier(0), Factor: 1},
+				{MinVsAvg: tier(-0.1), Factor: 0.7},
+				{Factor: 0.3},
+			},
+			LowCompetitionRate:    30,
+			MediumCompetitionRate: 10,
+		},
+		Financial: FinancialWeights{
+			Max:                     20,
+			PartialCoverage:         0.7,
+			Partial:                 14,
+			ScholarshipCoverage:     0.8,
+			WithScholarship:         16,
+			Short:                   6,
+			ScholarshipUnknownPrice: 12,
+		},
+		Achievements: AchievementWeights{
+			Weights: map[string]float64{
+				"olympiad": 3, "leadership": 2, "sports": 1, "volunteering": 0.8, "other": 1,
+			},
+			Tiers: []AchievementTier{{Min: 5, Points: 10}, {Min: 3, Points: 7}, {Min: 1, Points: 4}},
+		},
+		Categories: CategoryWeights{
+			SafetyProbability: 0.6,
+			TargetProbability: 0.25,
+			SafetyScore:       70,
+			ReachScore:        40,
+			LongShotScore:     20,
+		},
+	}
+}
+
+// builtinWeights back ComputeMatch.
+var builtinWeights = DefaultWeights()
+
+// Validate checks the weights are complete and ordered; field names are
+// JSON paths ("academic.language.ielts.at").
+func (w Weights) Validate() error {
+	var errs validation.Errors
+	if w.Version == "" {
+		errs.Add("version", "required", "version is required")
+	}
+
+	checkBand := func(field string, b Band) {
+		if !(0 <= b.Close && b.Close <= b.At && b.At <= b.Above) {
+			errs.Add(field, "order", "points must satisfy 0 <= close <= at <= above")
+		}
+		if b.AboveMargin < 0 || b.CloseMargin < 0 {
+			errs.Add(field, "negative", "margins must not be negative")
+		}
+	}
+	checkBands := func(field string, bands map[string]Band, keys []string) {
+		for _, k := range keys {
+			b, ok := bands[k]
+			if !ok {
+				errs.Add(field+"."+k, "required", k+" band is required")
+				continue
+			}
+			checkBand(field+"."+k, b)
+		}
+		for _, k := range sortedKeys(bands) {
+			if !contains(keys, k) {
+				errs.Add(field+"."+k, "unknown", "unknown test "+k)
+			}
+		}
+	}
+	checkBand("academic.gpa", w.Academic.GPA)
+	checkBands("academic.language", w.Academic.Language, languageTests)
+	checkBands("academic.tests", w.Academic.Tests, admissionTests)
+
+	c := w.Competitive
+	if c.Max <= 0 || c.Default < 0 || c.Default > c.Max {
+		errs.Add("competitive.default", "out_of_range", "default must be between 0 and max (max > 0)")
+	}
+	if len(c.Tiers) == 0 || c.Tiers[len(c.Tiers)-1].MinVsAvg != nil {
+		errs.Add("competitive.tiers", "open_end", "the last tier must have no min_vs_avg")
+	}
+	for i, t := range c.Tiers {
+		field := fmt.Sprintf("competitive.tiers[%d]", i)
+		if t.Factor < 0 {
+			errs.Add(field+".factor", "negative", "factor must not be negative")
+		}
+		if i < len(c.Tiers)-1 && t.MinVsAvg == nil {
+			errs.Add(field+".min_vs_avg", "required", "only the last tier may omit min_vs_avg")
+		}
+		if i > 0 && t.MinVsAvg != nil && c.Tiers[i-1].MinVsAvg != nil && *t.MinVsAvg >= *c.Tiers[i-1].MinVsAvg {
+			errs.Add(field+".min_vs_avg", "order", "tiers must go from the highest min_vs_avg down")
+		}
+	}
+	if !(0 < c.MediumCompetitionRate && c.MediumCompetitionRate < c.LowCompetitionRate && c.LowCompetitionRate < 100) {
+		errs.Add("competitive.low_competition_rate", "order", "rates must satisfy 0 < medium < low < 100")
+	}
+
+	f := w.Financial
+	if !(0 <= f.Short && f.Short <= f.Partial && f.Partial <= f.Max && f.Short <= f.WithScholarship && f.WithScholarship <= f.Max) {
+		errs.Add("financial", "order", "points must satisfy 0 <= short <= partial, with_scholarship <= max")
+	}
+	if f.ScholarshipUnknownPrice < 0 || f.ScholarshipUnknownPrice > f.Max {
+		errs.Add("financial.scholarship_unknown_price", "out_of_range", "must be between 0 and max")
+	}
+	if !(0 < f.PartialCoverage && f.PartialCoverage < 1) {
+		errs.Add("financial.partial_coverage", "out_of_range", "must be between 0 and 1")
+	}
+	if !(0 < f.ScholarshipCoverage && f.ScholarshipCoverage <= 1) {
+		errs.Add("financial.scholarship_coverage", "out_of_range", "must be above 0 and at most 1")
+	}
+
+	a := w.Achievements
+	for _, k := range achievementKinds {
+		if v, ok := a.Weights[k]; !ok {
+			errs.Add("achievements.weights."+k, "required", k+" weight is required")
+		} else if v < 0 {
+			errs.Add("achievements.weights."+k, "negative", "weight must not be negative")
+		}
+	}
+	for _, k := range sortedKeys(a.Weights) {
+		if !contains(achievementKinds, k) {
+			errs.Add("achievements.weights."+k, "unknown", "unknown achievement kind "+k)
+		}
+	}
+	for i, t := range a.Tiers {
+		field := fmt.Sprintf("achievements.tiers[%d]", i)
+		if t.Min <= 0 || t.Points < 0 {
+			errs.Add(field, "out_of_range", "min must be positive and points not negative")
+		}
+		if i > 0 && (t.Min >= a.Tiers[i-1].Min || t.Points > a.Tiers[i-1].Points) {
+			errs.Add(field, "order", "tiers must go from the highest min down, points not increasing")
+		}
+	}
+
+	k := w.Categories
+	if !(0 < k.TargetProbability && k.TargetProbability < k.SafetyProbability && k.SafetyProbability < 1) {
+		errs.Add("categories.safety_probability", "order", "probabilities must satisfy 0 < target < safety < 1")
+	}
+	if !(0 <= k.LongShotScore && k.LongShotScore <= k.ReachScore && k.ReachScore < k.SafetyScore && k.SafetyScore <= 100) {
+		errs.Add("categories.safety_score", "order", "scores must satisfy 0 <= long_shot <= reach < safety <= 100")
+	}
+	return errs.Err()
+}
+
+// ForProbability maps an admit probability to reach/target/safety.
+func (k CategoryWeights) ForProbability(p float64) string {
+	switch {
+	case p >= k.SafetyProbability:
+		return "safety"
+	case p >= k.TargetProbability:
+		return "target"
+	}
+	return "reach"
+}
+
+// ForScore maps a score to reach/target/safety (no admission data).
+func (k CategoryWeights) ForScore(score int) string {
+	switch {
+	case score >= k.SafetyScore:
+		return "safety"
+	case score < k.ReachScore:
+		return "reach"
+	}
+	return "target"
+}
+
+// points is the weighted achievement sum, the tier it reaches (-1: none)
+// and the tier's points.
+func (a AchievementWeights) points(c AchievementCounts) (sum, tier, points int) {
+	counts := map[string]int{
+		"olympiad": c.Olympiads, "leadership": c.Leadership, "sports": c.Sports,
+		"volunteering": c.Volunteering, "other": c.Other,
+	}
+	for _, k := range achievementKinds {
+		sum += int(float64(counts[k]) * a.Weights[k])
+	}
+	for i, t := range a.Tiers {
+		if sum >= t.Min {
+			return sum, i, t.Points
+		}
+	}
+	return sum, -1, 0
+}
+
+// factor is the tier factor for the student's relative GPA distance.
+func (c CompetitiveWeights) factor(vsAvg float64) float64 {
+	for _, t := range c.Tiers {
+		if t.MinVsAvg == nil || vsAvg >= *t.MinVsAvg {
+			return t.Factor
+		}
+	}
+	return 0
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package scoring
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"unichance-backend-go/internal/validation"
+)
+
+// LoadedWeights are the weights in use and where they came from.
+type LoadedWeights struct {
+	Weights
+	Source   string    `json:"-"` // file path, or "builtin"; not for clients
+	LoadedAt time.Time `json:"loaded_at"`
+}
+
+// WeightsStore holds the current weights; a file can replace them at any
+// time (Watch), a bad file never does.
+type WeightsStore struct {
+	mu  sync.RWMutex
+	cur LoadedWeights
+
+	// file state of the last load attempt, to spot changes
+	modTime time.Time
+	size    int64
+}
+
+// ActiveWeights are the weights of the registered match scorer.
+var ActiveWeights = NewWeightsStore(DefaultWeights())
+
+// NewWeightsStore starts with w as "builtin".
+func NewWeightsStore(w Weights) *WeightsStore {
+	return &WeightsStore{cur: LoadedWeights{Weights: w, Source: "builtin", LoadedAt: time.Now()}}
+}
+
+// Get returns the current weights.
+func (s *WeightsStore) Get() LoadedWeights {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cur
+}
+
+// Set validates w and makes it current.
+func (s *WeightsStore) Set(w Weights, source string) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.cur = LoadedWeights{Weights: w, Source: source, LoadedAt: time.Now()}
+	s.mu.Unlock()
+	return nil
+}
+
+// ParseWeights reads a JSON weights document. Omitted fields keep their
+// DefaultWeights value, unknown fields are an error (typos would otherwise
+// silently do nothing). A tier list replaces the default one as a whole.
+// The version must differ from the builtin one: it is stamped on every
+// score, and the same version must mean the same weights.
+func ParseWeights(r io.Reader) (Weights, error) {
+	defaults := DefaultWeights()
+	w := defaults
+	// decoding reuses slice elements, so a shorter list would keep fields
+	// of the default tiers it doesn't mention
+	w.Competitive.Tiers, w.Achievements.Tiers = nil, nil
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&w); err != nil {
+		return w, fmt.Errorf("weights: %w", err)
+	}
+	if w.Competitive.Tiers == nil {
+		w.Competitive.Tiers = defaults.Competitive.Tiers
+	}
+	if w.Achievements.Tiers == nil {
+		w.Achievements.Tiers = defaults.Achievements.Tiers
+	}
+	if w.Version == defaults.Version {
+		return w, validation.Errors{{Field: "version", Code: "reserved",
+			Message: "version " + defaults.Version + " is the builtin weights; name yours differently"}}
+	}
+	return w, w.Validate()
+}
+
+// LoadFile replaces the weights with the file's; on error the current
+// weights stay.
+func (s *WeightsStore) LoadFile(path string) error {
+	st, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.modTime, s.size = st.ModTime(), st.Size()
+	s.mu.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	w, err := ParseWeights(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return s.Set(w, path)
+}
+
+// changed reports whether the file differs from the last load attempt.
+func (s *WeightsStore) changed(path string) bool {
+	st, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !st.ModTime().Equal(s.modTime) || st.Size() != s.size
+}
+
+// Watch polls path every interval and reloads it when it changes. A file
+// that fails to parse or validate is logged and skipped until it changes
+// again; the weights in use stay.
+func (s *WeightsStore) Watch(ctx context.Context, path string, every time.Duration, logf func(string, ...any)) {
+	if every < time.Second {
+		every = time.Second
+	}
+	go func() {
+		t := time.NewTicker(every)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if !s.changed(path) {
+					continue
+				}
+				if err := s.LoadFile(path); err != nil {
+					if logf != nil {
+						logf("scoring: weights not reloaded: %v", err)
+					}
+					continue
+				}
+				if logf != nil {
+					logf("scoring: weights %q loaded from %s", s.Get().Version, path)
+				}
+			}
+		}
+	}()
+}
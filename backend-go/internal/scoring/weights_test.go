@@ -0,0 +1,170 @@
+package scoring
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"unichance-backend-go/internal/validation"
+)
+
+func TestDefaultWeightsValidate(t *testing.T) {
+	if err := DefaultWeights().Validate(); err != nil {
+		t.Fatalf("defaults: %v", err)
+	}
+}
+
+func TestWeightsValidateRejects(t *testing.T) {
+	cases := map[string]func(*Weights){
+		"version":             func(w *Weights) { w.Version = "" },
+		"academic.gpa":        func(w *Weights) { w.Academic.GPA.Close = 30 },
+		"academic.tests.gmat": func(w *Weights) { delete(w.Academic.Tests, "gmat") },
+		"academic.tests.lsat": func(w *Weights) { w.Academic.Tests["lsat"] = w.Academic.Tests["sat"] },
+		"competitive.tiers":   func(w *Weights) { w.Competitive.Tiers = w.Competitive.Tiers[:2] },
+		"competitive.tiers[1].min_vs_avg": func(w *Weights) {
+			w.Competitive.Tiers[0], w.Competitive.Tiers[1] = w.Competitive.Tiers[1], w.Competitive.Tiers[0]
+		},
+		"financial.partial_coverage":   func(w *Weights) { w.Financial.PartialCoverage = 1.5 },
+		"achievements.weights.olympic": func(w *Weights) { w.Achievements.Weights["olympic"] = 3 },
+		"categories.safety_probability": func(w *Weights) {
+			w.Categories.TargetProbability = 0.7
+		},
+		"financial": func(w *Weights) { w.Financial.Partial = 25 },
+	}
+	for field, mutate := range cases {
+		w := DefaultWeights()
+		mutate(&w)
+		errs, ok := validation.As(w.Validate())
+		if !ok {
+			t.Errorf("%q: expected validation errors", field)
+			continue
+		}
+		found := false
+		for _, e := range errs {
+			found = found || e.Field == field
+		}
+		if !found {
+			t.Errorf("%q: not among %+v", field, errs)
+		}
+	}
+}
+
+func TestParseWeights(t *testing.T) {
+	w, err := ParseWeights(strings.NewReader(`{
+		"version": "2024-06",
+		"academic": {"gpa": {"above": 20, "at": 16, "close": 10, "above_margin": 0.2, "close_margin": 0.3}},
+		"achievements": {"tiers": [{"min": 4, "points": 15}, {"min": 2, "points": 8}]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Version != "2024-06" || w.Academic.GPA.Above != 20 || w.Achievements.Tiers[0].Points != 15 {
+		t.Errorf("overlay not applied: %+v", w)
+	}
+	if !reflect.DeepEqual(w.Academic.Language, DefaultWeights().Academic.Language) {
+		t.Error("omitted fields should keep the defaults")
+	}
+
+	if _, err := ParseWeights(strings.NewReader(`{"version": "x", "finacial": {}}`)); err == nil {
+		t.Error("unknown field: expected an error")
+	}
+	if _, err := ParseWeights(strings.NewReader(`{"version": "x", "financial": {"partial": 25}}`)); err == nil {
+		t.Error("partial above max: expected an error")
+	}
+	if _, err := ParseWeights(strings.NewReader(`{"version": "default"}`)); err == nil {
+		t.Error("builtin version: expected an error")
+	}
+
+	// a shorter tier list replaces the defaults, it doesn't inherit their fields
+	w, err = ParseWeights(strings.NewReader(`{
+		"version": "two-tiers",
+		"competitive": {"tiers": [{"min_vs_avg": 0.05, "factor": 1.2}, {"factor": 0.5}]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tiers := w.Competitive.Tiers; len(tiers) != 2 || tiers[1].MinVsAvg != nil || tiers[1].Factor != 0.5 {
+		t.Errorf("tiers = %+v", tiers)
+	}
+}
+
+func TestExampleWeightsFile(t *testing.T) {
+	f, err := os.Open("../../scoring_weights.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w, err := ParseWeights(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Version == DefaultWeights().Version {
+		t.Error("the example must not claim the builtin version")
+	}
+	w.Version = DefaultWeights().Version
+	if !reflect.DeepEqual(w, DefaultWeights()) {
+		t.Error("scoring_weights.example.json differs from DefaultWeights")
+	}
+}
+
+func TestWeightsStoreLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.json")
+	s := NewWeightsStore(DefaultWeights())
+	if err := s.Set(Weights{}, "test"); err == nil || s.Get().Source != "builtin" {
+		t.Fatal("invalid weights must not replace the current ones")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"version": "v2"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Get(); got.Version != "v2" || got.Source != path {
+		t.Errorf("loaded %q from %q", got.Version, got.Source)
+	}
+	if s.changed(path) {
+		t.Error("unchanged file reported as changed")
+	}
+
+	// a broken file is noticed once and keeps the weights in use
+	if err := os.WriteFile(path, []byte(`{"version": "v3", "financial": {"partial": 25}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Second)
+	os.Chtimes(path, later, later)
+	if !s.changed(path) {
+		t.Fatal("rewritten file not reported as changed")
+	}
+	if err := s.LoadFile(path); err == nil {
+		t.Error("invalid file: expected an error")
+	}
+	if s.Get().Version != "v2" || s.changed(path) {
+		t.Errorf("after a bad file: version %q, changed %v", s.Get().Version, s.changed(path))
+	}
+}
+
+func TestComputeMatchWithWeights(t *testing.T) {
+	student := EnrichedStudentProfile{GPA: f64Ptr(3.8), GPAScale: f64Ptr(4), IELTS: f64Ptr(7.5)}
+	// no acceptance rate: no admit probability, the category follows the score
+	program := ProgramContext{AvgGPA: f64Ptr(3.6), AvgIELTS: f64Ptr(7), CompetitiveFactor: 1}
+
+	w := DefaultWeights()
+	base := ComputeMatchWith(w, student, program)
+	if base.BreakdownScore.GPA != 25 {
+		t.Fatalf("default GPA points = %d", base.BreakdownScore.GPA)
+	}
+
+	w.Academic.GPA.Above, w.Academic.GPA.AboveMargin = 20, 0.3 // 3.8 is now only "at"
+	w.Categories.SafetyScore, w.Categories.ReachScore = 95, 90
+	got := ComputeMatchWith(w, student, program)
+	if got.BreakdownScore.GPA != 20 || got.OverallScore != base.OverallScore-5 {
+		t.Errorf("GPA points = %d, score %d (was %d)", got.BreakdownScore.GPA, got.OverallScore, base.OverallScore)
+	}
+	if got.Category != "reach" || got.ImprovementPath.TargetScore != 95 {
+		t.Errorf("category %s, target %d", got.Category, got.ImprovementPath.TargetScore)
+	}
+}
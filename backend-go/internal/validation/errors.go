@@ -0,0 +1,54 @@
+// Package validation carries structured, per-field input errors from services
+// to handlers, so clients get machine-readable codes instead of err.Error().
+package validation
+
+import (
+	"errors"
+	"strings"
+)
+
+// FieldError is one violation. Code is stable and meant for clients (and
+// translation); Message is a human-readable English fallback.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors is a list of violations usable as an error.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, f := range e {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Add appends a violation.
+func (e *Errors) Add(field, code, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Err returns nil when there are no violations, so it can be returned directly.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// As extracts Errors from err.
+func As(err error) (Errors, bool) {
+	var v Errors
+	if errors.As(err, &v) {
+		return v, true
+	}
+	return nil, false
+}
+
+// Body is the JSON payload handlers answer with (422 Unprocessable Entity).
+func (e Errors) Body() map[string]any {
+	return map[string]any{"error": "validation failed", "fields": e}
+}
@@ -0,0 +1,35 @@
+-- 011_refresh_tokens.sql
+-- Short-lived access tokens + rotating refresh tokens.
+-- refresh_tokens: тек sha256 hash сақталады, raw token клиентте ғана.
+-- revoked_tokens: access token jti denylist (logout кезінде).
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+  id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  family_id   UUID NOT NULL,              -- one login = one family, rotation keeps it
+  token_hash  TEXT NOT NULL UNIQUE,       -- hex(sha256(raw token))
+  expires_at  TIMESTAMPTZ NOT NULL,
+  used_at     TIMESTAMPTZ,                -- set when rotated; reuse => family revoked
+  revoked_at  TIMESTAMPTZ,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user ON refresh_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family ON refresh_tokens(family_id);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+  jti         TEXT PRIMARY KEY,
+  user_id     UUID REFERENCES users(id) ON DELETE CASCADE,
+  expires_at  TIMESTAMPTZ NOT NULL,       -- row can be purged after the token itself expires
+  revoked_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires ON revoked_tokens(expires_at);
+
+-- every access token issued before this moment is rejected (password change, "revoke all")
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS tokens_valid_after TIMESTAMPTZ;
+
+COMMIT;
@@ -0,0 +1,5 @@
+-- 013_email_verification.sql
+-- NULL = not verified yet; set by POST /auth/verify.
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
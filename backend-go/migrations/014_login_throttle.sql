@@ -0,0 +1,29 @@
+-- 014_login_throttle.sql
+-- login_attempts: failed login counters per key ("email:<addr>", "ip:<addr>").
+-- auth_audit_log: append-only trail of auth events (login ok/failed/locked).
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+  key           TEXT PRIMARY KEY,
+  failures      INT NOT NULL DEFAULT 0,
+  last_failure  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  blocked_until TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failure ON login_attempts(last_failure);
+
+CREATE TABLE IF NOT EXISTS auth_audit_log (
+  id          BIGSERIAL PRIMARY KEY,
+  user_id     UUID REFERENCES users(id) ON DELETE SET NULL,
+  email       TEXT,
+  ip          TEXT,
+  event       TEXT NOT NULL,              -- login_success | login_failed | login_locked
+  detail      TEXT,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_audit_log_user ON auth_audit_log(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_auth_audit_log_email ON auth_audit_log(email, created_at DESC);
+
+COMMIT;
@@ -0,0 +1,15 @@
+-- 015_user_roles.sql
+-- Role-based access: role is embedded in the JWT ("role" claim) and checked by
+-- middleware.RequireRole. New accounts are students.
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'student';
+
+DO $$
+BEGIN
+  ALTER TABLE users
+    ADD CONSTRAINT users_role_check CHECK (role IN ('student','counselor','editor','admin'));
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role) WHERE role <> 'student';
@@ -0,0 +1,31 @@
+-- 016_oidc.sql
+-- OIDC social login: external identities linked to users, and short-lived
+-- login state (state/nonce/PKCE verifier) between /start and /callback.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS external_identities (
+  id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  provider      TEXT NOT NULL,            -- config name, e.g. "google"
+  subject       TEXT NOT NULL,            -- "sub" claim from the issuer
+  email         TEXT,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_login_at TIMESTAMPTZ,
+  UNIQUE (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_external_identities_user ON external_identities(user_id);
+
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+  state         TEXT PRIMARY KEY,
+  provider      TEXT NOT NULL,
+  nonce         TEXT NOT NULL,
+  code_verifier TEXT NOT NULL,
+  expires_at    TIMESTAMPTZ NOT NULL,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_oidc_login_states_expires ON oidc_login_states(expires_at);
+
+COMMIT;
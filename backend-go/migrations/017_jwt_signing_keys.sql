@@ -0,0 +1,20 @@
+-- 017_jwt_signing_keys.sql
+-- JWT signing key ring (kid header). Бір уақытта тек бір active key (retired_at IS NULL);
+-- retired кілттер JWT_KEY_GRACE бітпейінше тексеру үшін қолданылады.
+-- private_pem: PKCS#8 private key (RS256 / EdDSA), secret: HS256 bytes.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+  kid          TEXT PRIMARY KEY,
+  alg          TEXT NOT NULL CHECK (alg IN ('HS256','RS256','EdDSA')),
+  secret       BYTEA,
+  private_pem  TEXT,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  retired_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_jwt_signing_keys_active
+  ON jwt_signing_keys(created_at) WHERE retired_at IS NULL;
+
+COMMIT;
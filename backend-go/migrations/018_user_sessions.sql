@@ -0,0 +1,21 @@
+-- 018_user_sessions.sql
+-- Active sessions ("where am I logged in"). Бір session = бір refresh token family
+-- (user_sessions.id = refresh_tokens.family_id); access token "sid" claim арқылы байланысады.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+  id            UUID PRIMARY KEY,
+  user_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  device        TEXT NOT NULL DEFAULT '',   -- "Chrome on Windows", derived from user agent
+  ip            TEXT NOT NULL DEFAULT '',
+  user_agent    TEXT NOT NULL DEFAULT '',
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_seen_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at    TIMESTAMPTZ NOT NULL,       -- expiry of the newest refresh token
+  revoked_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions(user_id);
+
+COMMIT;
@@ -0,0 +1,15 @@
+-- 019_account_deletion.sql
+-- DELETE /me: алдымен soft delete (deleted_at), grace period біткен соң
+-- cmd/purge_accounts users жолын өшіреді — қалғаны ON DELETE CASCADE арқылы.
+-- Grace кезінде қайта login жасау өшіруді болдырмайды.
+
+BEGIN;
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS deleted_at  TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS purge_after TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_purge_after
+  ON users(purge_after) WHERE purge_after IS NOT NULL;
+
+COMMIT;
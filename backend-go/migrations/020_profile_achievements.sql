@@ -0,0 +1,26 @@
+-- 020_profile_achievements.sql
+-- Structured achievements (олимпиада, лидерство, спорт, волонтёрлік ...).
+-- Matcher бұларды деңгей салмағымен (school < regional < national < international)
+-- AchievementCounts-қа жинайды.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS profile_achievements (
+  id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  category    TEXT NOT NULL CHECK (category IN ('olympiad','leadership','sports','volunteering','other')),
+  level       TEXT NOT NULL CHECK (level IN ('school','regional','national','international')),
+  year        INT,
+  description TEXT NOT NULL DEFAULT '',
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_profile_achievements_user ON profile_achievements(user_id);
+
+DROP TRIGGER IF EXISTS trg_profile_achievements_updated_at ON profile_achievements;
+CREATE TRIGGER trg_profile_achievements_updated_at
+BEFORE UPDATE ON profile_achievements
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+COMMIT;
@@ -0,0 +1,51 @@
+-- 021_profile_versions.sql
+-- Profile history: әр upsert жаңа immutable нұсқа жазады (profile + achievements snapshot).
+-- /profile/me/history, /profile/me/diff және /score?version= осыны пайдаланады.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS profile_versions (
+  id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  profile_id  UUID NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+  user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  version     INT NOT NULL,
+  data        JSONB NOT NULL,             -- {"profile": {...}, "achievements": [...]}
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (profile_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_profile_versions_user ON profile_versions(user_id, version DESC);
+
+-- versions are append-only; rows only disappear with their profile/user
+CREATE OR REPLACE FUNCTION profile_versions_immutable() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'profile_versions rows are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_profile_versions_immutable ON profile_versions;
+CREATE TRIGGER trg_profile_versions_immutable
+BEFORE UPDATE ON profile_versions
+FOR EACH ROW EXECUTE FUNCTION profile_versions_immutable();
+
+-- existing profiles start at version 1
+INSERT INTO profile_versions(profile_id, user_id, version, data, created_at)
+SELECT p.id, p.user_id, 1,
+  jsonb_build_object(
+    'profile', to_jsonb(p) || jsonb_build_object('budget_currency', p.budget_currency::text),
+    'achievements', COALESCE((
+      SELECT jsonb_agg(jsonb_build_object(
+        'id', a.id, 'category', a.category, 'level', a.level,
+        'year', a.year, 'description', a.description))
+      FROM profile_achievements a WHERE a.user_id = p.user_id
+    ), '[]'::jsonb)
+  ),
+  p.updated_at
+FROM profiles p
+WHERE NOT EXISTS (SELECT 1 FROM profile_versions v WHERE v.profile_id = p.id);
+
+-- which profile version a saved score was computed from (NULL = current at the time)
+ALTER TABLE scores
+  ADD COLUMN IF NOT EXISTS profile_version INT;
+
+COMMIT;
@@ -0,0 +1,15 @@
+-- 022_grading_system.sql
+-- Profile stores the grading system (us_4, percent, kz_5, ib_45, a_level, de_5),
+-- not only a scale. Кодтар тізімі Go жағында: internal/scoring/grading.go.
+-- NULL = ескі мінез: GPA / gpa_scale сызықтық.
+
+BEGIN;
+
+ALTER TABLE profiles
+  ADD COLUMN IF NOT EXISTS grading_system TEXT;
+
+-- only the unambiguous scales; 5.0 could be kz_5 or a weighted US GPA
+UPDATE profiles SET grading_system = 'us_4'    WHERE grading_system IS NULL AND gpa_scale = 4;
+UPDATE profiles SET grading_system = 'percent' WHERE grading_system IS NULL AND gpa_scale = 100;
+
+COMMIT;
@@ -0,0 +1,36 @@
+-- 023_more_tests.sql
+-- ACT, GRE, GMAT, Duolingo, PTE Academic, TestDaF: profile, requirements, admission_stats.
+-- GRE: verbal/quant 130-170 бөлек, requirements/admission_stats-та total (260-340).
+-- TestDaF: ең төмен бөлімнің TDN деңгейі (3-5), "TDN 4 в каждой части" = 4.
+-- Concordance кестелері Go жағында: internal/scoring/concordance.go.
+
+BEGIN;
+
+ALTER TABLE profiles
+  ADD COLUMN IF NOT EXISTS act        INT,
+  ADD COLUMN IF NOT EXISTS gre_verbal INT,
+  ADD COLUMN IF NOT EXISTS gre_quant  INT,
+  ADD COLUMN IF NOT EXISTS gre_awa    NUMERIC(2,1),
+  ADD COLUMN IF NOT EXISTS gmat       INT,
+  ADD COLUMN IF NOT EXISTS duolingo   INT,
+  ADD COLUMN IF NOT EXISTS pte        INT,
+  ADD COLUMN IF NOT EXISTS testdaf    INT;
+
+-- min_act, min_gre, min_gmat already exist in the old schema, nothing read them
+ALTER TABLE requirements
+  ADD COLUMN IF NOT EXISTS min_act      INT,
+  ADD COLUMN IF NOT EXISTS min_gre      INT,
+  ADD COLUMN IF NOT EXISTS min_gmat     INT,
+  ADD COLUMN IF NOT EXISTS min_duolingo INT,
+  ADD COLUMN IF NOT EXISTS min_pte      INT,
+  ADD COLUMN IF NOT EXISTS min_testdaf  INT;
+
+ALTER TABLE admission_stats
+  ADD COLUMN IF NOT EXISTS avg_act      INT,
+  ADD COLUMN IF NOT EXISTS avg_gre      INT,
+  ADD COLUMN IF NOT EXISTS avg_gmat     INT,
+  ADD COLUMN IF NOT EXISTS avg_duolingo INT,
+  ADD COLUMN IF NOT EXISTS avg_pte      INT,
+  ADD COLUMN IF NOT EXISTS avg_testdaf  NUMERIC(2,1);
+
+COMMIT;
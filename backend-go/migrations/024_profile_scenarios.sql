@@ -0,0 +1,27 @@
+-- 024_profile_scenarios.sql
+-- What-if scenarios: "IELTS 7.5 болса?" — негізгі профильді өзгертпей.
+-- overrides = profile JSON-ына RFC 7396 merge patch, әр сұрауда ағымдағы
+-- профильге қолданылады (base өзгерсе, сценарий де соған ереді).
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS profile_scenarios (
+  id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name        TEXT NOT NULL,
+  overrides   JSONB NOT NULL DEFAULT '{}'::jsonb,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (user_id, name)
+);
+
+DROP TRIGGER IF EXISTS trg_profile_scenarios_updated_at ON profile_scenarios;
+CREATE TRIGGER trg_profile_scenarios_updated_at
+BEFORE UPDATE ON profile_scenarios
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- /score history: which scenario (if any) a score was computed for
+ALTER TABLE scores
+  ADD COLUMN IF NOT EXISTS scenario_id UUID REFERENCES profile_scenarios(id) ON DELETE SET NULL;
+
+COMMIT;
@@ -0,0 +1,45 @@
+-- 025_counselor_links.sql
+-- Counselor <-> student links. Кеңесші студентті email арқылы шақырады,
+-- студент өзі қабылдайды (explicit consent) және кез келген уақытта қайтарып ала алады.
+-- scope: read = профиль/score/smart-search көру, write = өзгерту де.
+-- counselor_access_log: кеңесшінің студент деректеріне әр қатынауы.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS counselor_links (
+  id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  counselor_id  UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  student_id    UUID REFERENCES users(id) ON DELETE CASCADE, -- set on accept
+  invite_email  TEXT NOT NULL,
+  scope         TEXT NOT NULL DEFAULT 'read' CHECK (scope IN ('read','write')),
+  status        TEXT NOT NULL DEFAULT 'pending'
+                CHECK (status IN ('pending','active','declined','revoked')),
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at    TIMESTAMPTZ NOT NULL,              -- pending invitation only
+  accepted_at   TIMESTAMPTZ,
+  ended_at      TIMESTAMPTZ                        -- declined or revoked
+);
+
+CREATE INDEX IF NOT EXISTS idx_counselor_links_counselor ON counselor_links(counselor_id);
+CREATE INDEX IF NOT EXISTS idx_counselor_links_student ON counselor_links(student_id);
+CREATE INDEX IF NOT EXISTS idx_counselor_links_email ON counselor_links(lower(invite_email)) WHERE status = 'pending';
+
+-- one open invitation/link per counselor and email
+CREATE UNIQUE INDEX IF NOT EXISTS uq_counselor_links_open
+  ON counselor_links(counselor_id, lower(invite_email)) WHERE status IN ('pending','active');
+
+CREATE TABLE IF NOT EXISTS counselor_access_log (
+  id            BIGSERIAL PRIMARY KEY,
+  link_id       UUID REFERENCES counselor_links(id) ON DELETE SET NULL,
+  counselor_id  UUID REFERENCES users(id) ON DELETE SET NULL,
+  student_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  method        TEXT NOT NULL,
+  path          TEXT NOT NULL,
+  status        INT NOT NULL,
+  ip            TEXT,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_counselor_access_log_student ON counselor_access_log(student_id, created_at DESC);
+
+COMMIT;
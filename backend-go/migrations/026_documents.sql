@@ -0,0 +1,35 @@
+-- 026_documents.sql
+-- Document vault: transcripts, test reports, certificates.
+-- Файлдың өзі storage-та (local dir немесе S3), мұнда тек метадеректер.
+-- sha256 бойынша dedup: бір қолданушы бір файлды екі рет сақтамайды.
+-- document_field_links: қай құжат қай профиль өрісін растайды (IELTS report -> ielts).
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS documents (
+  id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind          TEXT NOT NULL DEFAULT 'other'
+                CHECK (kind IN ('transcript','test_report','certificate','other')),
+  filename      TEXT NOT NULL,
+  content_type  TEXT NOT NULL,               -- sniffed, not the client's claim
+  size_bytes    BIGINT NOT NULL,
+  sha256        TEXT NOT NULL,               -- hex
+  storage_key   TEXT NOT NULL,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (user_id, sha256)
+);
+
+CREATE INDEX IF NOT EXISTS idx_documents_user ON documents(user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS document_field_links (
+  user_id      UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  field        TEXT NOT NULL,                -- profile JSON field, e.g. 'ielts'
+  document_id  UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, field)
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_field_links_document ON document_field_links(document_id);
+
+COMMIT;
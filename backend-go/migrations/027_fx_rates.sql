@@ -0,0 +1,37 @@
+-- 027_fx_rates.sql
+-- Валюта бағамдары: budget (KZT) мен tuition (EUR) салыстыру үшін.
+-- ECB convention: per_eur = 1 EUR бағасы осы валютада (USD 1.08, KZT 510.2).
+-- cmd/import_fx CSV немесе ECB eurofxref XML файлынан толтырады.
+-- fx_convert: on_date-ке дейінгі ең соңғы бағам бойынша; бағам жоқ болса NULL.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS fx_rates (
+  currency    CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$' AND currency <> 'EUR'),
+  rate_date   DATE NOT NULL,
+  per_eur     NUMERIC(20,8) NOT NULL CHECK (per_eur > 0),
+  source      TEXT NOT NULL DEFAULT 'csv',
+  imported_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (currency, rate_date)
+);
+
+-- rate of one currency on a date: EUR is 1 by definition
+CREATE OR REPLACE FUNCTION fx_per_eur(cur TEXT, on_date DATE)
+RETURNS NUMERIC LANGUAGE sql STABLE AS $$
+  SELECT CASE WHEN upper(cur) = 'EUR' THEN 1::numeric ELSE (
+    SELECT per_eur FROM fx_rates
+    WHERE currency = upper(cur) AND rate_date <= on_date
+    ORDER BY rate_date DESC LIMIT 1
+  ) END
+$$;
+
+CREATE OR REPLACE FUNCTION fx_convert(amount NUMERIC, from_cur TEXT, to_cur TEXT, on_date DATE DEFAULT current_date)
+RETURNS NUMERIC LANGUAGE sql STABLE AS $$
+  SELECT CASE
+    WHEN amount IS NULL OR from_cur IS NULL OR to_cur IS NULL THEN NULL
+    WHEN upper(from_cur) = upper(to_cur) THEN amount
+    ELSE amount / fx_per_eur(from_cur, on_date) * fx_per_eur(to_cur, on_date)
+  END
+$$;
+
+COMMIT;
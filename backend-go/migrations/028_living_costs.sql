@@ -0,0 +1,24 @@
+-- 028_living_costs.sql
+-- Тұру құны (cost of living) ел/қала бойынша: жалдау, тамақ, сақтандыру, виза.
+-- city = '' — елдің әдепкі мәні, қала табылмаса соны қолданамыз.
+-- Айлық шығындар (housing, food, other) жылына 12 ай деп есептеледі;
+-- insurance_year мен visa_fee жылдық. cmd/import_living_costs CSV-ден толтырады.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS living_costs (
+  country_code    CHAR(2) NOT NULL,
+  city            TEXT NOT NULL DEFAULT '',
+  currency        CHAR(3) NOT NULL,
+  housing_month   NUMERIC(12,2) NOT NULL DEFAULT 0 CHECK (housing_month >= 0),
+  food_month      NUMERIC(12,2) NOT NULL DEFAULT 0 CHECK (food_month >= 0),
+  other_month     NUMERIC(12,2) NOT NULL DEFAULT 0 CHECK (other_month >= 0),  -- transport, utilities, phone
+  insurance_year  NUMERIC(12,2) NOT NULL DEFAULT 0 CHECK (insurance_year >= 0),
+  visa_fee        NUMERIC(12,2) NOT NULL DEFAULT 0 CHECK (visa_fee >= 0),
+  source          TEXT,
+  updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_living_costs_place ON living_costs(country_code, lower(city));
+
+COMMIT;
@@ -0,0 +1,38 @@
+-- 029_admission_model.sql
+-- Admission probability: логистикалық модель әр бағдарламаға.
+-- admission_outcomes: студенттер хабарлаған нәтижелер (admitted/rejected/
+-- waitlisted) + сол кездегі профиль көрсеткіштерінің snapshot-ы.
+-- admission_model_params: cmd/fit_admission есептеген коэффициенттер мен
+-- ковариация (4x4, row-major); жоқ болса acceptance_rate-тен prior қолданамыз.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS admission_outcomes (
+  id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  program_id  UUID NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+  year        INT NOT NULL,
+  decision    TEXT NOT NULL CHECK (decision IN ('admitted','rejected','waitlisted')),
+  -- features at the time of the report, on the model's scales
+  gpa_us4     NUMERIC(3,2),
+  sat         INT,        -- SAT or ACT via concordance
+  gre         INT,        -- verbal + quant
+  gmat        INT,
+  ielts       NUMERIC(2,1), -- best English test as IELTS band
+  testdaf     NUMERIC(2,1),
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (user_id, program_id, year)
+);
+
+CREATE INDEX IF NOT EXISTS idx_admission_outcomes_program ON admission_outcomes(program_id);
+
+CREATE TABLE IF NOT EXISTS admission_model_params (
+  program_id  UUID PRIMARY KEY REFERENCES programs(id) ON DELETE CASCADE,
+  coef        FLOAT8[] NOT NULL,  -- intercept, gpa, test, language
+  cov         FLOAT8[] NOT NULL,  -- 4x4 row-major
+  n_outcomes  INT NOT NULL DEFAULT 0,
+  fitted_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CHECK (cardinality(coef) = 4 AND cardinality(cov) = 16)
+);
+
+COMMIT;
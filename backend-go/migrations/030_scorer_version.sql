@@ -0,0 +1,40 @@
+-- 030_scorer_version.sql
+-- Scorer registry: әр сақталған баға қай scorer нұсқасымен ("match-v1",
+-- "points-v1") есептелгенін сақтаймыз. Ескі scores жолдары POST /score-тың
+-- бұрынғы engine-інен, яғни points-v1.
+-- match_history 005-те INTEGER user_id/program_id-пен жасалған, ал users мен
+-- programs UUID — FK ешқашан жұмыс істемеген, қосымша оған жазбаған.
+-- INTEGER болса, кестені UUID-пен қайта құрамыз.
+
+BEGIN;
+
+ALTER TABLE scores ADD COLUMN IF NOT EXISTS scorer_version TEXT;
+UPDATE scores SET scorer_version = 'points-v1' WHERE scorer_version IS NULL;
+ALTER TABLE scores ALTER COLUMN scorer_version SET NOT NULL;
+
+DO $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'match_history' AND column_name = 'user_id' AND data_type = 'integer'
+  ) THEN
+    DROP TABLE match_history;
+  END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS match_history (
+  id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id            UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  program_id         UUID NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+  match_score        INT NOT NULL CHECK (match_score >= 0 AND match_score <= 100),
+  category           TEXT NOT NULL,
+  breakdown          JSONB,
+  admit_probability  FLOAT8,
+  scorer_version     TEXT NOT NULL,
+  created_at         TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_match_history_user ON match_history(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_match_history_program ON match_history(program_id);
+
+COMMIT;
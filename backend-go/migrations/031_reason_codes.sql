@@ -0,0 +1,10 @@
+-- 031_reason_codes.sql
+-- Себептер енді құрылымдық: code, severity, component, params
+-- (scoring.Reason). reasons бағанында бұрынғыдай орысша мәтін қалады,
+-- reason_codes — сол себептердің коды. Ескі жолдарда код жоқ, бос массив.
+
+BEGIN;
+
+ALTER TABLE scores ADD COLUMN IF NOT EXISTS reason_codes JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+COMMIT;
@@ -0,0 +1,15 @@
+-- 032_counselor_links_expired.sql
+-- Мерзімі өткен шақырулар 'expired' күйіне ауысады, сонда олар
+-- uq_counselor_links_open индексінен шығып, жаңа шақыруды бөгемейді.
+-- Бұрынғы мерзімі өткен 'pending' жолдар да бірден ауыстырылады.
+
+BEGIN;
+
+ALTER TABLE counselor_links DROP CONSTRAINT IF EXISTS counselor_links_status_check;
+ALTER TABLE counselor_links ADD CONSTRAINT counselor_links_status_check
+  CHECK (status IN ('pending','active','declined','revoked','expired'));
+
+UPDATE counselor_links SET status='expired', ended_at=expires_at
+WHERE status='pending' AND expires_at <= now();
+
+COMMIT;
@@ -0,0 +1,10 @@
+-- 033_match_history_latest.sql
+-- Smart search match_history-ге бағдарламаның соңғы жолымен салыстырып,
+-- тек өзгерген нәтижені жазады; соңғы жолды табу үшін индекс.
+
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS idx_match_history_user_program
+  ON match_history(user_id, program_id, created_at DESC);
+
+COMMIT;